@@ -0,0 +1,275 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v43/github"
+	"github.com/gruntwork-io/git-xargs/types"
+	"github.com/gruntwork-io/go-commons/errors"
+)
+
+const (
+	// GitHub rejects app JWTs that are valid for longer than 10 minutes, so we stay comfortably below that
+	githubAppJWTLifetime = 9 * time.Minute
+	// GitHub recommends backdating the JWT issued-at time to allow for clock drift between us and GitHub
+	githubAppJWTClockDrift = 60 * time.Second
+	// Installation tokens last an hour. We refresh them this long before they expire so that a token never lapses
+	// in the middle of a clone or push
+	installationTokenRefreshWindow = 5 * time.Minute
+	// The username GitHub expects when an installation token is presented via HTTP basic auth
+	installationTokenGitUsername = "x-access-token"
+)
+
+// GithubApp authenticates git-xargs as a GitHub App rather than as a user. It signs short-lived JWTs with the app's
+// private key, looks up the installation of the app for every organization or user git-xargs operates on, and
+// exchanges the JWT for installation access tokens. Installation tokens are cached and transparently refreshed before
+// they expire, so runs that outlast a single token continue to work
+type GithubApp struct {
+	appID          int64
+	installationID int64
+	privateKey     *rsa.PrivateKey
+	server         *GithubServer
+	client         *github.Client
+	mutex          *sync.Mutex
+	installations  map[string]int64
+	tokens         map[int64]*github.InstallationToken
+}
+
+// NewGithubApp parses the supplied PEM-encoded private key and returns a GithubApp for the given app ID. If
+// installationID is non-zero, every request will use that installation. Otherwise, the installation is looked up
+// separately for each owner of the repos being processed. The app is registered on the supplied server, which is nil
+// for github.com
+func NewGithubApp(appID int64, privateKeyPEM []byte, installationID int64, server *GithubServer) (*GithubApp, error) {
+	privateKey, err := parseGithubAppPrivateKey(privateKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	app := &GithubApp{
+		appID:          appID,
+		installationID: installationID,
+		privateKey:     privateKey,
+		server:         server,
+		mutex:          &sync.Mutex{},
+		installations:  make(map[string]int64),
+		tokens:         make(map[int64]*github.InstallationToken),
+	}
+
+	// The app-level client authenticates with a freshly signed JWT on every request. It is only used to look up
+	// installations and mint installation tokens
+	app.client = server.newAPIClient(&http.Client{Transport: &githubAppJWTTransport{app: app}})
+
+	return app, nil
+}
+
+// parseGithubAppPrivateKey decodes the PEM-encoded RSA private key that GitHub generates for an app. GitHub hands out
+// PKCS#1 keys, but we also accept PKCS#8 in case the key has been converted
+func parseGithubAppPrivateKey(privateKeyPEM []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(privateKeyPEM)
+	if block == nil {
+		return nil, errors.WithStackTrace(types.GithubAppPrivateKeyInvalidErr{})
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, errors.WithStackTrace(types.GithubAppPrivateKeyInvalidErr{})
+	}
+
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.WithStackTrace(types.GithubAppPrivateKeyInvalidErr{})
+	}
+
+	return key, nil
+}
+
+// GenerateJWT returns a JWT, signed with the app's private key using RS256, that identifies the app itself to the
+// GitHub API. See https://docs.github.com/en/apps/creating-github-apps/authenticating-with-a-github-app/generating-a-json-web-token-jwt-for-a-github-app
+func (a *GithubApp) GenerateJWT() (string, error) {
+	now := time.Now()
+
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+
+	claims, err := json.Marshal(map[string]interface{}{
+		"iat": now.Add(-githubAppJWTClockDrift).Unix(),
+		"exp": now.Add(githubAppJWTLifetime).Unix(),
+		"iss": strconv.FormatInt(a.appID, 10),
+	})
+	if err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+
+	signingInput := fmt.Sprintf("%s.%s", base64.RawURLEncoding.EncodeToString(header), base64.RawURLEncoding.EncodeToString(claims))
+
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, a.privateKey, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+
+	return fmt.Sprintf("%s.%s", signingInput, base64.RawURLEncoding.EncodeToString(signature)), nil
+}
+
+// installationIDForOwner returns the ID of the app installation that grants access to the given organization or user's
+// repos. Lookups are cached, since every repo belonging to the same owner shares an installation
+func (a *GithubApp) installationIDForOwner(owner string) (int64, error) {
+	if a.installationID != 0 {
+		return a.installationID, nil
+	}
+
+	a.mutex.Lock()
+	id, ok := a.installations[owner]
+	a.mutex.Unlock()
+	if ok {
+		return id, nil
+	}
+
+	installation, resp, err := a.client.Apps.FindOrganizationInstallation(context.Background(), owner)
+	if err != nil && resp != nil && resp.StatusCode == http.StatusNotFound {
+		// The owner may be a personal account rather than an organization
+		installation, _, err = a.client.Apps.FindUserInstallation(context.Background(), owner)
+	}
+	if err != nil {
+		return 0, errors.WithStackTrace(types.GithubAppInstallationNotFoundErr{Owner: owner, Underlying: err})
+	}
+
+	a.mutex.Lock()
+	a.installations[owner] = installation.GetID()
+	a.mutex.Unlock()
+
+	return installation.GetID(), nil
+}
+
+// InstallationToken returns a valid installation access token for the given organization or user. Tokens are reused
+// until they are about to expire, at which point a new one is requested from GitHub
+func (a *GithubApp) InstallationToken(owner string) (string, error) {
+	id, err := a.installationIDForOwner(owner)
+	if err != nil {
+		return "", err
+	}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if token, ok := a.tokens[id]; ok && time.Until(token.GetExpiresAt()) > installationTokenRefreshWindow {
+		return token.GetToken(), nil
+	}
+
+	token, _, err := a.client.Apps.CreateInstallationToken(context.Background(), id, nil)
+	if err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+
+	a.tokens[id] = token
+
+	return token.GetToken(), nil
+}
+
+// BasicAuth satisfies the GitCredentials interface, so that clones and pushes are authenticated with the installation
+// token for the repo's owner
+func (a *GithubApp) BasicAuth(owner string) (string, string, error) {
+	token, err := a.InstallationToken(owner)
+	if err != nil {
+		return "", "", err
+	}
+	return installationTokenGitUsername, token, nil
+}
+
+// BotIdentity returns the name and email address of the app's bot user, which git-xargs uses as the commit author so
+// that commits are attributed to the app in the same way as pull requests
+func (a *GithubApp) BotIdentity() (string, string, error) {
+	app, _, err := a.client.Apps.Get(context.Background(), "")
+	if err != nil {
+		return "", "", errors.WithStackTrace(err)
+	}
+
+	botLogin := fmt.Sprintf("%s[bot]", app.GetSlug())
+
+	// Looking up the bot user doesn't require any particular authentication, but the app JWT works fine for it
+	botUser, _, err := a.client.Users.Get(context.Background(), botLogin)
+	if err != nil {
+		return "", "", errors.WithStackTrace(err)
+	}
+
+	noReplyHost := fmt.Sprintf("users.noreply.%s", a.server.Hostname())
+
+	return botLogin, fmt.Sprintf("%d+%s@%s", botUser.GetID(), botLogin, noReplyHost), nil
+}
+
+// githubAppJWTTransport authenticates every request it sends as the app itself, using a freshly signed JWT
+type githubAppJWTTransport struct {
+	app *GithubApp
+}
+
+func (t *githubAppJWTTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	jwt, err := t.app.GenerateJWT()
+	if err != nil {
+		return nil, err
+	}
+
+	// RoundTrippers must not modify the request they are given
+	authenticated := req.Clone(req.Context())
+	authenticated.Header.Set("Authorization", fmt.Sprintf("Bearer %s", jwt))
+
+	return t.app.server.Transport().RoundTrip(authenticated)
+}
+
+// githubAppInstallationTransport authenticates every request it sends with the installation token for the owner of
+// the repo or organization the request is about
+type githubAppInstallationTransport struct {
+	app *GithubApp
+}
+
+func (t *githubAppInstallationTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	owner := ownerFromAPIPath(req.URL.Path)
+	if owner == "" && t.app.installationID == 0 {
+		return nil, errors.WithStackTrace(types.GithubAppOwnerUnknownErr{Path: req.URL.Path})
+	}
+
+	token, err := t.app.InstallationToken(owner)
+	if err != nil {
+		return nil, err
+	}
+
+	authenticated := req.Clone(req.Context())
+	authenticated.Header.Set("Authorization", fmt.Sprintf("token %s", token))
+
+	return t.app.server.Transport().RoundTrip(authenticated)
+}
+
+// ownerFromAPIPath extracts the organization or user from GitHub API paths such as /repos/<owner>/<repo>/pulls or
+// /orgs/<org>/repos, including their GitHub Enterprise equivalents, which are prefixed with /api/v3
+func ownerFromAPIPath(path string) string {
+	segments := strings.Split(strings.Trim(strings.TrimPrefix(path, "/api/v3"), "/"), "/")
+	if len(segments) < 2 {
+		return ""
+	}
+
+	switch segments[0] {
+	case "repos", "orgs", "users":
+		return segments[1]
+	default:
+		return ""
+	}
+}
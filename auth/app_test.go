@@ -0,0 +1,138 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestGithubApp(t *testing.T, installationID int64) (*GithubApp, *rsa.PrivateKey) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	app, err := NewGithubApp(12345, keyPEM, installationID, nil)
+	require.NoError(t, err)
+
+	return app, key
+}
+
+func TestNewGithubAppRejectsInvalidPrivateKey(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewGithubApp(12345, []byte("not a private key"), 0, nil)
+	assert.Error(t, err)
+}
+
+// TestGithubAppGenerateJWT ensures the JWT is signed with the app's private key and identifies the app
+func TestGithubAppGenerateJWT(t *testing.T) {
+	t.Parallel()
+
+	app, key := newTestGithubApp(t, 0)
+
+	jwt, err := app.GenerateJWT()
+	require.NoError(t, err)
+
+	parts := strings.Split(jwt, ".")
+	require.Len(t, parts, 3)
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	require.NoError(t, err)
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	assert.NoError(t, rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], signature))
+
+	rawClaims, err := base64.RawURLEncoding.DecodeString(parts[1])
+	require.NoError(t, err)
+	claims := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(rawClaims, &claims))
+	assert.Equal(t, "12345", claims["iss"])
+	// GitHub rejects JWTs that expire more than 10 minutes into the future
+	assert.LessOrEqual(t, claims["exp"].(float64), float64(time.Now().Add(10*time.Minute).Unix()))
+	assert.Less(t, claims["iat"].(float64), float64(time.Now().Unix()))
+}
+
+// TestGithubAppInstallationTokenRefresh ensures installation tokens are looked up per owner, reused while they are
+// valid and requested again once they are about to expire
+func TestGithubAppInstallationTokenRefresh(t *testing.T) {
+	t.Parallel()
+
+	var tokensIssued int32
+	var expiresIn atomic.Value
+	expiresIn.Store(time.Hour)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "Bearer "))
+
+		switch r.URL.Path {
+		case "/orgs/gruntwork-io/installation":
+			fmt.Fprint(w, `{"id": 42}`)
+		case "/app/installations/42/access_tokens":
+			issued := atomic.AddInt32(&tokensIssued, 1)
+			expiresAt := time.Now().Add(expiresIn.Load().(time.Duration)).UTC().Format(time.RFC3339)
+			fmt.Fprintf(w, `{"token": "token-%d", "expires_at": "%s"}`, issued, expiresAt)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	app, _ := newTestGithubApp(t, 0)
+	app.client.BaseURL, _ = url.Parse(server.URL + "/")
+
+	token, err := app.InstallationToken("gruntwork-io")
+	require.NoError(t, err)
+	assert.Equal(t, "token-1", token)
+
+	// The first token is still valid, so it should be reused
+	token, err = app.InstallationToken("gruntwork-io")
+	require.NoError(t, err)
+	assert.Equal(t, "token-1", token)
+
+	// Force the cached token into its refresh window, which should cause a new one to be issued
+	expiresIn.Store(time.Minute)
+	app.tokens[42].ExpiresAt = nil
+	username, password, err := app.BasicAuth("gruntwork-io")
+	require.NoError(t, err)
+	assert.Equal(t, "x-access-token", username)
+	assert.Equal(t, "token-2", password)
+
+	_, err = app.InstallationToken("not-installed")
+	assert.Error(t, err)
+}
+
+func TestOwnerFromAPIPath(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		path     string
+		expected string
+	}{
+		{"/repos/gruntwork-io/git-xargs/pulls", "gruntwork-io"},
+		{"/api/v3/repos/gruntwork-io/git-xargs", "gruntwork-io"},
+		{"/orgs/gruntwork-io/repos", "gruntwork-io"},
+		{"/users/octocat", "octocat"},
+		{"/user", ""},
+		{"/rate_limit", ""},
+	}
+
+	for _, testCase := range testCases {
+		assert.Equal(t, testCase.expected, ownerFromAPIPath(testCase.path), testCase.path)
+	}
+}
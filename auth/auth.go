@@ -2,8 +2,7 @@ package auth
 
 import (
 	"context"
-	"fmt"
-	"os"
+	"net/http"
 
 	"github.com/google/go-github/v43/github"
 	"github.com/gruntwork-io/git-xargs/types"
@@ -22,6 +21,12 @@ type githubPullRequestService interface {
 type githubRepositoriesService interface {
 	Get(ctx context.Context, owner, repo string) (*github.Repository, *github.Response, error)
 	ListByOrg(ctx context.Context, org string, opts *github.RepositoryListByOrgOptions) ([]*github.Repository, *github.Response, error)
+	GetSignaturesProtectedBranch(ctx context.Context, owner, repo, branch string) (*github.SignaturesProtectedBranch, *github.Response, error)
+}
+
+// The go-github package satisfies this Users service's interface in production
+type githubUsersService interface {
+	Get(ctx context.Context, user string) (*github.User, *github.Response, error)
 }
 
 // GithubClient is the data structure that is common between production code and test code. In production code,
@@ -32,47 +37,62 @@ type githubRepositoriesService interface {
 type GithubClient struct {
 	PullRequests githubPullRequestService
 	Repositories githubRepositoriesService
+	Users        githubUsersService
 }
 
 func NewClient(client *github.Client) GithubClient {
 	return GithubClient{
 		PullRequests: client.PullRequests,
 		Repositories: client.Repositories,
+		Users:        client.Users,
 	}
 }
 
-// ConfigureGithubClient creates a GitHub API client using the user-supplied GITHUB_OAUTH_TOKEN and returns the configured GitHub client
-func ConfigureGithubClient() GithubClient {
-	// Ensure user provided a GITHUB_OAUTH_TOKEN
-	GithubOauthToken := os.Getenv("GITHUB_OAUTH_TOKEN")
-
-	ts := oauth2.StaticTokenSource(
-		&oauth2.Token{AccessToken: GithubOauthToken},
-	)
-
-	tc := oauth2.NewClient(context.Background(), ts)
+// GitCredentials supplies the username and password that git-xargs presents over HTTPS when cloning, pulling and
+// pushing repos belonging to the given GitHub organization or user
+type GitCredentials interface {
+	BasicAuth(owner string) (string, string, error)
+}
 
-	var githubClient *github.Client
+// TokenCredentials authenticates git operations with a personal access token, using the repo owner's login as the
+// username
+type TokenCredentials struct {
+	Token string
+}
 
-	if os.Getenv("GITHUB_HOSTNAME") != "" {
-		GithubHostname := os.Getenv("GITHUB_HOSTNAME")
-		baseUrl := fmt.Sprintf("https://%s/", GithubHostname)
+func (t TokenCredentials) BasicAuth(owner string) (string, string, error) {
+	return owner, t.Token, nil
+}
 
-		githubClient, _ = github.NewEnterpriseClient(baseUrl, baseUrl, tc)
+// ConfigureGithubClient creates a GitHub API client for the supplied server using the supplied token, as returned by
+// ResolveTokens, and returns the configured GitHub client
+func ConfigureGithubClient(token string, server *GithubServer) GithubClient {
+	ts := oauth2.StaticTokenSource(
+		&oauth2.Token{AccessToken: token},
+	)
 
-	} else {
-		githubClient = github.NewClient(tc)
-	}
+	// oauth2 sends its requests via whichever http client it finds in the context, which lets us supply the
+	// server's proxy and CA settings
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, &http.Client{Transport: server.Transport()})
+	tc := oauth2.NewClient(ctx, ts)
 
 	// Wrap the go-github client in a GithubClient struct, which is common between production and test code
-	client := NewClient(githubClient)
+	client := NewClient(server.newAPIClient(tc))
 
 	return client
 }
 
-// EnsureGithubOauthTokenSet is a sanity check that a value is exported for GITHUB_OAUTH_TOKEN
-func EnsureGithubOauthTokenSet() error {
-	if os.Getenv("GITHUB_OAUTH_TOKEN") == "" {
+// ConfigureGithubAppClient creates a GitHub API client that authenticates each request with the supplied GitHub App's
+// installation token for the organization or user that owns the targeted repo
+func ConfigureGithubAppClient(app *GithubApp) GithubClient {
+	tc := &http.Client{Transport: &githubAppInstallationTransport{app: app}}
+
+	return NewClient(app.server.newAPIClient(tc))
+}
+
+// EnsureGithubOauthTokenSet is a sanity check that one of the supported token sources supplied at least one token
+func EnsureGithubOauthTokenSet(tokens []string) error {
+	if len(tokens) == 0 {
 		return errors.WithStackTrace(types.NoGithubOauthTokenProvidedErr{})
 	}
 	return nil
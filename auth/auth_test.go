@@ -1,41 +1,43 @@
 package auth
 
 import (
-	"os"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // TestConfigureGithubClient performs a sanity check that you can configure a production GitHub API client
-// If GITHUB_HOSTNAME, use the github.NewEnterpriseClient
+// If a GitHub Enterprise server is configured, the client is pointed at it
 func TestConfigureGithubClient(t *testing.T) {
 	t.Parallel()
 
 	t.Run("returns github client", func(t *testing.T) {
-		client := ConfigureGithubClient()
+		client := ConfigureGithubClient("test-token", nil)
 		assert.NotNil(t, client)
 	})
 	t.Run("returns github client with GithubHostname", func(t *testing.T) {
-		GithubHostname := "ghe.my-domain.com"
-		os.Setenv("GITHUB_HOSTNAME", GithubHostname)
+		server, err := NewGithubServer(ServerOptions{Hostname: "ghe.my-domain.com"})
+		require.NoError(t, err)
 
-		client := ConfigureGithubClient()
+		client := ConfigureGithubClient("test-token", server)
 		assert.NotNil(t, client)
 
 	})
 
 }
 
-// TestNoGithubOauthTokenPassed temporarily drops the existing GITHUB_OAUTH_TOKEN env var to ensure that the validation
-// code throws an error when it is missing. It then replaces it. This is therefore the one test that cannot be run in
-// parallel.
+// TestNoGithubOauthTokenPassed temporarily drops the existing token env vars to ensure that the validation
+// code throws an error when they are missing. t.Setenv restores them afterwards, which is why this test cannot be run
+// in parallel.
 func TestNoGithubOAuthTokenPassed(t *testing.T) {
-	token := os.Getenv("GITHUB_OAUTH_TOKEN")
-	defer os.Setenv("GITHUB_OAUTH_TOKEN", token)
+	t.Setenv("GITHUB_OAUTH_TOKEN", "")
+	t.Setenv("GITHUB_TOKEN", "")
+	t.Setenv("GH_TOKEN", "")
 
-	os.Setenv("GITHUB_OAUTH_TOKEN", "")
+	tokens, err := ResolveTokens(TokenOptions{})
+	assert.NoError(t, err)
 
-	err := EnsureGithubOauthTokenSet()
+	err = EnsureGithubOauthTokenSet(tokens)
 	assert.Error(t, err)
 }
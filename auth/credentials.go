@@ -0,0 +1,141 @@
+package auth
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"unicode"
+
+	"github.com/gruntwork-io/git-xargs/types"
+	"github.com/gruntwork-io/go-commons/errors"
+)
+
+// The environment variables git-xargs reads a GitHub token from, in order of preference. GITHUB_TOKEN and GH_TOKEN
+// are the variables used by GitHub Actions and the gh CLI respectively, so supporting them lets git-xargs pick up a
+// token that is already present in those environments
+var githubTokenEnvVars = []string{"GITHUB_OAUTH_TOKEN", "GITHUB_TOKEN", "GH_TOKEN"}
+
+// TokenOptions describes the user-configured sources git-xargs may read a GitHub token from, in addition to the
+// environment
+type TokenOptions struct {
+	// TokenFile is the path to a file containing one or more tokens
+	TokenFile string
+	// TokenCommand is a shell command, such as `vault read -field=token secret/github`, whose stdout is one or more tokens
+	TokenCommand string
+}
+
+// ResolveTokens returns the GitHub tokens from the first source that supplies any, checking in order: the token file,
+// the token command and then the GITHUB_OAUTH_TOKEN, GITHUB_TOKEN and GH_TOKEN environment variables. Each source may
+// supply several tokens, separated by newlines, whitespace or commas, in which case they are used as a TokenPool. It
+// returns an empty slice if no source supplies a token, and an error only if a configured source could not be read
+func ResolveTokens(opts TokenOptions) ([]string, error) {
+	if opts.TokenFile != "" {
+		return readTokenFile(opts.TokenFile)
+	}
+
+	if opts.TokenCommand != "" {
+		return runTokenCommand(opts.TokenCommand)
+	}
+
+	return TokensFromEnvironment(), nil
+}
+
+// TokensFromEnvironment returns the tokens in the first of GITHUB_OAUTH_TOKEN, GITHUB_TOKEN and GH_TOKEN that is set
+func TokensFromEnvironment() []string {
+	for _, envVar := range githubTokenEnvVars {
+		if tokens := splitTokens(os.Getenv(envVar)); len(tokens) > 0 {
+			return tokens
+		}
+	}
+	return []string{}
+}
+
+// TokenFromEnvironment returns the first token supplied via the environment, or an empty string if there is none
+func TokenFromEnvironment() string {
+	tokens := TokensFromEnvironment()
+	if len(tokens) == 0 {
+		return ""
+	}
+	return tokens[0]
+}
+
+// splitTokens splits a list of tokens separated by newlines, whitespace or commas
+func splitTokens(tokens string) []string {
+	return strings.FieldsFunc(tokens, func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	})
+}
+
+// readTokenFile reads the tokens from the given file, which may contain one or more of them
+func readTokenFile(path string) ([]string, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	return splitTokens(string(contents)), nil
+}
+
+// runTokenCommand executes the given command via the shell and returns its stdout as the tokens. Stderr is captured
+// so that it can be reported if the command fails, but is otherwise discarded to avoid cluttering the output of the run
+func runTokenCommand(command string) ([]string, error) {
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, errors.WithStackTrace(types.TokenCommandFailedErr{Command: command, Stderr: strings.TrimSpace(stderr.String()), Underlying: err})
+	}
+
+	return splitTokens(stdout.String()), nil
+}
+
+// GitCredentialHelper delegates git authentication to whichever credential helper is configured in the user's git
+// config (e.g., osxkeychain, libsecret or a custom helper), by calling `git credential fill` in the same way git itself
+// does before an HTTPS operation. Server is the GitHub server whose credentials are requested, which is nil for github.com
+type GitCredentialHelper struct {
+	Server *GithubServer
+}
+
+func (h GitCredentialHelper) BasicAuth(owner string) (string, string, error) {
+	host := h.Server.Hostname()
+
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.Command("git", "credential", "fill")
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("protocol=https\nhost=%s\n\n", host))
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	// Never let git fall back to prompting on the terminal, which would hang a concurrent run
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+
+	if err := cmd.Run(); err != nil {
+		return "", "", errors.WithStackTrace(types.GitCredentialHelperFailedErr{Host: host, Stderr: strings.TrimSpace(stderr.String()), Underlying: err})
+	}
+
+	var username, password string
+
+	scanner := bufio.NewScanner(&stdout)
+	for scanner.Scan() {
+		key, value, found := strings.Cut(scanner.Text(), "=")
+		if !found {
+			continue
+		}
+		switch key {
+		case "username":
+			username = value
+		case "password":
+			password = value
+		}
+	}
+
+	if password == "" {
+		return "", "", errors.WithStackTrace(types.GitCredentialHelperFailedErr{Host: host, Stderr: "no password returned"})
+	}
+
+	return username, password, nil
+}
@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestResolveTokens ensures tokens are read from each supported source in order of precedence. It sets environment
+// variables, so it cannot be run in parallel
+func TestResolveTokens(t *testing.T) {
+	t.Setenv("GITHUB_OAUTH_TOKEN", "")
+	t.Setenv("GITHUB_TOKEN", "")
+	t.Setenv("GH_TOKEN", "gh-token")
+
+	tokens, err := ResolveTokens(TokenOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"gh-token"}, tokens)
+
+	t.Setenv("GITHUB_TOKEN", "github-token")
+	tokens, err = ResolveTokens(TokenOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"github-token"}, tokens)
+
+	t.Setenv("GITHUB_OAUTH_TOKEN", "oauth-token")
+	tokens, err = ResolveTokens(TokenOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"oauth-token"}, tokens)
+
+	tokens, err = ResolveTokens(TokenOptions{TokenCommand: "echo command-token"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"command-token"}, tokens)
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("file-token\n"), 0600))
+	tokens, err = ResolveTokens(TokenOptions{TokenFile: tokenFile, TokenCommand: "echo command-token"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"file-token"}, tokens)
+
+	// Several tokens can be supplied by any source, to be used as a pool
+	require.NoError(t, os.WriteFile(tokenFile, []byte("first-token\nsecond-token\n\n"), 0600))
+	tokens, err = ResolveTokens(TokenOptions{TokenFile: tokenFile})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"first-token", "second-token"}, tokens)
+
+	t.Setenv("GITHUB_OAUTH_TOKEN", "first-token,second-token")
+	tokens, err = ResolveTokens(TokenOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"first-token", "second-token"}, tokens)
+}
+
+func TestResolveTokensReportsFailingSources(t *testing.T) {
+	t.Parallel()
+
+	_, err := ResolveTokens(TokenOptions{TokenCommand: "echo oops >&2 && exit 1"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "oops")
+
+	_, err = ResolveTokens(TokenOptions{TokenFile: filepath.Join(t.TempDir(), "missing")})
+	assert.Error(t, err)
+}
+
+// TestGitCredentialHelper configures a throwaway credential helper via git's environment-based config and ensures its
+// credentials are returned. It sets environment variables, so it cannot be run in parallel
+func TestGitCredentialHelper(t *testing.T) {
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_CONFIG_COUNT", "1")
+	t.Setenv("GIT_CONFIG_KEY_0", "credential.helper")
+	t.Setenv("GIT_CONFIG_VALUE_0", "!f() { echo username=helper-user; echo password=helper-password; }; f")
+
+	username, password, err := GitCredentialHelper{}.BasicAuth("gruntwork-io")
+	require.NoError(t, err)
+	assert.Equal(t, "helper-user", username)
+	assert.Equal(t, "helper-password", password)
+}
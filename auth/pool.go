@@ -0,0 +1,235 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gruntwork-io/git-xargs/types"
+)
+
+const (
+	// RoundRobinRotation cycles through the pool's tokens in order, skipping any that are benched
+	RoundRobinRotation = "round-robin"
+	// MostRemainingRotation picks the token with the most remaining requests, according to the rate limit headers
+	// GitHub returned for its most recent request
+	MostRemainingRotation = "most-remaining"
+)
+
+// pooledToken tracks a single token in a TokenPool, along with what GitHub last told us about its rate limit
+type pooledToken struct {
+	token        string
+	label        string
+	requests     int
+	remaining    int
+	benchedUntil time.Time
+}
+
+// TokenPool spreads GitHub API requests across several tokens, so that large runs are not held up by the primary rate
+// limit of a single token. Tokens are picked either round-robin or by most remaining quota. A token whose quota is
+// exhausted is benched until its rate limit resets, and the request that exhausted it is retried with another token
+type TokenPool struct {
+	tokens   []*pooledToken
+	strategy string
+	server   *GithubServer
+	next     int
+	mutex    *sync.Mutex
+}
+
+// NewTokenPool returns a TokenPool that rotates through the given tokens using the given strategy, sending requests to
+// the supplied server
+func NewTokenPool(tokens []string, strategy string, server *GithubServer) *TokenPool {
+	pool := &TokenPool{
+		strategy: strategy,
+		server:   server,
+		mutex:    &sync.Mutex{},
+	}
+
+	for idx, token := range tokens {
+		pool.tokens = append(pool.tokens, &pooledToken{
+			token: token,
+			label: tokenLabel(idx, token),
+			// We don't know the remaining quota until GitHub tells us, so treat every token as having a full quota
+			remaining: -1,
+		})
+	}
+
+	return pool
+}
+
+// tokenLabel returns a description of the token that is safe to print in the run report: its position in the pool
+// and its last four characters
+func tokenLabel(idx int, token string) string {
+	suffix := token
+	if len(token) > 4 {
+		suffix = token[len(token)-4:]
+	}
+	return fmt.Sprintf("#%d (...%s)", idx+1, suffix)
+}
+
+// pick selects the token to use for the next request. Benched tokens are only used if every token is benched, in
+// which case the one that resets soonest is returned
+func (p *TokenPool) pick() *pooledToken {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	now := time.Now()
+
+	var available []*pooledToken
+	for idx := range p.tokens {
+		// Walk the pool starting from the round-robin cursor, so that ties are broken in round-robin order too
+		candidate := p.tokens[(p.next+idx)%len(p.tokens)]
+		if !candidate.benchedUntil.After(now) {
+			available = append(available, candidate)
+		}
+	}
+
+	if len(available) == 0 {
+		soonest := p.tokens[0]
+		for _, candidate := range p.tokens[1:] {
+			if candidate.benchedUntil.Before(soonest.benchedUntil) {
+				soonest = candidate
+			}
+		}
+		return soonest
+	}
+
+	chosen := available[0]
+	if p.strategy == MostRemainingRotation {
+		for _, candidate := range available[1:] {
+			if remainingOrFull(candidate) > remainingOrFull(chosen) {
+				chosen = candidate
+			}
+		}
+	}
+
+	for idx, candidate := range p.tokens {
+		if candidate == chosen {
+			p.next = (idx + 1) % len(p.tokens)
+		}
+	}
+
+	return chosen
+}
+
+// remainingOrFull treats tokens we have not used yet as having more quota than any token we have used
+func remainingOrFull(t *pooledToken) int {
+	if t.remaining < 0 {
+		return int(^uint(0) >> 1)
+	}
+	return t.remaining
+}
+
+// hasAvailableToken returns true if at least one token in the pool is not benched
+func (p *TokenPool) hasAvailableToken() bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for _, t := range p.tokens {
+		if !t.benchedUntil.After(time.Now()) {
+			return true
+		}
+	}
+	return false
+}
+
+// record updates the token's usage and rate limit state from GitHub's response. It returns true if the token was
+// benched because GitHub reported it as rate limited
+func (p *TokenPool) record(t *pooledToken, resp *http.Response) bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	t.requests++
+
+	if remaining, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining")); err == nil {
+		t.remaining = remaining
+	}
+
+	var resetAt time.Time
+	if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		resetAt = time.Unix(reset, 0)
+	}
+
+	rateLimited := resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests
+
+	// Secondary rate limits come with a Retry-After header rather than an exhausted primary quota
+	if retryAfter, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && rateLimited {
+		t.benchedUntil = time.Now().Add(time.Duration(retryAfter) * time.Second)
+		return true
+	}
+
+	if t.remaining == 0 && !resetAt.IsZero() {
+		t.benchedUntil = resetAt
+		return rateLimited
+	}
+
+	return false
+}
+
+// RoundTrip satisfies http.RoundTripper, authenticating each request with a token from the pool. If GitHub reports
+// the token as rate limited and another token is available, the request is retried with that token
+func (p *TokenPool) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		t := p.pick()
+
+		authenticated := req.Clone(req.Context())
+		authenticated.Header.Set("Authorization", fmt.Sprintf("Bearer %s", t.token))
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			authenticated.Body = body
+		}
+
+		resp, err := p.server.Transport().RoundTrip(authenticated)
+		if err != nil {
+			return nil, err
+		}
+
+		benched := p.record(t, resp)
+
+		replayable := req.Body == nil || req.GetBody != nil
+		if !benched || !replayable || attempt >= len(p.tokens)-1 || !p.hasAvailableToken() {
+			return resp, nil
+		}
+
+		// Discard the rate limited response and try again with the next token
+		resp.Body.Close()
+	}
+}
+
+// BasicAuth satisfies the GitCredentials interface. Clones and pushes don't count against the API rate limit, so this
+// doesn't count as usage, but it does avoid handing out tokens that GitHub has benched
+func (p *TokenPool) BasicAuth(owner string) (string, string, error) {
+	return owner, p.pick().token, nil
+}
+
+// Usage returns how many API requests were made with each token in the pool and how many requests GitHub last
+// reported as remaining for it, so that they can be included in the run report
+func (p *TokenPool) Usage() []types.TokenUsage {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	var usage []types.TokenUsage
+	for _, t := range p.tokens {
+		remaining := "unknown"
+		if t.remaining >= 0 {
+			remaining = strconv.Itoa(t.remaining)
+		}
+		usage = append(usage, types.TokenUsage{
+			Token:     t.label,
+			Requests:  t.requests,
+			Remaining: remaining,
+		})
+	}
+	return usage
+}
+
+// ConfigureGithubClientWithTokenPool creates a GitHub API client that authenticates each request with a token from the
+// supplied pool
+func ConfigureGithubClientWithTokenPool(pool *TokenPool) GithubClient {
+	return NewClient(pool.server.newAPIClient(&http.Client{Transport: pool}))
+}
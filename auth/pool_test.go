@@ -0,0 +1,96 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/git-xargs/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newRateLimitedServer returns a test server that reports the given remaining quota for each token, and records which
+// token authenticated each request
+func newRateLimitedServer(t *testing.T, remaining map[string]int) (*httptest.Server, func() []string) {
+	var mutex sync.Mutex
+	var seen []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+		mutex.Lock()
+		seen = append(seen, token)
+		mutex.Unlock()
+
+		w.Header().Set("X-RateLimit-Remaining", fmt.Sprintf("%d", remaining[token]))
+		w.Header().Set("X-RateLimit-Reset", fmt.Sprintf("%d", time.Now().Add(time.Hour).Unix()))
+		if remaining[token] == 0 {
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server, func() []string {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return seen
+	}
+}
+
+func sendPoolRequests(t *testing.T, pool *TokenPool, url string, count int) {
+	client := &http.Client{Transport: pool}
+	for i := 0; i < count; i++ {
+		resp, err := client.Get(url)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+}
+
+func TestTokenPoolRoundRobin(t *testing.T) {
+	t.Parallel()
+
+	server, seen := newRateLimitedServer(t, map[string]int{"token-a": 10, "token-b": 100})
+	pool := NewTokenPool([]string{"token-a", "token-b"}, RoundRobinRotation, nil)
+
+	sendPoolRequests(t, pool, server.URL, 4)
+
+	assert.Equal(t, []string{"token-a", "token-b", "token-a", "token-b"}, seen())
+}
+
+func TestTokenPoolMostRemaining(t *testing.T) {
+	t.Parallel()
+
+	server, seen := newRateLimitedServer(t, map[string]int{"token-a": 10, "token-b": 100})
+	pool := NewTokenPool([]string{"token-a", "token-b"}, MostRemainingRotation, nil)
+
+	sendPoolRequests(t, pool, server.URL, 4)
+
+	// Each token is tried once before GitHub has told us anything about its quota, after which token-b has more left
+	assert.Equal(t, []string{"token-a", "token-b", "token-b", "token-b"}, seen())
+	assert.Equal(t, []types.TokenUsage{
+		{Token: "#1 (...en-a)", Requests: 1, Remaining: "10"},
+		{Token: "#2 (...en-b)", Requests: 3, Remaining: "100"},
+	}, pool.Usage())
+}
+
+// TestTokenPoolBenchesExhaustedTokens ensures a rate limited request is retried with another token, and that the
+// exhausted token isn't used again until its rate limit resets
+func TestTokenPoolBenchesExhaustedTokens(t *testing.T) {
+	t.Parallel()
+
+	server, seen := newRateLimitedServer(t, map[string]int{"token-a": 0, "token-b": 100})
+	pool := NewTokenPool([]string{"token-a", "token-b"}, RoundRobinRotation, nil)
+
+	sendPoolRequests(t, pool, server.URL, 3)
+
+	assert.Equal(t, []string{"token-a", "token-b", "token-b", "token-b"}, seen())
+
+	_, password, err := pool.BasicAuth("gruntwork-io")
+	require.NoError(t, err)
+	assert.Equal(t, "token-b", password)
+}
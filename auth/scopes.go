@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/gruntwork-io/go-commons/errors"
+)
+
+// TokenScopes looks up the OAuth scopes GitHub grants to the tokens git-xargs pushes with. Each token is only looked up
+// the first time it's needed, and its scopes are remembered for the rest of the run
+type TokenScopes struct {
+	server *GithubServer
+	scopes map[string]map[string]bool
+	mutex  *sync.Mutex
+}
+
+// NewTokenScopes returns a TokenScopes that looks up tokens' scopes on the supplied server
+func NewTokenScopes(server *GithubServer) *TokenScopes {
+	return &TokenScopes{
+		server: server,
+		scopes: map[string]map[string]bool{},
+		mutex:  &sync.Mutex{},
+	}
+}
+
+// Get returns the scopes granted to the supplied token, as reported by GitHub in the X-OAuth-Scopes header. It returns
+// nil if GitHub reports none, which is the case for fine-grained tokens, whose permissions GitHub checks on each push
+// instead
+func (s *TokenScopes) Get(token string) (map[string]bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if scopes, ok := s.scopes[token]; ok {
+		return scopes, nil
+	}
+
+	_, resp, err := ConfigureGithubClient(token, s.server).Users.Get(context.Background(), "")
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+
+	var scopes map[string]bool
+	if header := resp.Header.Get("X-OAuth-Scopes"); header != "" {
+		scopes = map[string]bool{}
+		for _, scope := range strings.Split(header, ",") {
+			scopes[strings.TrimSpace(scope)] = true
+		}
+	}
+
+	s.scopes[token] = scopes
+	return scopes, nil
+}
@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newScopedServer returns a test server that reports the given scopes for each token, and counts the lookups made
+// with each token
+func newScopedServer(t *testing.T, scopes map[string]string) (*GithubServer, func() map[string]int) {
+	var mutex sync.Mutex
+	lookups := map[string]int{}
+
+	apiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+		mutex.Lock()
+		lookups[token]++
+		mutex.Unlock()
+
+		if scopes[token] != "" {
+			w.Header().Set("X-OAuth-Scopes", scopes[token])
+		}
+		w.Write([]byte(`{"login": "git-xargs"}`))
+	}))
+	t.Cleanup(apiServer.Close)
+
+	server, err := NewGithubServer(ServerOptions{APIURL: apiServer.URL})
+	require.NoError(t, err)
+
+	return server, func() map[string]int {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return lookups
+	}
+}
+
+// TestTokenScopes ensures the scopes GitHub reports for each token are looked up once, and that they're unknown for a
+// token that doesn't report any
+func TestTokenScopes(t *testing.T) {
+	t.Parallel()
+
+	server, lookups := newScopedServer(t, map[string]string{"classic-token": "repo, read:org"})
+	tokenScopes := NewTokenScopes(server)
+
+	for i := 0; i < 2; i++ {
+		scopes, err := tokenScopes.Get("classic-token")
+		require.NoError(t, err)
+		assert.Equal(t, map[string]bool{"repo": true, "read:org": true}, scopes)
+
+		scopes, err = tokenScopes.Get("fine-grained-token")
+		require.NoError(t, err)
+		assert.Nil(t, scopes)
+	}
+
+	assert.Equal(t, map[string]int{"classic-token": 1, "fine-grained-token": 1}, lookups())
+}
@@ -0,0 +1,225 @@
+package auth
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/google/go-github/v43/github"
+	"github.com/gruntwork-io/git-xargs/types"
+	"github.com/gruntwork-io/go-commons/errors"
+)
+
+// The hostname git-xargs clones from and pushes to when no GitHub Enterprise server is configured
+const defaultGithubHostname = "github.com"
+
+// ServerOptions describes the GitHub server git-xargs talks to, and how to reach it
+type ServerOptions struct {
+	// Hostname is the GitHub Enterprise Server hostname, such as ghe.example.com, used to clone and push repos. If the
+	// API URL is not set, the API is assumed to be served from https://<hostname>/api/v3/
+	Hostname string
+	// APIURL is the base URL of the GitHub Enterprise Server REST API, for servers whose API is not at the default path
+	APIURL string
+	// UploadURL is the base URL of the GitHub Enterprise Server uploads API. Defaults to the uploads API that sits
+	// alongside the REST API
+	UploadURL string
+	// CABundlePath is the path to a PEM file of certificate authorities to trust, in addition to the system's, when
+	// connecting to the server
+	CABundlePath string
+	// ProxyURL is the HTTP(S) proxy to connect to the server through. If unset, the HTTPS_PROXY, HTTP_PROXY and
+	// NO_PROXY environment variables are honored
+	ProxyURL string
+}
+
+// GithubServer holds the validated connection settings for the GitHub server git-xargs talks to. A nil *GithubServer
+// is valid, and describes github.com reached with the default transport
+type GithubServer struct {
+	hostname  string
+	baseURL   *url.URL
+	uploadURL *url.URL
+	caBundle  []byte
+	proxyURL  *url.URL
+	transport http.RoundTripper
+}
+
+// NewGithubServer validates the supplied options and returns the GithubServer they describe. It returns an error if
+// any of the URLs are invalid or the CA bundle can't be read
+func NewGithubServer(opts ServerOptions) (*GithubServer, error) {
+	server := &GithubServer{hostname: opts.Hostname}
+
+	apiURL := opts.APIURL
+	if apiURL == "" && opts.Hostname != "" {
+		apiURL = fmt.Sprintf("https://%s/", opts.Hostname)
+	}
+
+	if apiURL != "" {
+		if err := validateServerURL(apiURL); err != nil {
+			return nil, err
+		}
+
+		uploadURL := opts.UploadURL
+		if uploadURL == "" {
+			// The uploads API lives alongside the REST API, at /api/uploads/ rather than /api/v3/
+			uploadURL = strings.TrimSuffix(strings.TrimSuffix(apiURL, "/"), "/api/v3")
+		} else if err := validateServerURL(uploadURL); err != nil {
+			return nil, err
+		}
+
+		// Let go-github work out the API paths from the URLs we've been given, exactly as it would for any other
+		// enterprise client, so that we only have to do it once
+		enterpriseClient, err := github.NewEnterpriseClient(apiURL, uploadURL, nil)
+		if err != nil {
+			return nil, errors.WithStackTrace(types.InvalidGithubEnterpriseURLErr{URL: apiURL, Reason: err.Error()})
+		}
+		server.baseURL = enterpriseClient.BaseURL
+		server.uploadURL = enterpriseClient.UploadURL
+
+		if server.hostname == "" {
+			// An API served from its own subdomain, such as api.ghe.example.com, serves git from the parent domain
+			server.hostname = strings.TrimPrefix(server.baseURL.Hostname(), "api.")
+		}
+	}
+
+	if opts.ProxyURL != "" {
+		proxyURL, err := url.Parse(opts.ProxyURL)
+		if err != nil || proxyURL.Scheme == "" || proxyURL.Host == "" {
+			return nil, errors.WithStackTrace(types.InvalidProxyURLErr{URL: opts.ProxyURL})
+		}
+		server.proxyURL = proxyURL
+	}
+
+	if opts.CABundlePath != "" {
+		caBundle, err := os.ReadFile(opts.CABundlePath)
+		if err != nil {
+			return nil, errors.WithStackTrace(err)
+		}
+		server.caBundle = caBundle
+	}
+
+	transport, err := server.newTransport()
+	if err != nil {
+		return nil, err
+	}
+	server.transport = transport
+
+	return server, nil
+}
+
+// validateServerURL returns an InvalidGithubEnterpriseURLErr unless the supplied URL is an absolute http or https URL
+func validateServerURL(rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return errors.WithStackTrace(types.InvalidGithubEnterpriseURLErr{URL: rawURL, Reason: err.Error()})
+	}
+	if parsed.Scheme != "https" && parsed.Scheme != "http" {
+		return errors.WithStackTrace(types.InvalidGithubEnterpriseURLErr{URL: rawURL, Reason: "the URL must start with https:// or http://"})
+	}
+	if parsed.Host == "" {
+		return errors.WithStackTrace(types.InvalidGithubEnterpriseURLErr{URL: rawURL, Reason: "the URL has no hostname"})
+	}
+	return nil
+}
+
+// newTransport returns the http.RoundTripper that API requests are sent through, which trusts the configured CA bundle
+// and connects via the configured proxy
+func (s *GithubServer) newTransport() (http.RoundTripper, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if s.proxyURL != nil {
+		transport.Proxy = http.ProxyURL(s.proxyURL)
+	}
+
+	if len(s.caBundle) > 0 {
+		rootCAs, err := x509.SystemCertPool()
+		if err != nil {
+			rootCAs = x509.NewCertPool()
+		}
+		if !rootCAs.AppendCertsFromPEM(s.caBundle) {
+			return nil, errors.WithStackTrace(types.CABundleInvalidErr{})
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: rootCAs, MinVersion: tls.VersionTLS12}
+	}
+
+	return transport, nil
+}
+
+// Hostname returns the hostname repos are cloned from and pushed to
+func (s *GithubServer) Hostname() string {
+	if s == nil || s.hostname == "" {
+		return defaultGithubHostname
+	}
+	return s.hostname
+}
+
+// IsEnterprise returns true if git-xargs is talking to a GitHub Enterprise Server rather than github.com
+func (s *GithubServer) IsEnterprise() bool {
+	return s != nil && s.baseURL != nil
+}
+
+// Transport returns the http.RoundTripper that requests to the server should be sent through
+func (s *GithubServer) Transport() http.RoundTripper {
+	if s == nil || s.transport == nil {
+		return http.DefaultTransport
+	}
+	return s.transport
+}
+
+// CABundle returns the contents of the configured CA bundle, for go-git to trust when cloning and pushing
+func (s *GithubServer) CABundle() []byte {
+	if s == nil {
+		return nil
+	}
+	return s.caBundle
+}
+
+// ProxyURL returns the configured proxy URL, or an empty string if requests should be proxied according to the
+// environment
+func (s *GithubServer) ProxyURL() string {
+	if s == nil || s.proxyURL == nil {
+		return ""
+	}
+	return s.proxyURL.String()
+}
+
+// RewriteCloneURL points the supplied HTTPS or SSH clone URL at the configured server's hostname. The API reports
+// clone URLs using whichever hostname it was reached by, which is not necessarily the one git is served from
+func (s *GithubServer) RewriteCloneURL(cloneURL string) string {
+	if !s.IsEnterprise() || cloneURL == "" {
+		return cloneURL
+	}
+
+	// SSH URLs take the scp-like form git@host:owner/repo.git
+	if user, rest, found := strings.Cut(cloneURL, "@"); found && !strings.Contains(user, "://") {
+		if _, path, found := strings.Cut(rest, ":"); found {
+			return fmt.Sprintf("%s@%s:%s", user, s.Hostname(), path)
+		}
+	}
+
+	parsed, err := url.Parse(cloneURL)
+	if err != nil || parsed.Host == "" {
+		return cloneURL
+	}
+	parsed.Host = s.Hostname()
+
+	return parsed.String()
+}
+
+// newAPIClient returns a go-github client that sends its requests via the supplied http client, pointed at either
+// github.com or the configured GitHub Enterprise server
+func (s *GithubServer) newAPIClient(httpClient *http.Client) *github.Client {
+	githubClient := github.NewClient(httpClient)
+
+	if s.IsEnterprise() {
+		// Copy the URLs so that no two clients can modify each other's
+		baseURL := *s.baseURL
+		uploadURL := *s.uploadURL
+		githubClient.BaseURL = &baseURL
+		githubClient.UploadURL = &uploadURL
+	}
+
+	return githubClient
+}
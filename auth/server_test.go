@@ -0,0 +1,122 @@
+package auth
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewGithubServerURLs(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name             string
+		opts             ServerOptions
+		expectedBaseURL  string
+		expectedUpload   string
+		expectedHostname string
+	}{
+		{"hostname only", ServerOptions{Hostname: "ghe.example.com"}, "https://ghe.example.com/api/v3/", "https://ghe.example.com/api/uploads/", "ghe.example.com"},
+		{"api url only", ServerOptions{APIURL: "https://ghe.example.com/api/v3"}, "https://ghe.example.com/api/v3/", "https://ghe.example.com/api/uploads/", "ghe.example.com"},
+		{"api subdomain", ServerOptions{APIURL: "https://api.ghe.example.com/"}, "https://api.ghe.example.com/", "https://api.ghe.example.com/", "ghe.example.com"},
+		{"separate upload url", ServerOptions{Hostname: "git.example.com", APIURL: "https://ghe.example.com/", UploadURL: "https://uploads.example.com/"}, "https://ghe.example.com/api/v3/", "https://uploads.example.com/api/uploads/", "git.example.com"},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			server, err := NewGithubServer(testCase.opts)
+			require.NoError(t, err)
+			assert.True(t, server.IsEnterprise())
+			assert.Equal(t, testCase.expectedHostname, server.Hostname())
+
+			client := server.newAPIClient(nil)
+			assert.Equal(t, testCase.expectedBaseURL, client.BaseURL.String())
+			assert.Equal(t, testCase.expectedUpload, client.UploadURL.String())
+		})
+	}
+}
+
+func TestNewGithubServerRejectsInvalidSettings(t *testing.T) {
+	t.Parallel()
+
+	invalidOptions := []ServerOptions{
+		{APIURL: "ghe.example.com"},
+		{APIURL: "ftp://ghe.example.com/"},
+		{APIURL: "https://ghe.example.com/", UploadURL: "://uploads"},
+		{Hostname: "ghe.example.com:port"},
+		{ProxyURL: "proxy.example.com"},
+		{CABundlePath: filepath.Join(t.TempDir(), "missing.pem")},
+	}
+
+	for _, opts := range invalidOptions {
+		_, err := NewGithubServer(opts)
+		assert.Error(t, err, "%+v", opts)
+	}
+}
+
+func TestGithubServerDefaultsToGithubDotCom(t *testing.T) {
+	t.Parallel()
+
+	var server *GithubServer
+	assert.False(t, server.IsEnterprise())
+	assert.Equal(t, "github.com", server.Hostname())
+	assert.Equal(t, http.DefaultTransport, server.Transport())
+	assert.Equal(t, "https://api.github.com/", server.newAPIClient(nil).BaseURL.String())
+	assert.Equal(t, "https://github.com/gruntwork-io/git-xargs.git", server.RewriteCloneURL("https://github.com/gruntwork-io/git-xargs.git"))
+}
+
+func TestGithubServerRewriteCloneURL(t *testing.T) {
+	t.Parallel()
+
+	server, err := NewGithubServer(ServerOptions{Hostname: "git.example.com", APIURL: "https://api.example.com/"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "https://git.example.com/gruntwork-io/git-xargs.git", server.RewriteCloneURL("https://api.example.com/gruntwork-io/git-xargs.git"))
+	assert.Equal(t, "git@git.example.com:gruntwork-io/git-xargs.git", server.RewriteCloneURL("git@api.example.com:gruntwork-io/git-xargs.git"))
+}
+
+// TestGithubServerTrustsCABundle ensures requests to a server whose certificate is signed by a CA in the bundle succeed,
+// and that they fail without the bundle
+func TestGithubServerTrustsCABundle(t *testing.T) {
+	t.Parallel()
+
+	tlsServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer tlsServer.Close()
+
+	caBundlePath := filepath.Join(t.TempDir(), "ca.pem")
+	caBundle := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: tlsServer.Certificate().Raw})
+	require.NoError(t, os.WriteFile(caBundlePath, caBundle, 0600))
+
+	server, err := NewGithubServer(ServerOptions{APIURL: tlsServer.URL, CABundlePath: caBundlePath})
+	require.NoError(t, err)
+	assert.Equal(t, caBundle, server.CABundle())
+
+	resp, err := (&http.Client{Transport: server.Transport()}).Get(tlsServer.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	untrusted, err := NewGithubServer(ServerOptions{APIURL: tlsServer.URL})
+	require.NoError(t, err)
+
+	_, err = (&http.Client{Transport: untrusted.Transport()}).Get(tlsServer.URL)
+	assert.Error(t, err)
+}
+
+func TestNewGithubServerRejectsCABundleWithoutCertificates(t *testing.T) {
+	t.Parallel()
+
+	caBundlePath := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(caBundlePath, []byte("not a certificate"), 0600))
+
+	_, err := NewGithubServer(ServerOptions{CABundlePath: caBundlePath})
+	assert.Error(t, err)
+}
@@ -0,0 +1,97 @@
+package bundle
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gruntwork-io/git-xargs/types"
+	"github.com/gruntwork-io/go-commons/errors"
+)
+
+const (
+	// ManifestFile is the file in a bundle's directory that describes it
+	ManifestFile = "bundle.json"
+	// ManifestVersion is the version of the manifest format this build of git-xargs writes and reads
+	ManifestVersion = 1
+)
+
+// Manifest describes a campaign bundle written by `git-xargs generate`: the changes the command made to each repo, as
+// patches in the bundle's directory, and everything `git-xargs publish` needs to push them and open pull requests later
+type Manifest struct {
+	Version                int      `json:"version"`
+	Command                []string `json:"command"`
+	BranchName             string   `json:"branch_name"`
+	CommitMessage          string   `json:"commit_message"`
+	PullRequestTitle       string   `json:"pull_request_title"`
+	PullRequestDescription string   `json:"pull_request_description"`
+	Draft                  bool     `json:"draft"`
+	SkipPullRequests       bool     `json:"skip_pull_requests"`
+	Reviewers              []string `json:"reviewers,omitempty"`
+	TeamReviewers          []string `json:"team_reviewers,omitempty"`
+	Repos                  []Repo   `json:"repos"`
+}
+
+// Repo is a single repo in a bundle, along with where its patch was made
+type Repo struct {
+	Owner      string `json:"owner"`
+	Name       string `json:"name"`
+	BaseBranch string `json:"base_branch"`
+	// BaseCommit is the commit the patch was made on top of. If the repo's branch has moved on since, the patch may no
+	// longer apply
+	BaseCommit string `json:"base_commit"`
+	// Patch is the path of the repo's patch, relative to the bundle's directory and separated by slashes
+	Patch string `json:"patch"`
+}
+
+// FullName returns the repo's name along with its owner, such as gruntwork-io/git-xargs
+func (r Repo) FullName() string {
+	return r.Owner + "/" + r.Name
+}
+
+// Load reads the manifest of the bundle in the given directory
+func Load(dir string) (*Manifest, error) {
+	contents, err := os.ReadFile(filepath.Join(dir, ManifestFile))
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+
+	var manifest Manifest
+	if err := json.Unmarshal(contents, &manifest); err != nil {
+		return nil, errors.WithStackTrace(types.InvalidBundleErr{Dir: dir, Underlying: err})
+	}
+	if manifest.Version != ManifestVersion {
+		return nil, errors.WithStackTrace(types.UnsupportedBundleVersionErr{Dir: dir, Version: manifest.Version})
+	}
+	return &manifest, nil
+}
+
+// Save writes the manifest to the bundle in the given directory
+func (m *Manifest) Save(dir string) error {
+	m.Version = ManifestVersion
+
+	contents, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return errors.WithStackTrace(err)
+	}
+	return errors.WithStackTrace(os.WriteFile(filepath.Join(dir, ManifestFile), append(contents, '\n'), 0644))
+}
+
+// Repo returns the given repo in the bundle, ignoring case as GitHub does
+func (m *Manifest) Repo(owner string, name string) (Repo, bool) {
+	for _, repo := range m.Repos {
+		if strings.EqualFold(repo.Owner, owner) && strings.EqualFold(repo.Name, name) {
+			return repo, true
+		}
+	}
+	return Repo{}, false
+}
+
+// PatchPath returns the path of the given repo's patch within the bundle in the given directory
+func PatchPath(dir string, repo Repo) string {
+	return filepath.Join(dir, filepath.FromSlash(repo.Patch))
+}
@@ -0,0 +1,49 @@
+package bundle
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSaveAndLoad(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	manifest := &Manifest{
+		Command:          []string{"./update.sh"},
+		BranchName:       "update",
+		CommitMessage:    "Update things",
+		PullRequestTitle: "Update things",
+		Repos: []Repo{
+			{Owner: "gruntwork-io", Name: "terragrunt", BaseBranch: "main", BaseCommit: "abc123", Patch: "gruntwork-io/terragrunt.patch"},
+		},
+	}
+	require.NoError(t, manifest.Save(dir))
+
+	loaded, err := Load(dir)
+	require.NoError(t, err)
+	assert.Equal(t, manifest, loaded)
+	assert.Equal(t, ManifestVersion, loaded.Version)
+
+	repo, ok := loaded.Repo("Gruntwork-IO", "Terragrunt")
+	require.True(t, ok)
+	assert.Equal(t, "gruntwork-io/terragrunt", repo.FullName())
+	assert.Equal(t, filepath.Join(dir, "gruntwork-io", "terragrunt.patch"), PatchPath(dir, repo))
+
+	_, ok = loaded.Repo("gruntwork-io", "git-xargs")
+	assert.False(t, ok)
+}
+
+func TestLoadRejectsUnsupportedVersion(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ManifestFile), []byte(`{"version": 2}`), 0644))
+
+	_, err := Load(dir)
+	assert.Error(t, err)
+}
@@ -0,0 +1,181 @@
+package cache
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/gruntwork-io/go-commons/logging"
+	"github.com/sirupsen/logrus"
+)
+
+// lastUsedMarker is the file inside each mirror whose modification time records when a run last used the mirror
+const lastUsedMarker = "git-xargs-last-used"
+
+// Cache is a directory of bare mirrors of GitHub repos that persists between runs, so that each run only has to fetch
+// what changed since the last one. Mirrors are laid out as <dir>/<hostname>/<owner>/<repo>.git, and each is guarded by
+// a lock file alongside it, so that concurrent runs sharing the cache take turns updating a mirror
+type Cache struct {
+	dir string
+}
+
+// Entry is a single mirror in the cache
+type Entry struct {
+	Hostname string
+	Owner    string
+	Name     string
+	Path     string
+	LastUsed time.Time
+}
+
+// PruneOptions configures which entries Prune removes
+type PruneOptions struct {
+	// MaxAge is how long an entry may go unused before it is removed. Zero disables removing entries by age
+	MaxAge time.Duration
+	// RepoGone, if set, is called for each entry that was used recently enough to keep, and returns true if the repo
+	// it mirrors no longer exists at that location, because it was deleted, renamed or transferred
+	RepoGone func(entry Entry) (bool, error)
+}
+
+// PrunedEntry is an entry that Prune removed, along with why
+type PrunedEntry struct {
+	Entry
+	Reason string
+}
+
+// NewCache returns the cache rooted at the given directory, which is created when the first mirror is added to it
+func NewCache(dir string) *Cache {
+	return &Cache{dir: dir}
+}
+
+// MirrorPath returns where the mirror of the given repo lives within the cache
+func (c *Cache) MirrorPath(hostname, owner, name string) string {
+	return filepath.Join(c.dir, hostname, owner, fmt.Sprintf("%s.git", name))
+}
+
+// Lock blocks until it holds the lock on the given mirror, which must be held while the mirror is created, fetched
+// into or cloned from
+func (c *Cache) Lock(mirrorPath string) (*Lock, error) {
+	if err := os.MkdirAll(filepath.Dir(mirrorPath), 0755); err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	lock, _, err := acquireLock(mirrorPath, true)
+	return lock, err
+}
+
+// MarkUsed records that the given mirror was used by the current run, which keeps Prune from removing it
+func MarkUsed(mirrorPath string) error {
+	now := time.Now()
+	markerPath := filepath.Join(mirrorPath, lastUsedMarker)
+
+	if err := os.WriteFile(markerPath, nil, 0644); err != nil {
+		return errors.WithStackTrace(err)
+	}
+	return errors.WithStackTrace(os.Chtimes(markerPath, now, now))
+}
+
+// Entries returns every mirror in the cache. It returns an empty slice if the cache directory doesn't exist yet
+func (c *Cache) Entries() ([]Entry, error) {
+	matches, err := filepath.Glob(filepath.Join(c.dir, "*", "*", "*.git"))
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+
+	entries := []Entry{}
+	for _, mirrorPath := range matches {
+		info, err := os.Stat(mirrorPath)
+		if err != nil || !info.IsDir() {
+			continue
+		}
+
+		lastUsed := info.ModTime()
+		if markerInfo, err := os.Stat(filepath.Join(mirrorPath, lastUsedMarker)); err == nil {
+			lastUsed = markerInfo.ModTime()
+		}
+
+		ownerDir := filepath.Dir(mirrorPath)
+		entries = append(entries, Entry{
+			Hostname: filepath.Base(filepath.Dir(ownerDir)),
+			Owner:    filepath.Base(ownerDir),
+			Name:     strings.TrimSuffix(filepath.Base(mirrorPath), ".git"),
+			Path:     mirrorPath,
+			LastUsed: lastUsed,
+		})
+	}
+
+	return entries, nil
+}
+
+// Prune removes the entries that haven't been used within the maximum age, and those whose repo is gone. Entries that a
+// concurrent run is using are left alone. An entry whose repo can't be looked up is kept, so that a failing API call
+// never costs a full clone on the next run
+func (c *Cache) Prune(opts PruneOptions) ([]PrunedEntry, error) {
+	logger := logging.GetLogger("git-xargs")
+
+	entries, err := c.Entries()
+	if err != nil {
+		return nil, err
+	}
+
+	pruned := []PrunedEntry{}
+	for _, entry := range entries {
+		reason := ""
+
+		if opts.MaxAge > 0 && time.Since(entry.LastUsed) > opts.MaxAge {
+			reason = fmt.Sprintf("unused since %s", entry.LastUsed.Format(time.RFC3339))
+		} else if opts.RepoGone != nil {
+			gone, err := opts.RepoGone(entry)
+			if err != nil {
+				logger.WithFields(logrus.Fields{
+					"Error": err,
+					"Entry": entry.Path,
+				}).Warn("Could not check whether the cached repo still exists, so keeping it")
+				continue
+			}
+			if gone {
+				reason = "repo was deleted, renamed or transferred"
+			}
+		}
+
+		if reason == "" {
+			continue
+		}
+
+		removed, err := c.remove(entry)
+		if err != nil {
+			return pruned, err
+		}
+		if !removed {
+			logger.WithFields(logrus.Fields{
+				"Entry": entry.Path,
+			}).Debug("Cache entry is in use by another run, so not pruning it")
+			continue
+		}
+
+		pruned = append(pruned, PrunedEntry{Entry: entry, Reason: reason})
+	}
+
+	return pruned, nil
+}
+
+// remove deletes the given entry and its lock file, returning false if another run holds its lock
+func (c *Cache) remove(entry Entry) (bool, error) {
+	lock, acquired, err := acquireLock(entry.Path, false)
+	if err != nil || !acquired {
+		return false, err
+	}
+
+	if err := os.RemoveAll(entry.Path); err != nil {
+		lock.Unlock()
+		return false, errors.WithStackTrace(err)
+	}
+
+	// Remove the lock file while still holding the lock, so that a run waiting on it notices it is stale and locks a
+	// fresh one instead. Platforms that won't remove an open file leave it behind, which is harmless
+	os.Remove(lockPath(entry.Path))
+
+	return true, lock.Unlock()
+}
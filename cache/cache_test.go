@@ -0,0 +1,146 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// addTestEntry creates an empty mirror in the cache that was last used the given duration ago
+func addTestEntry(t *testing.T, repoCache *Cache, owner, name string, unusedFor time.Duration) string {
+	mirrorPath := repoCache.MirrorPath("github.com", owner, name)
+	require.NoError(t, os.MkdirAll(mirrorPath, 0755))
+	require.NoError(t, MarkUsed(mirrorPath))
+
+	lastUsed := time.Now().Add(-unusedFor)
+	require.NoError(t, os.Chtimes(filepath.Join(mirrorPath, lastUsedMarker), lastUsed, lastUsed))
+
+	return mirrorPath
+}
+
+func TestEntries(t *testing.T) {
+	t.Parallel()
+
+	repoCache := NewCache(t.TempDir())
+	mirrorPath := addTestEntry(t, repoCache, "gruntwork-io", "terragrunt", time.Hour)
+
+	entries, err := repoCache.Entries()
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	assert.Equal(t, "github.com", entries[0].Hostname)
+	assert.Equal(t, "gruntwork-io", entries[0].Owner)
+	assert.Equal(t, "terragrunt", entries[0].Name)
+	assert.Equal(t, mirrorPath, entries[0].Path)
+	assert.WithinDuration(t, time.Now().Add(-time.Hour), entries[0].LastUsed, time.Minute)
+}
+
+func TestEntriesOfMissingCache(t *testing.T) {
+	t.Parallel()
+
+	entries, err := NewCache(filepath.Join(t.TempDir(), "missing")).Entries()
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestLockIsExclusive(t *testing.T) {
+	t.Parallel()
+
+	repoCache := NewCache(t.TempDir())
+	mirrorPath := repoCache.MirrorPath("github.com", "gruntwork-io", "terragrunt")
+
+	lock, err := repoCache.Lock(mirrorPath)
+	require.NoError(t, err)
+
+	_, acquired, err := acquireLock(mirrorPath, false)
+	require.NoError(t, err)
+	assert.False(t, acquired)
+
+	require.NoError(t, lock.Unlock())
+
+	lock, acquired, err = acquireLock(mirrorPath, false)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+	require.NoError(t, lock.Unlock())
+}
+
+func TestPrune(t *testing.T) {
+	t.Parallel()
+
+	repoCache := NewCache(t.TempDir())
+	recent := addTestEntry(t, repoCache, "gruntwork-io", "terragrunt", time.Hour)
+	stale := addTestEntry(t, repoCache, "gruntwork-io", "terratest", 48*time.Hour)
+	deleted := addTestEntry(t, repoCache, "gruntwork-io", "deleted", time.Hour)
+	unknown := addTestEntry(t, repoCache, "gruntwork-io", "unknown", time.Hour)
+
+	pruned, err := repoCache.Prune(PruneOptions{
+		MaxAge: 24 * time.Hour,
+		RepoGone: func(entry Entry) (bool, error) {
+			if entry.Name == "unknown" {
+				return false, assert.AnError
+			}
+			return entry.Name == "deleted", nil
+		},
+	})
+	require.NoError(t, err)
+
+	prunedPaths := []string{}
+	for _, entry := range pruned {
+		prunedPaths = append(prunedPaths, entry.Path)
+	}
+	assert.ElementsMatch(t, []string{stale, deleted}, prunedPaths)
+
+	assert.DirExists(t, recent)
+	assert.DirExists(t, unknown)
+	assert.NoDirExists(t, stale)
+	assert.NoDirExists(t, deleted)
+	assert.NoFileExists(t, lockPath(stale))
+}
+
+// TestPruneSkipsLockedEntries ensures an entry another run is using is kept, however long ago it was last used
+func TestPruneSkipsLockedEntries(t *testing.T) {
+	t.Parallel()
+
+	repoCache := NewCache(t.TempDir())
+	mirrorPath := addTestEntry(t, repoCache, "gruntwork-io", "terragrunt", 48*time.Hour)
+
+	lock, err := repoCache.Lock(mirrorPath)
+	require.NoError(t, err)
+	defer lock.Unlock()
+
+	pruned, err := repoCache.Prune(PruneOptions{MaxAge: 24 * time.Hour})
+	require.NoError(t, err)
+	assert.Empty(t, pruned)
+	assert.DirExists(t, mirrorPath)
+}
+
+// TestLockAfterPrune ensures a run that opened an entry's lock file before it was pruned locks a fresh lock file, rather
+// than the one prune removed
+func TestLockAfterPrune(t *testing.T) {
+	t.Parallel()
+
+	repoCache := NewCache(t.TempDir())
+	mirrorPath := addTestEntry(t, repoCache, "gruntwork-io", "terragrunt", 48*time.Hour)
+
+	staleFile, err := os.OpenFile(lockPath(mirrorPath), os.O_CREATE|os.O_RDWR, 0644)
+	require.NoError(t, err)
+	defer staleFile.Close()
+
+	pruned, err := repoCache.Prune(PruneOptions{MaxAge: 24 * time.Hour})
+	require.NoError(t, err)
+	require.Len(t, pruned, 1)
+
+	lock, err := repoCache.Lock(mirrorPath)
+	require.NoError(t, err)
+	defer lock.Unlock()
+
+	lockInfo, err := lock.file.Stat()
+	require.NoError(t, err)
+	staleInfo, err := staleFile.Stat()
+	require.NoError(t, err)
+	assert.False(t, os.SameFile(lockInfo, staleInfo))
+}
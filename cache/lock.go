@@ -0,0 +1,54 @@
+package cache
+
+import (
+	"os"
+
+	"github.com/gruntwork-io/go-commons/errors"
+)
+
+// Lock is an exclusive, advisory lock on a single mirror in the cache. The operating system releases it if the process
+// holding it dies, so a crashed run never leaves a mirror locked
+type Lock struct {
+	file *os.File
+}
+
+// lockPath returns the path of the lock file guarding the given mirror
+func lockPath(mirrorPath string) string {
+	return mirrorPath + ".lock"
+}
+
+// acquireLock locks the given mirror. If block is false and another process holds the lock, it returns immediately
+// with acquired set to false
+func acquireLock(mirrorPath string, block bool) (*Lock, bool, error) {
+	path := lockPath(mirrorPath)
+
+	for {
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+		if err != nil {
+			return nil, false, errors.WithStackTrace(err)
+		}
+
+		acquired, err := lockFile(file, block)
+		if err != nil || !acquired {
+			file.Close()
+			return nil, false, err
+		}
+
+		// Pruning removes the lock file of an entry while holding its lock, so if we were waiting on it, the lock we now
+		// hold is on a file no one else can open. Start again with the file at the path
+		openedInfo, statErr := file.Stat()
+		pathInfo, pathErr := os.Stat(path)
+		if statErr == nil && pathErr == nil && os.SameFile(openedInfo, pathInfo) {
+			return &Lock{file: file}, true, nil
+		}
+
+		unlockFile(file)
+		file.Close()
+	}
+}
+
+// Unlock releases the lock
+func (l *Lock) Unlock() error {
+	defer l.file.Close()
+	return unlockFile(l.file)
+}
@@ -0,0 +1,37 @@
+//go:build unix
+
+package cache
+
+import (
+	"os"
+	"syscall"
+
+	"github.com/gruntwork-io/go-commons/errors"
+)
+
+// lockFile takes an exclusive flock on the given file, returning false if block is false and the file is already locked
+func lockFile(file *os.File, block bool) (bool, error) {
+	how := syscall.LOCK_EX
+	if !block {
+		how |= syscall.LOCK_NB
+	}
+
+	for {
+		err := syscall.Flock(int(file.Fd()), how)
+		switch err {
+		case nil:
+			return true, nil
+		case syscall.EINTR:
+			continue
+		case syscall.EWOULDBLOCK:
+			return false, nil
+		default:
+			return false, errors.WithStackTrace(err)
+		}
+	}
+}
+
+// unlockFile releases the flock on the given file
+func unlockFile(file *os.File) error {
+	return errors.WithStackTrace(syscall.Flock(int(file.Fd()), syscall.LOCK_UN))
+}
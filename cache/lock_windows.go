@@ -0,0 +1,34 @@
+//go:build windows
+
+package cache
+
+import (
+	"os"
+
+	"github.com/gruntwork-io/go-commons/errors"
+	"golang.org/x/sys/windows"
+)
+
+// lockFile takes an exclusive lock on the first byte of the given file, returning false if block is false and the file
+// is already locked
+func lockFile(file *os.File, block bool) (bool, error) {
+	flags := uint32(windows.LOCKFILE_EXCLUSIVE_LOCK)
+	if !block {
+		flags |= windows.LOCKFILE_FAIL_IMMEDIATELY
+	}
+
+	err := windows.LockFileEx(windows.Handle(file.Fd()), flags, 0, 1, 0, new(windows.Overlapped))
+	switch err {
+	case nil:
+		return true, nil
+	case windows.ERROR_LOCK_VIOLATION:
+		return false, nil
+	default:
+		return false, errors.WithStackTrace(err)
+	}
+}
+
+// unlockFile releases the lock on the given file
+func unlockFile(file *os.File) error {
+	return errors.WithStackTrace(windows.UnlockFileEx(windows.Handle(file.Fd()), 0, 1, 0, new(windows.Overlapped)))
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/gruntwork-io/go-commons/logging"
+	"github.com/urfave/cli"
+)
+
+// RunApplyPatch is the urfave cli Action of the `apply-patch` command, which applies a unified diff or a series written
+// by git format-patch to each repo in place of running a command, then commits and pushes it, and opens a pull request,
+// just as a run would
+func RunApplyPatch(c *cli.Context) error {
+	if !c.Args().Present() {
+		return cli.ShowCommandHelp(c, "apply-patch")
+	}
+
+	logger := logging.GetLogger("git-xargs")
+
+	logger.Info("git-xargs applying a patch...")
+
+	config, err := parseGitXargsConfig(c)
+	if err != nil {
+		return err
+	}
+
+	// git applies the patch within each repo, so it needs the patch's absolute path
+	patchFile, err := filepath.Abs(c.Args().First())
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+	if _, err := os.Stat(patchFile); err != nil {
+		return errors.WithStackTrace(err)
+	}
+	config.PatchFile = patchFile
+	config.PatchFuzz = c.Int("fuzz")
+	config.PatchThreeWay = c.Bool("3way")
+	// The patch takes the place of a command, so any other arguments are one, which sanityCheckInputs rejects
+	config.Args = c.Args().Tail()
+
+	if err := sanityCheckInputs(config); err != nil {
+		return err
+	}
+
+	if err := configureAuthentication(config); err != nil {
+		return err
+	}
+
+	if err := configureCommitSigning(config); err != nil {
+		return err
+	}
+
+	// If DryRun is enabled, notify user that no file changes will be made
+	if config.DryRun {
+		logger.Info("Dry run setting enabled. No local branches will be pushed and no PRs will be opened in Github")
+	}
+
+	return handleRepoProcessing(config)
+}
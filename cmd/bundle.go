@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/gruntwork-io/git-xargs/bundle"
+	"github.com/gruntwork-io/git-xargs/config"
+	"github.com/gruntwork-io/git-xargs/types"
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/gruntwork-io/go-commons/logging"
+	"github.com/urfave/cli"
+)
+
+// RunGenerate is the urfave cli Action of the `generate` command, which runs the supplied command against each repo
+// just as a run would, but instead of pushing the changes it made, writes them to a bundle in --bundle-dir, which can
+// be reviewed, and then published by `git-xargs publish`
+func RunGenerate(c *cli.Context) error {
+	if !c.Args().Present() {
+		return cli.ShowCommandHelp(c, "generate")
+	}
+
+	logger := logging.GetLogger("git-xargs")
+
+	bundleDir := c.String("bundle-dir")
+	if bundleDir == "" {
+		return errors.WithStackTrace(types.NoBundleDirPassedErr{})
+	}
+
+	logger.Info("git-xargs generating a bundle...")
+
+	config, err := parseGitXargsConfig(c)
+	if err != nil {
+		return err
+	}
+
+	// Nothing is pushed while generating a bundle. Instead, each repo's changes are written to it as a patch
+	config.DryRun = true
+	config.DryRunOutputDir = bundleDir
+	config.GeneratingBundle = true
+
+	if err := sanityCheckInputs(config); err != nil {
+		return err
+	}
+
+	if err := configureAuthentication(config); err != nil {
+		return err
+	}
+
+	if err := configureCommitSigning(config); err != nil {
+		return err
+	}
+
+	if err := handleRepoProcessing(config); err != nil {
+		return err
+	}
+
+	manifest, err := newBundleManifest(config)
+	if err != nil {
+		return err
+	}
+	if err := manifest.Save(bundleDir); err != nil {
+		return err
+	}
+
+	logger.Infof("Wrote the changes to %d repos to the bundle in %s. Publish it with: git-xargs publish %s", len(manifest.Repos), bundleDir, bundleDir)
+
+	return nil
+}
+
+// newBundleManifest describes the patches written while generating a bundle, along with the branch, commit message and
+// pull request settings they'll be published with
+func newBundleManifest(config *config.GitXargsConfig) (*bundle.Manifest, error) {
+	manifest := &bundle.Manifest{
+		Command:                config.Args,
+		BranchName:             config.BranchName,
+		CommitMessage:          config.CommitMessage,
+		PullRequestTitle:       config.PullRequestTitle,
+		PullRequestDescription: config.PullRequestDescription,
+		Draft:                  config.Draft,
+		SkipPullRequests:       config.SkipPullRequests,
+		Reviewers:              config.Reviewers,
+		TeamReviewers:          config.TeamReviewers,
+		Repos:                  []bundle.Repo{},
+	}
+
+	for _, patch := range config.Stats.GetPatches() {
+		relativePath, err := filepath.Rel(config.DryRunOutputDir, patch.Path)
+		if err != nil {
+			return nil, errors.WithStackTrace(err)
+		}
+
+		owner, name, _ := strings.Cut(patch.Repo, "/")
+		manifest.Repos = append(manifest.Repos, bundle.Repo{
+			Owner:      owner,
+			Name:       name,
+			BaseBranch: patch.BaseBranch,
+			BaseCommit: patch.BaseCommit,
+			Patch:      filepath.ToSlash(relativePath),
+		})
+	}
+
+	return manifest, nil
+}
+
+// RunPublish is the urfave cli Action of the `publish` command, which applies each patch in a bundle written by
+// `git-xargs generate` to a fresh clone of its repo, then pushes it and opens a pull request, just as a run would have
+func RunPublish(c *cli.Context) error {
+	if !c.Args().Present() {
+		return cli.ShowCommandHelp(c, "publish")
+	}
+
+	logger := logging.GetLogger("git-xargs")
+
+	bundleDir := c.Args().First()
+	manifest, err := bundle.Load(bundleDir)
+	if err != nil {
+		return err
+	}
+	if len(manifest.Repos) == 0 {
+		logger.Infof("The bundle in %s doesn't change any repos, so there's nothing to publish", bundleDir)
+		return nil
+	}
+
+	logger.Info("git-xargs publishing a bundle...")
+
+	config, err := parseGitXargsConfig(c)
+	if err != nil {
+		return err
+	}
+	useBundle(config, manifest, bundleDir)
+
+	if err := sanityCheckInputs(config); err != nil {
+		return err
+	}
+
+	if err := configureAuthentication(config); err != nil {
+		return err
+	}
+
+	if err := configureCommitSigning(config); err != nil {
+		return err
+	}
+
+	// If DryRun is enabled, notify user that no file changes will be made
+	if config.DryRun {
+		logger.Info("Dry run setting enabled. No local branches will be pushed and no PRs will be opened in Github")
+	}
+
+	return handleRepoProcessing(config)
+}
+
+// useBundle makes the run publish the given bundle. The bundle's repos, branch, commit message and pull request
+// settings are the ones it was generated with, so they replace any that would otherwise come from flags or stdin
+func useBundle(config *config.GitXargsConfig, manifest *bundle.Manifest, bundleDir string) {
+	config.Bundle = manifest
+	config.BundleDir = bundleDir
+	config.Args = manifest.Command
+	config.BranchName = manifest.BranchName
+	config.CommitMessage = manifest.CommitMessage
+	config.PullRequestTitle = manifest.PullRequestTitle
+	config.PullRequestDescription = manifest.PullRequestDescription
+	config.Draft = manifest.Draft
+	config.SkipPullRequests = config.SkipPullRequests || manifest.SkipPullRequests
+	config.Reviewers = manifest.Reviewers
+	config.TeamReviewers = manifest.TeamReviewers
+
+	// Each repo's patch was made on top of its own base branch, which is selected along with it
+	config.GithubOrg = ""
+	config.ReposFile = ""
+	config.RepoFromStdIn = nil
+	config.BaseBranchName = ""
+	config.RepoSlice = nil
+	for _, repo := range manifest.Repos {
+		repoSelection := repo.FullName()
+		if repo.BaseBranch != "" {
+			repoSelection += "@" + repo.BaseBranch
+		}
+		config.RepoSlice = append(config.RepoSlice, repoSelection)
+	}
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/gruntwork-io/git-xargs/config"
+	"github.com/gruntwork-io/git-xargs/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestBundleManifestRoundTrip ensures a bundle is published with the repos, base branches, branch, commit message and
+// pull request settings it was generated with, rather than the ones passed to publish
+func TestBundleManifestRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	bundleDir := t.TempDir()
+	generateConfig := config.NewGitXargsTestConfig()
+	generateConfig.Args = []string{"./update.sh"}
+	generateConfig.BranchName = "update"
+	generateConfig.CommitMessage = "[skip ci] Update things"
+	generateConfig.PullRequestTitle = "Update things"
+	generateConfig.Draft = true
+	generateConfig.Reviewers = []string{"octocat"}
+	generateConfig.DryRunOutputDir = bundleDir
+	generateConfig.Stats.TrackPatch(types.PatchSummary{
+		Repo:       "gruntwork-io/terragrunt",
+		Path:       filepath.Join(bundleDir, "gruntwork-io", "terragrunt.patch"),
+		BaseBranch: "main",
+		BaseCommit: "abc123",
+	})
+	generateConfig.Stats.TrackPatch(types.PatchSummary{
+		Repo:       "gruntwork-io/fetch",
+		Path:       filepath.Join(bundleDir, "gruntwork-io", "fetch.patch"),
+		BaseBranch: "release",
+		BaseCommit: "def456",
+	})
+
+	manifest, err := newBundleManifest(generateConfig)
+	require.NoError(t, err)
+	require.NoError(t, manifest.Save(bundleDir))
+	assert.Equal(t, "gruntwork-io/fetch.patch", manifest.Repos[0].Patch)
+
+	publishConfig := config.NewGitXargsTestConfig()
+	publishConfig.GithubOrg = "gruntwork-io"
+	publishConfig.BranchName = "other-branch"
+	publishConfig.BaseBranchName = "develop"
+	useBundle(publishConfig, manifest, bundleDir)
+
+	assert.Equal(t, []string{"gruntwork-io/fetch@release", "gruntwork-io/terragrunt@main"}, publishConfig.RepoSlice)
+	assert.Empty(t, publishConfig.GithubOrg)
+	assert.Empty(t, publishConfig.BaseBranchName)
+	assert.Equal(t, "update", publishConfig.BranchName)
+	assert.Equal(t, "[skip ci] Update things", publishConfig.CommitMessage)
+	assert.Equal(t, "Update things", publishConfig.PullRequestTitle)
+	assert.True(t, publishConfig.Draft)
+	assert.Equal(t, []string{"octocat"}, publishConfig.Reviewers)
+	assert.Equal(t, []string{"./update.sh"}, publishConfig.Args)
+	assert.Equal(t, bundleDir, publishConfig.BundleDir)
+}
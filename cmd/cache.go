@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/gruntwork-io/git-xargs/auth"
+	"github.com/gruntwork-io/git-xargs/cache"
+	"github.com/gruntwork-io/git-xargs/types"
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/gruntwork-io/go-commons/logging"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+// RunCachePrune is the urfave cli Action of the `cache prune` command, which removes the mirrors in the --cache-dir
+// that no run has used within --max-age. If a GitHub token is available, it also removes the mirrors of repos that
+// have been deleted, renamed or transferred, which no future run would ever use
+func RunCachePrune(c *cli.Context) error {
+	logger := logging.GetLogger("git-xargs")
+
+	cacheDir := c.String("cache-dir")
+	if cacheDir == "" {
+		return errors.WithStackTrace(types.NoCacheDirPassedErr{})
+	}
+
+	server, err := auth.NewGithubServer(auth.ServerOptions{
+		Hostname:     c.String("github-hostname"),
+		APIURL:       c.String("github-api-url"),
+		CABundlePath: c.String("ca-bundle"),
+		ProxyURL:     c.String("proxy"),
+	})
+	if err != nil {
+		return err
+	}
+
+	tokens, err := auth.ResolveTokens(auth.TokenOptions{
+		TokenFile:    c.String("token-file"),
+		TokenCommand: c.String("token-command"),
+	})
+	if err != nil {
+		return err
+	}
+
+	opts := cache.PruneOptions{MaxAge: c.Duration("max-age")}
+	if len(tokens) > 0 {
+		opts.RepoGone = cachedRepoGone(auth.ConfigureGithubClient(tokens[0], server), server.Hostname())
+	} else {
+		logger.Warn("No GitHub token was supplied, so only cached repos that haven't been used recently will be pruned")
+	}
+
+	pruned, err := cache.NewCache(cacheDir).Prune(opts)
+	for _, entry := range pruned {
+		logger.WithFields(logrus.Fields{
+			"Repo":   strings.Join([]string{entry.Hostname, entry.Owner, entry.Name}, "/"),
+			"Reason": entry.Reason,
+		}).Info("Pruned cached repo")
+	}
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("Pruned %d cached repos from %s", len(pruned), cacheDir)
+
+	return nil
+}
+
+// cachedRepoGone returns a function that reports whether the repo a cache entry mirrors is gone from the given GitHub
+// server. GitHub redirects requests for renamed and transferred repos to their new location, so a repo is gone if it
+// either can't be found or is found under a different name. Entries cached from other servers are never reported gone
+func cachedRepoGone(client auth.GithubClient, hostname string) func(entry cache.Entry) (bool, error) {
+	return func(entry cache.Entry) (bool, error) {
+		if entry.Hostname != hostname {
+			return false, nil
+		}
+
+		repo, resp, err := client.Repositories.Get(context.Background(), entry.Owner, entry.Name)
+		if resp != nil && resp.Response != nil && resp.StatusCode == http.StatusNotFound {
+			return true, nil
+		}
+		if err != nil {
+			return false, errors.WithStackTrace(err)
+		}
+
+		movedOwner := !strings.EqualFold(repo.GetOwner().GetLogin(), entry.Owner)
+		movedName := !strings.EqualFold(repo.GetName(), entry.Name)
+		return movedOwner || movedName, nil
+	}
+}
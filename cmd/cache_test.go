@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gruntwork-io/git-xargs/auth"
+	"github.com/gruntwork-io/git-xargs/cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCachedRepoGone(t *testing.T) {
+	t.Parallel()
+
+	// Serve terragrunt where it always was, moved-repo as renamed and everything else as missing
+	githubAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch strings.TrimPrefix(r.URL.Path, "/api/v3") {
+		case "/repos/gruntwork-io/terragrunt":
+			fmt.Fprint(w, `{"name": "terragrunt", "owner": {"login": "gruntwork-io"}}`)
+		case "/repos/gruntwork-io/moved-repo":
+			fmt.Fprint(w, `{"name": "new-name", "owner": {"login": "gruntwork-io"}}`)
+		case "/repos/gruntwork-io/broken":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer githubAPI.Close()
+
+	server, err := auth.NewGithubServer(auth.ServerOptions{APIURL: githubAPI.URL})
+	require.NoError(t, err)
+	repoGone := cachedRepoGone(auth.ConfigureGithubClient("token", server), server.Hostname())
+
+	testCases := []struct {
+		entry       cache.Entry
+		expected    bool
+		expectedErr bool
+	}{
+		{cache.Entry{Hostname: server.Hostname(), Owner: "gruntwork-io", Name: "terragrunt"}, false, false},
+		{cache.Entry{Hostname: server.Hostname(), Owner: "gruntwork-io", Name: "moved-repo"}, true, false},
+		{cache.Entry{Hostname: server.Hostname(), Owner: "gruntwork-io", Name: "deleted"}, true, false},
+		{cache.Entry{Hostname: server.Hostname(), Owner: "gruntwork-io", Name: "broken"}, false, true},
+		{cache.Entry{Hostname: "ghe.example.com", Owner: "gruntwork-io", Name: "deleted"}, false, false},
+	}
+
+	for _, testCase := range testCases {
+		gone, err := repoGone(testCase.entry)
+		if testCase.expectedErr {
+			assert.Error(t, err, "%+v", testCase.entry)
+		} else {
+			assert.NoError(t, err, "%+v", testCase.entry)
+		}
+		assert.Equal(t, testCase.expected, gone, "%+v", testCase.entry)
+	}
+}
@@ -12,11 +12,13 @@ import (
 	"github.com/gruntwork-io/git-xargs/common"
 	"github.com/gruntwork-io/git-xargs/config"
 	gitxargs_io "github.com/gruntwork-io/git-xargs/io"
+	"github.com/gruntwork-io/git-xargs/local"
 	"github.com/gruntwork-io/git-xargs/repository"
 	"github.com/gruntwork-io/git-xargs/types"
 	"github.com/gruntwork-io/go-commons/errors"
 	"github.com/gruntwork-io/go-commons/logging"
 	"github.com/urfave/cli"
+	"golang.org/x/term"
 )
 
 // parseGitXargsConfig accepts a urfave cli context and binds its values
@@ -30,6 +32,22 @@ func parseGitXargsConfig(c *cli.Context) (*config.GitXargsConfig, error) {
 	config.BranchName = c.String("branch-name")
 	config.BaseBranchName = c.String("base-branch-name")
 	config.CommitMessage = c.String("commit-message")
+	config.CommitAuthorName = c.String("commit-author-name")
+	config.CommitAuthorEmail = c.String("commit-author-email")
+	config.Signoff = c.Bool("signoff")
+	config.CommitTrailers = c.StringSlice("commit-trailer")
+	config.StageInclude = c.StringSlice("stage-include")
+	config.StageExclude = c.StringSlice("stage-exclude")
+	config.MaxChangedFiles = c.Int("max-changed-files")
+	config.MaxChangedLines = c.Int("max-changed-lines")
+	config.MaxFileSize = c.Int64("max-file-size")
+	config.SecretPatterns = c.StringSlice("secret-pattern")
+	config.SkipSecretScan = c.Bool("skip-secret-scan")
+	config.ValidateCmd = c.String("validate-cmd")
+	config.ValidateTimeout = c.Duration("validate-timeout")
+	config.Interactive = c.Bool("interactive")
+	config.DryRunOutputDir = c.String("dry-run-output-dir")
+	config.CherryPick = c.String("cherry-pick")
 	config.PullRequestTitle = c.String("pull-request-title")
 	config.PullRequestDescription = c.String("pull-request-description")
 	config.Reviewers = c.StringSlice("reviewers")
@@ -41,6 +59,42 @@ func parseGitXargsConfig(c *cli.Context) (*config.GitXargsConfig, error) {
 	config.SecondsToSleepBetweenPRs = c.Int("seconds-between-prs")
 	config.PullRequestRetries = c.Int("max-pr-retries")
 	config.SecondsToSleepWhenRateLimited = c.Int("seconds-to-wait-when-rate-limited")
+	config.GithubAppID = c.Int64("github-app-id")
+	config.GithubAppPrivateKeyPath = c.String("github-app-private-key-path")
+	config.GithubAppInstallationID = c.Int64("github-app-installation-id")
+	config.GitTransport = c.String("git-transport")
+	config.SSHKeyPath = c.String("ssh-key-path")
+	config.SSHKnownHostsPath = c.String("ssh-known-hosts-path")
+	config.TokenFile = c.String("token-file")
+	config.TokenCommand = c.String("token-command")
+	config.UseGitCredentialHelper = c.Bool("git-credential-helper")
+	config.TokenRotationStrategy = c.String("token-rotation-strategy")
+	config.PreflightCheck = c.String("preflight-check")
+	config.CloneDepth = c.Int("clone-depth")
+	config.SingleBranch = c.Bool("single-branch")
+	config.SparsePaths = c.StringSlice("sparse-paths")
+	config.RecurseSubmodules = c.Bool("recurse-submodules")
+	config.BranchStrategy = c.String("branch-strategy")
+	config.SignCommits = c.Bool("sign-commits")
+	config.SigningKeyPath = c.String("signing-key")
+	config.SigningKeyPassphraseFile = c.String("signing-key-passphrase-file")
+	config.CacheDir = c.String("cache-dir")
+	config.GitBackend = c.String("git-backend")
+	config.GithubServerOptions = auth.ServerOptions{
+		Hostname:     c.String("github-hostname"),
+		APIURL:       c.String("github-api-url"),
+		UploadURL:    c.String("github-upload-url"),
+		CABundlePath: c.String("ca-bundle"),
+		ProxyURL:     c.String("proxy"),
+	}
+	// Unlike go-git, the git CLI reads SSH keys and CA bundles from disk itself, so it is given their paths
+	if config.GitBackend == common.GitBackendCLI {
+		config.GitClient = local.NewGitClient(local.GitCLIProvider{
+			SSHKeyPath:        config.SSHKeyPath,
+			SSHKnownHostsPath: config.SSHKnownHostsPath,
+			CABundlePath:      config.GithubServerOptions.CABundlePath,
+		})
+	}
 	maxConcurrentClones := c.Int("max-concurrent-clones")
 	if maxConcurrentClones > 0 {
 		config.CloneJobsLimiter = make(chan struct{}, maxConcurrentClones)
@@ -63,6 +117,18 @@ func parseGitXargsConfig(c *cli.Context) (*config.GitXargsConfig, error) {
 	config.Ticker = time.NewTicker(time.Duration(tickerVal) * time.Second)
 	config.Args = c.Args()
 
+	// GitHub Apps mint their own tokens, so there is no need to run a potentially slow --token-command for them
+	if !config.UsesGithubApp() {
+		tokens, err := auth.ResolveTokens(auth.TokenOptions{
+			TokenFile:    config.TokenFile,
+			TokenCommand: config.TokenCommand,
+		})
+		if err != nil {
+			return nil, err
+		}
+		config.GithubTokens = tokens
+	}
+
 	shouldReadStdIn, err := dataBeingPipedToStdIn()
 	if err != nil {
 		return nil, err
@@ -121,43 +187,189 @@ func handleRepoProcessing(config *config.GitXargsConfig) error {
 	// Track whether pull requests were skipped
 	config.Stats.SetSkipPullRequests(config.SkipPullRequests)
 
-	// Update raw command supplied
+	// Update raw command supplied, or the commit cherry-picked or patch applied in its place
 	config.Stats.SetCommand(config.Args)
+	config.Stats.SetCherryPick(config.CherryPick)
+	config.Stats.SetPatchFile(config.PatchFile)
+
+	// Record who commits are authored by and the trailers added to them
+	config.Stats.SetCommitIdentity(commitAuthor(config), commitTrailers(config))
 
 	if err := repository.OperateOnRepos(config); err != nil {
 		return err
 	}
 
+	// Summarize the patches written to --dry-run-output-dir, if any, so that the run can be reviewed before it's repeated
+	// without --dry-run
+	if err := repository.WriteDryRunReport(config); err != nil {
+		return err
+	}
+
+	// Record how much each token was used, so that operators can tell whether the pool is spreading the load
+	if config.TokenPool != nil {
+		config.Stats.SetTokenUsage(config.TokenPool.Usage())
+	}
+
 	// Once all processing is complete, print out the summary of what was done
 	config.Stats.PrintReport()
 
 	return nil
 }
 
+// commitAuthor describes who commits are authored by, or returns an empty string if git-xargs uses the git config's
+// identity
+func commitAuthor(config *config.GitXargsConfig) string {
+	if config.CommitAuthorName == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s <%s>", config.CommitAuthorName, config.CommitAuthorEmail)
+}
+
+// commitTrailers returns the trailers added to each commit message. Unless the commit author was chosen, the
+// Signed-off-by trailer's identity comes from the git config of each repo, which may differ between repos, so only its
+// source is reported
+func commitTrailers(config *config.GitXargsConfig) []string {
+	trailers := append([]string{}, config.CommitTrailers...)
+	if config.Signoff {
+		if author := commitAuthor(config); author != "" {
+			trailers = append(trailers, "Signed-off-by: "+author)
+		} else {
+			trailers = append(trailers, "Signed-off-by (from git config)")
+		}
+	}
+	return trailers
+}
+
+// configureAuthentication configures the GitHub API client and the credentials used for git operations. By default,
+// both use the token resolved from --token-file, --token-command or the environment. If several tokens were supplied,
+// API requests are spread across them with a token pool, so that large runs don't exhaust a single token's rate limit.
+// If the user passed --github-app-id, both instead authenticate as that GitHub App, and commits are authored by the
+// app's bot user so that they are attributed to the same identity as the pull requests. If the user passed
+// --git-credential-helper, git operations are authenticated by their configured git credential helper instead. Either
+// way, requests go to the GitHub server described by the --github-* flags, via the configured proxy and CA bundle
+func configureAuthentication(config *config.GitXargsConfig) error {
+	server, err := auth.NewGithubServer(config.GithubServerOptions)
+	if err != nil {
+		return err
+	}
+	config.GithubServer = server
+
+	if config.UsesGithubApp() {
+		privateKey, err := os.ReadFile(config.GithubAppPrivateKeyPath)
+		if err != nil {
+			return errors.WithStackTrace(err)
+		}
+
+		app, err := auth.NewGithubApp(config.GithubAppID, privateKey, config.GithubAppInstallationID, server)
+		if err != nil {
+			return err
+		}
+
+		config.GithubClient = auth.ConfigureGithubAppClient(app)
+		config.GitCredentials = app
+
+		// An identity passed via --commit-author-name and --commit-author-email takes precedence
+		if config.CommitAuthorName == "" {
+			name, email, err := app.BotIdentity()
+			if err != nil {
+				return err
+			}
+			config.CommitAuthorName = name
+			config.CommitAuthorEmail = email
+		}
+	} else if len(config.GithubTokens) > 1 {
+		config.TokenPool = auth.NewTokenPool(config.GithubTokens, config.TokenRotationStrategy, server)
+		config.GithubClient = auth.ConfigureGithubClientWithTokenPool(config.TokenPool)
+		config.GitCredentials = config.TokenPool
+	} else {
+		config.GithubClient = auth.ConfigureGithubClient(config.GithubTokens[0], server)
+		config.GitCredentials = auth.TokenCredentials{Token: config.GithubTokens[0]}
+	}
+
+	if config.UseGitCredentialHelper {
+		config.GitCredentials = auth.GitCredentialHelper{Server: server}
+	}
+
+	// Only tokens pushed over HTTPS have scopes worth checking before pushing changes to workflows
+	if !config.UsesGithubApp() && !config.UseGitCredentialHelper && config.GitTransport != common.GitTransportSSH {
+		config.TokenScopes = auth.NewTokenScopes(server)
+	}
+
+	return nil
+}
+
+// configureCommitSigning loads the key passed via --signing-key, if the user passed --sign-commits, decrypting it with
+// the passphrase read from --signing-key-passphrase-file or the environment
+func configureCommitSigning(config *config.GitXargsConfig) error {
+	if !config.SignCommits {
+		return nil
+	}
+
+	key, err := os.ReadFile(config.SigningKeyPath)
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+
+	passphrase := os.Getenv(common.SigningKeyPassphraseEnvVar)
+	if config.SigningKeyPassphraseFile != "" {
+		contents, err := os.ReadFile(config.SigningKeyPassphraseFile)
+		if err != nil {
+			return errors.WithStackTrace(err)
+		}
+		passphrase = strings.TrimRight(string(contents), "\r\n")
+	}
+
+	signer, err := local.NewCommitSigner(key, passphrase)
+	if err != nil {
+		return err
+	}
+	config.CommitSigner = signer
+	return nil
+}
+
 // sanityCheckInputs performs validation on the user-supplied inputs to ensure we have everything we need:
-// 1. An exported GITHUB_OAUTH_TOKEN
-// 2. Arguments passed to the binary itself which should be executed against the targeted repos
+// 1. A GitHub token from --token-file, --token-command or the environment, unless authenticating as a GitHub App
+// 2. Exactly one of arguments passed to the binary itself which should be executed against the targeted repos, a commit
+// to cherry-pick, or a patch to apply in their place
 // 3. At least one of the three valid methods for selecting repositories
 func sanityCheckInputs(config *config.GitXargsConfig) error {
-	if err := auth.EnsureGithubOauthTokenSet(); err != nil {
-		return err
+	if !config.UsesGithubApp() {
+		if err := auth.EnsureGithubOauthTokenSet(config.GithubTokens); err != nil {
+			return err
+		}
 	}
 
-	if len(config.Args) < 1 {
+	// The changes to make come from exactly one of a command, the commit passed via --cherry-pick, or the patch passed
+	// to apply-patch
+	changeSources := 0
+	for _, passed := range []bool{len(config.Args) > 0, config.CherryPick != "", config.PatchFile != ""} {
+		if passed {
+			changeSources++
+		}
+	}
+	if changeSources == 0 {
 		return errors.WithStackTrace(types.NoArgumentsPassedErr{})
 	}
+	if changeSources > 1 {
+		return errors.WithStackTrace(types.MultipleChangeSourcesErr{})
+	}
 
 	if err := gitxargs_io.EnsureValidOptionsPassed(config); err != nil {
 		return errors.WithStackTrace(err)
 	}
 
+	// --interactive prompts for each repo, which requires someone at a terminal to answer
+	if config.Interactive && !term.IsTerminal(int(os.Stdin.Fd())) {
+		return errors.WithStackTrace(types.InteractiveRequiresTerminalErr{})
+	}
+
 	return nil
 }
 
 // RunGitXargs is the urfave cli app's Action that is called when the user executes the binary
 func RunGitXargs(c *cli.Context) error {
 	// If someone calls us with no args at all, show the help text and exit
-	if !c.Args().Present() {
+	if !c.Args().Present() && c.String("cherry-pick") == "" {
 		return cli.ShowAppHelp(c)
 	}
 
@@ -174,6 +386,14 @@ func RunGitXargs(c *cli.Context) error {
 		return err
 	}
 
+	if err := configureAuthentication(config); err != nil {
+		return err
+	}
+
+	if err := configureCommitSigning(config); err != nil {
+		return err
+	}
+
 	// If DryRun is enabled, notify user that no file changes will be made
 	if config.DryRun {
 		logger.Info("Dry run setting enabled. No local branches will be pushed and no PRs will be opened in Github")
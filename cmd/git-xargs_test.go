@@ -6,6 +6,8 @@ import (
 
 	"github.com/gruntwork-io/git-xargs/config"
 	"github.com/gruntwork-io/git-xargs/mocks"
+	"github.com/gruntwork-io/git-xargs/types"
+	"github.com/gruntwork-io/go-commons/errors"
 	"github.com/stretchr/testify/require"
 
 	"github.com/stretchr/testify/assert"
@@ -29,6 +31,39 @@ func TestHandleRepoProcessing(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+// TestSanityCheckInputsChangeSources ensures a run is given exactly one of a command, a commit to cherry-pick, or a
+// patch to apply
+func TestSanityCheckInputsChangeSources(t *testing.T) {
+	t.Parallel()
+
+	testConfig := config.NewGitXargsTestConfig()
+	testConfig.GithubTokens = []string{"token"}
+	assert.IsType(t, types.NoArgumentsPassedErr{}, errors.Unwrap(sanityCheckInputs(testConfig)))
+
+	testConfig.Args = []string{"touch", "test.txt"}
+	testConfig.PatchFile = "/tmp/changes.patch"
+	assert.IsType(t, types.MultipleChangeSourcesErr{}, errors.Unwrap(sanityCheckInputs(testConfig)))
+
+	testConfig.PatchFile = ""
+	testConfig.CherryPick = "gruntwork-io/upstream@" + strings.Repeat("0", 40)
+	assert.IsType(t, types.MultipleChangeSourcesErr{}, errors.Unwrap(sanityCheckInputs(testConfig)))
+}
+
+// TestCommitTrailers ensures the Signed-off-by trailer names the commit author if one was chosen, and otherwise only
+// reports that the identity comes from the git config
+func TestCommitTrailers(t *testing.T) {
+	t.Parallel()
+
+	testConfig := config.NewGitXargsTestConfig()
+	testConfig.Signoff = true
+	testConfig.CommitTrailers = []string{"Co-authored-by: Jane Doe <jane@example.com>"}
+	assert.Equal(t, []string{"Co-authored-by: Jane Doe <jane@example.com>", "Signed-off-by (from git config)"}, commitTrailers(testConfig))
+
+	testConfig.CommitAuthorName = "git-xargs"
+	testConfig.CommitAuthorEmail = "git-xargs@example.com"
+	assert.Equal(t, []string{"Co-authored-by: Jane Doe <jane@example.com>", "Signed-off-by: git-xargs <git-xargs@example.com>"}, commitTrailers(testConfig))
+}
+
 func TestParseSliceFromReader(t *testing.T) {
 	t.Parallel()
 
@@ -1,6 +1,10 @@
 package common
 
-import "github.com/urfave/cli"
+import (
+	"time"
+
+	"github.com/urfave/cli"
+)
 
 const (
 	GithubOrgFlagName                    = "github-org"
@@ -11,6 +15,22 @@ const (
 	RepoFlagName                         = "repo"
 	ReposFileFlagName                    = "repos"
 	CommitMessageFlagName                = "commit-message"
+	CommitAuthorNameFlagName             = "commit-author-name"
+	CommitAuthorEmailFlagName            = "commit-author-email"
+	SignoffFlagName                      = "signoff"
+	CommitTrailerFlagName                = "commit-trailer"
+	StageIncludeFlagName                 = "stage-include"
+	StageExcludeFlagName                 = "stage-exclude"
+	MaxChangedFilesFlagName              = "max-changed-files"
+	MaxChangedLinesFlagName              = "max-changed-lines"
+	MaxFileSizeFlagName                  = "max-file-size"
+	SecretPatternFlagName                = "secret-pattern"
+	SkipSecretScanFlagName               = "skip-secret-scan"
+	ValidateCmdFlagName                  = "validate-cmd"
+	ValidateTimeoutFlagName              = "validate-timeout"
+	InteractiveFlagName                  = "interactive"
+	DryRunOutputDirFlagName              = "dry-run-output-dir"
+	CherryPickFlagName                   = "cherry-pick"
 	BranchFlagName                       = "branch-name"
 	BaseBranchFlagName                   = "base-branch-name"
 	PullRequestTitleFlagName             = "pull-request-title"
@@ -26,6 +46,53 @@ const (
 	MaxConcurrentClonesFlagName          = "max-concurrent-clones"
 	NoSkipCIFlagName                     = "no-skip-ci"
 	KeepClonedRepositoriesFlagName       = "keep-cloned-repositories"
+	GithubAppIDFlagName                  = "github-app-id"
+	GithubAppPrivateKeyPathFlagName      = "github-app-private-key-path"
+	GithubAppInstallationIDFlagName      = "github-app-installation-id"
+	GitTransportFlagName                 = "git-transport"
+	SSHKeyPathFlagName                   = "ssh-key-path"
+	SSHKnownHostsPathFlagName            = "ssh-known-hosts-path"
+	GitTransportHTTPS                    = "https"
+	GitTransportSSH                      = "ssh"
+	DefaultGitTransport                  = GitTransportHTTPS
+	SSHKeyPassphraseEnvVar               = "GIT_XARGS_SSH_KEY_PASSPHRASE"
+	TokenFileFlagName                    = "token-file"
+	TokenCommandFlagName                 = "token-command"
+	GitCredentialHelperFlagName          = "git-credential-helper"
+	TokenRotationStrategyFlagName        = "token-rotation-strategy"
+	DefaultTokenRotationStrategy         = "most-remaining"
+	PreflightCheckFlagName               = "preflight-check"
+	PreflightCheckSkip                   = "skip"
+	PreflightCheckAbort                  = "abort"
+	GithubHostnameFlagName               = "github-hostname"
+	GithubAPIURLFlagName                 = "github-api-url"
+	GithubUploadURLFlagName              = "github-upload-url"
+	CABundleFlagName                     = "ca-bundle"
+	ProxyFlagName                        = "proxy"
+	CloneDepthFlagName                   = "clone-depth"
+	SingleBranchFlagName                 = "single-branch"
+	SparsePathsFlagName                  = "sparse-paths"
+	RecurseSubmodulesFlagName            = "recurse-submodules"
+	BranchStrategyFlagName               = "branch-strategy"
+	BranchStrategyAppend                 = "append"
+	BranchStrategyRebase                 = "rebase"
+	BranchStrategyRecreate               = "recreate"
+	DefaultBranchStrategy                = BranchStrategyAppend
+	SignCommitsFlagName                  = "sign-commits"
+	SigningKeyFlagName                   = "signing-key"
+	SigningKeyPassphraseFileFlagName     = "signing-key-passphrase-file"
+	SigningKeyPassphraseEnvVar           = "GIT_XARGS_SIGNING_KEY_PASSPHRASE"
+	CacheDirFlagName                     = "cache-dir"
+	GitBackendFlagName                   = "git-backend"
+	GitBackendGoGit                      = "go-git"
+	GitBackendCLI                        = "cli"
+	DefaultGitBackend                    = GitBackendGoGit
+	CacheMaxAgeFlagName                  = "max-age"
+	BundleDirFlagName                    = "bundle-dir"
+	FuzzFlagName                         = "fuzz"
+	ThreeWayFlagName                     = "3way"
+	DefaultCacheMaxAge                   = 30 * 24 * time.Hour
+	DefaultValidateTimeout               = 10 * time.Minute
 	DefaultMaxConcurrentClones           = 4
 	DefaultSecondsBetweenPRs             = 1
 	DefaultMaxPullRequestRetries         = 3
@@ -55,11 +122,11 @@ var (
 	}
 	GenericRepoFlag = cli.StringSliceFlag{
 		Name:  RepoFlagName,
-		Usage: "A single repo name to run the command on in the format of <github-organization/repo-name>. Can be invoked multiple times with different repo names",
+		Usage: "A single repo name to run the command on in the format of <github-organization/repo-name>, optionally followed by @<base-branch>. Can be invoked multiple times with different repo names",
 	}
 	GenericRepoFileFlag = cli.StringFlag{
 		Name:  ReposFileFlagName,
-		Usage: "The path to a file containing repos, one per line in the format of <github-organization/repo-name>",
+		Usage: "The path to a file containing repos, one per line in the format of <github-organization/repo-name>, optionally followed by @<base-branch>",
 	}
 	GenericBranchFlag = cli.StringFlag{
 		Name:  BranchFlagName,
@@ -67,13 +134,78 @@ var (
 	}
 	GenericBaseBranchFlag = cli.StringFlag{
 		Name:  BaseBranchFlagName,
-		Usage: "The base branch that changes should be merged into",
+		Usage: "The base branch that changes are made on top of and should be merged into. Defaults to each repo's default branch",
 	}
 	GenericCommitMessageFlag = cli.StringFlag{
 		Name:  CommitMessageFlagName,
 		Usage: "The commit message to use when creating commits from changes introduced by your command or script",
 		Value: DefaultCommitMessage,
 	}
+	GenericCommitAuthorNameFlag = cli.StringFlag{
+		Name:  CommitAuthorNameFlagName,
+		Usage: "The name to author and commit commits as. Requires --commit-author-email. Defaults to the name in your git config, or the GitHub App's bot user when using --github-app-id.",
+	}
+	GenericCommitAuthorEmailFlag = cli.StringFlag{
+		Name:  CommitAuthorEmailFlagName,
+		Usage: "The email address to author and commit commits as. Requires --commit-author-name. Defaults to the email in your git config, or the GitHub App's bot user when using --github-app-id.",
+	}
+	GenericSignoffFlag = cli.BoolFlag{
+		Name:  SignoffFlagName,
+		Usage: "Add a Signed-off-by trailer for the commit author to each commit message, as git commit --signoff does.",
+	}
+	GenericCommitTrailerFlag = cli.StringSliceFlag{
+		Name:  CommitTrailerFlagName,
+		Usage: "A trailer, such as \"Co-authored-by: Jane Doe <jane@example.com>\", to add to each commit message. Pass multiple times to add several trailers.",
+	}
+	GenericStageIncludeFlag = cli.StringSliceFlag{
+		Name:  StageIncludeFlagName,
+		Usage: "Only commit the files the command changed that match the given pattern, written as in a .gitignore file, such as \"*.tf\" or \"docs/\". Pass multiple times to commit files matching any of several patterns. Files that don't match are left uncommitted, and listed in the run report.",
+	}
+	GenericStageExcludeFlag = cli.StringSliceFlag{
+		Name:  StageExcludeFlagName,
+		Usage: "Don't commit files the command changed that match the given pattern, written as in a .gitignore file, such as \"node_modules/\" or \".terraform/\", even if they match --stage-include. Pass multiple times to leave out files matching any of several patterns. Files left out are listed in the run report.",
+	}
+	GenericMaxChangedFilesFlag = cli.IntFlag{
+		Name:  MaxChangedFilesFlagName,
+		Usage: "Leave a repo unpushed if the command changed more than the given number of files in it. Defaults to no limit.",
+	}
+	GenericMaxChangedLinesFlag = cli.IntFlag{
+		Name:  MaxChangedLinesFlagName,
+		Usage: "Leave a repo unpushed if the command added and removed more than the given number of lines in it, in total. Defaults to no limit.",
+	}
+	GenericMaxFileSizeFlag = cli.Int64Flag{
+		Name:  MaxFileSizeFlagName,
+		Usage: "Leave a repo unpushed if the command wrote a file larger than the given number of bytes in it. Defaults to no limit.",
+	}
+	GenericSecretPatternFlag = cli.StringSliceFlag{
+		Name:  SecretPatternFlagName,
+		Usage: "A regular expression matching a kind of secret to scan each repo's changes for, on top of the built-in rules. Pass multiple times to scan for several kinds of secrets.",
+	}
+	GenericSkipSecretScanFlag = cli.BoolFlag{
+		Name:  SkipSecretScanFlagName,
+		Usage: "Don't scan the command's changes for secrets, such as cloud keys, GitHub tokens and private keys, before pushing them.",
+	}
+	GenericValidateCmdFlag = cli.StringFlag{
+		Name:  ValidateCmdFlagName,
+		Usage: "A shell command, such as \"go build ./...\" or \"terraform validate\", to run in each repo after the command. If it fails, the repo's changes are neither committed nor pushed.",
+	}
+	GenericValidateTimeoutFlag = cli.DurationFlag{
+		Name:  ValidateTimeoutFlagName,
+		Usage: "How long the --validate-cmd command may run in each repo, such as 5m, before it's stopped and the repo's changes are treated as having failed validation.",
+		Value: DefaultValidateTimeout,
+	}
+	GenericInteractiveFlag = cli.BoolFlag{
+		Name:  InteractiveFlagName,
+		Usage: "Show the changes the command made to each repo, and ask whether to push them, skip the repo or edit the commit message first. Requires a terminal.",
+	}
+	GenericDryRunOutputDirFlag = cli.StringFlag{
+		Name:  DryRunOutputDirFlagName,
+		Usage: "With --dry-run, write the changes the command made to each repo to this directory as a patch, which can be applied with git am, along with a report.md summarizing all of them.",
+	}
+	GenericCherryPickFlag = cli.StringFlag{
+		Name:  CherryPickFlagName,
+		Usage: "Instead of running a command, cherry-pick a commit from another repo into each repo, given as <github-org>/<repo-name>@<full-commit-sha>. The commit keeps its author and message, and gets a Cherry-picked-from trailer.",
+	}
 	GenericPullRequestTitleFlag = cli.StringFlag{
 		Name:  PullRequestTitleFlagName,
 		Usage: "The title to add to pull requests opened by git-xargs",
@@ -120,4 +252,133 @@ var (
 		Name:  KeepClonedRepositoriesFlagName,
 		Usage: "By default, git-xargs deletes the cloned repositories from the temp directory after the command has finished running, to save space on your machine. Pass this flag to prevent git-xargs from deleting the cloned repositories.",
 	}
+	GenericGithubAppIDFlag = cli.Int64Flag{
+		Name:   GithubAppIDFlagName,
+		Usage:  "The ID of the GitHub App to authenticate as, instead of using GITHUB_OAUTH_TOKEN. Can also be set via the GITHUB_APP_ID environment variable.",
+		EnvVar: "GITHUB_APP_ID",
+	}
+	GenericGithubAppPrivateKeyPathFlag = cli.StringFlag{
+		Name:   GithubAppPrivateKeyPathFlagName,
+		Usage:  "The path to the PEM-encoded private key of the GitHub App passed via --github-app-id. Can also be set via the GITHUB_APP_PRIVATE_KEY_PATH environment variable.",
+		EnvVar: "GITHUB_APP_PRIVATE_KEY_PATH",
+	}
+	GenericGithubAppInstallationIDFlag = cli.Int64Flag{
+		Name:   GithubAppInstallationIDFlagName,
+		Usage:  "The ID of the GitHub App installation to use for every repo. If not set, the installation is looked up for each organization or user that owns a targeted repo. Can also be set via the GITHUB_APP_INSTALLATION_ID environment variable.",
+		EnvVar: "GITHUB_APP_INSTALLATION_ID",
+	}
+	GenericGitTransportFlag = cli.StringFlag{
+		Name:  GitTransportFlagName,
+		Usage: "The transport to use when cloning, pulling and pushing repos. Either \"https\", which authenticates with your GitHub token, or \"ssh\", which authenticates with your SSH key and leaves the token to be used for API calls only. Defaults to https.",
+		Value: DefaultGitTransport,
+	}
+	GenericSSHKeyPathFlag = cli.StringFlag{
+		Name:  SSHKeyPathFlagName,
+		Usage: "The path to the private key to use when --git-transport is ssh. If not set, keys are obtained from the running ssh-agent. If the key is encrypted, export its passphrase as GIT_XARGS_SSH_KEY_PASSPHRASE.",
+	}
+	GenericSSHKnownHostsPathFlag = cli.StringFlag{
+		Name:  SSHKnownHostsPathFlagName,
+		Usage: "The path to the known_hosts file used to verify the GitHub host key when --git-transport is ssh. Defaults to $SSH_KNOWN_HOSTS, or ~/.ssh/known_hosts and /etc/ssh/ssh_known_hosts.",
+	}
+	GenericTokenFileFlag = cli.StringFlag{
+		Name:  TokenFileFlagName,
+		Usage: "The path to a file containing the GitHub token to use, or several tokens, one per line, to spread API requests across. Takes precedence over --token-command and the GITHUB_OAUTH_TOKEN, GITHUB_TOKEN and GH_TOKEN environment variables.",
+	}
+	GenericTokenCommandFlag = cli.StringFlag{
+		Name:  TokenCommandFlagName,
+		Usage: "A shell command, such as \"vault read -field=token secret/github\", whose output is the GitHub token to use. Takes precedence over the GITHUB_OAUTH_TOKEN, GITHUB_TOKEN and GH_TOKEN environment variables.",
+	}
+	GenericGitCredentialHelperFlag = cli.BoolFlag{
+		Name:  GitCredentialHelperFlagName,
+		Usage: "Authenticate HTTPS clones and pushes with the credential helper configured in your git config, rather than with the GitHub token. The token is then only used for API calls.",
+	}
+	GenericTokenRotationStrategyFlag = cli.StringFlag{
+		Name:  TokenRotationStrategyFlagName,
+		Usage: "When several GitHub tokens are supplied, how to pick the token for each API request. Either \"round-robin\", or \"most-remaining\" to pick the token with the most remaining rate limit quota. Defaults to most-remaining.",
+		Value: DefaultTokenRotationStrategy,
+	}
+	GenericGithubHostnameFlag = cli.StringFlag{
+		Name:   GithubHostnameFlagName,
+		Usage:  "The hostname of your GitHub Enterprise Server, such as ghe.example.com. Can also be set via the GITHUB_HOSTNAME environment variable.",
+		EnvVar: "GITHUB_HOSTNAME",
+	}
+	GenericGithubAPIURLFlag = cli.StringFlag{
+		Name:  GithubAPIURLFlagName,
+		Usage: "The base URL of your GitHub Enterprise Server's REST API, if it is not served from https://<github-hostname>/api/v3/.",
+	}
+	GenericGithubUploadURLFlag = cli.StringFlag{
+		Name:  GithubUploadURLFlagName,
+		Usage: "The base URL of your GitHub Enterprise Server's uploads API, if it is not served alongside the REST API.",
+	}
+	GenericCABundleFlag = cli.StringFlag{
+		Name:  CABundleFlagName,
+		Usage: "The path to a PEM file of certificate authorities to trust, in addition to the system's, when connecting to GitHub. Use this if your GitHub Enterprise Server's certificate is issued by an internal CA.",
+	}
+	GenericProxyFlag = cli.StringFlag{
+		Name:  ProxyFlagName,
+		Usage: "The HTTP(S) proxy to use for API calls and for cloning and pushing over HTTPS, such as http://proxy.example.com:3128. Defaults to the HTTPS_PROXY, HTTP_PROXY and NO_PROXY environment variables.",
+	}
+	GenericCloneDepthFlag = cli.IntFlag{
+		Name:  CloneDepthFlagName,
+		Usage: "Create shallow clones, truncated to the given number of commits. Defaults to cloning the full history.",
+	}
+	GenericSingleBranchFlag = cli.BoolFlag{
+		Name:  SingleBranchFlagName,
+		Usage: "Only clone each repo's default branch, rather than all of its branches.",
+	}
+	GenericSparsePathsFlag = cli.StringSliceFlag{
+		Name:  SparsePathsFlagName,
+		Usage: "Only check out the given directory of each repo, along with the files at its root. Pass multiple times to check out several directories. Requires git to be installed.",
+	}
+	GenericRecurseSubmodulesFlag = cli.BoolFlag{
+		Name:  RecurseSubmodulesFlagName,
+		Usage: "Initialize and check out each repo's submodules, recursively, before running the command. If the command checks out a different commit in a submodule, the updated submodule reference is committed.",
+	}
+	GenericBranchStrategyFlag = cli.StringFlag{
+		Name:  BranchStrategyFlagName,
+		Usage: "What to do when the branch already exists on a repo, for example from a previous run. Either \"append\" to add a commit on top of it, \"rebase\" to replay its commits on top of the latest base branch, or \"recreate\" to start it over from the latest base branch. rebase and recreate force-push the branch, but only if nobody else pushed to it in the meantime. rebase requires git to be installed.",
+		Value: DefaultBranchStrategy,
+	}
+	GenericSignCommitsFlag = cli.BoolFlag{
+		Name:  SignCommitsFlagName,
+		Usage: "Sign each commit git-xargs makes with the key passed via --signing-key, so that it can be pushed to branches that require signed commits.",
+	}
+	GenericSigningKeyFlag = cli.StringFlag{
+		Name:  SigningKeyFlagName,
+		Usage: "The path to the OpenPGP or SSH private key --sign-commits signs commits with. If the key is encrypted, export its passphrase as GIT_XARGS_SIGNING_KEY_PASSPHRASE, or pass --signing-key-passphrase-file.",
+	}
+	GenericSigningKeyPassphraseFileFlag = cli.StringFlag{
+		Name:  SigningKeyPassphraseFileFlagName,
+		Usage: "The path to a file containing the passphrase of the key passed via --signing-key. Takes precedence over GIT_XARGS_SIGNING_KEY_PASSPHRASE.",
+	}
+	GenericGitBackendFlag = cli.StringFlag{
+		Name:  GitBackendFlagName,
+		Usage: "How git-xargs runs git operations. Either \"go-git\" to run them in-process, or \"cli\" to run the git binary, which must be installed, so that features such as LFS, hooks and your git config apply. Defaults to go-git.",
+		Value: DefaultGitBackend,
+	}
+	GenericCacheDirFlag = cli.StringFlag{
+		Name:  CacheDirFlagName,
+		Usage: "Keep a mirror of each repo in the given directory between runs, so that each run only fetches what changed since the last one. Runs sharing the directory take turns updating each mirror.",
+	}
+	GenericCacheMaxAgeFlag = cli.DurationFlag{
+		Name:  CacheMaxAgeFlagName,
+		Usage: "Remove cached repos that no run has used for this long, such as 168h.",
+		Value: DefaultCacheMaxAge,
+	}
+	GenericBundleDirFlag = cli.StringFlag{
+		Name:  BundleDirFlagName,
+		Usage: "The directory to write the bundle to: a patch of the changes the command made to each repo, along with everything git-xargs publish needs to push them and open pull requests later.",
+	}
+	GenericFuzzFlag = cli.IntFlag{
+		Name:  FuzzFlagName,
+		Usage: "Apply hunks even if up to this many of the three lines of context around them don't match the repo, like patch --fuzz.",
+	}
+	GenericThreeWayFlag = cli.BoolFlag{
+		Name:  ThreeWayFlagName,
+		Usage: "If a hunk doesn't apply, fall back to a three-way merge with the file the patch was made against, if the repo has it.",
+	}
+	GenericPreflightCheckFlag = cli.StringFlag{
+		Name:  PreflightCheckFlagName,
+		Usage: "Before cloning anything, check that git-xargs can push to and open pull requests against each selected repo. Either \"skip\" to leave out repos that fail the check and report why, or \"abort\" to stop the run without changing anything if any repo fails it.",
+	}
 )
@@ -4,7 +4,10 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/go-git/go-git/v5"
+	"github.com/google/go-github/v43/github"
 	"github.com/gruntwork-io/git-xargs/auth"
+	"github.com/gruntwork-io/git-xargs/bundle"
 	"github.com/gruntwork-io/git-xargs/common"
 	"github.com/gruntwork-io/git-xargs/local"
 	"github.com/gruntwork-io/git-xargs/stats"
@@ -21,6 +24,7 @@ type GitXargsConfig struct {
 	MaxConcurrentRepos            int
 	BranchName                    string
 	BaseBranchName                string
+	RepoBaseBranches              map[string]string
 	CommitMessage                 string
 	PullRequestTitle              string
 	PullRequestDescription        string
@@ -32,6 +36,58 @@ type GitXargsConfig struct {
 	RepoFromStdIn                 []string
 	Args                          []string
 	GithubClient                  auth.GithubClient
+	GitCredentials                auth.GitCredentials
+	GithubTokens                  []string
+	TokenRotationStrategy         string
+	TokenPool                     *auth.TokenPool
+	PreflightCheck                string
+	TokenScopes                   *auth.TokenScopes
+	GithubServerOptions           auth.ServerOptions
+	GithubServer                  *auth.GithubServer
+	CloneDepth                    int
+	SingleBranch                  bool
+	SparsePaths                   []string
+	RecurseSubmodules             bool
+	BranchStrategy                string
+	SignCommits                   bool
+	SigningKeyPath                string
+	SigningKeyPassphraseFile      string
+	CommitSigner                  git.Signer
+	CacheDir                      string
+	GitBackend                    string
+	TokenFile                     string
+	TokenCommand                  string
+	UseGitCredentialHelper        bool
+	GithubAppID                   int64
+	GithubAppPrivateKeyPath       string
+	GithubAppInstallationID       int64
+	CommitAuthorName              string
+	CommitAuthorEmail             string
+	Signoff                       bool
+	CommitTrailers                []string
+	StageInclude                  []string
+	StageExclude                  []string
+	MaxChangedFiles               int
+	MaxChangedLines               int
+	MaxFileSize                   int64
+	SecretPatterns                []string
+	SkipSecretScan                bool
+	ValidateCmd                   string
+	ValidateTimeout               time.Duration
+	Interactive                   bool
+	DryRunOutputDir               string
+	GeneratingBundle              bool
+	Bundle                        *bundle.Manifest
+	BundleDir                     string
+	PatchFile                     string
+	PatchFuzz                     int
+	PatchThreeWay                 bool
+	CherryPick                    string
+	CherryPickSource              *github.Repository
+	CherryPickCommit              string
+	GitTransport                  string
+	SSHKeyPath                    string
+	SSHKnownHostsPath             string
 	GitClient                     local.GitClient
 	Stats                         *stats.RunStats
 	PRChan                        chan types.OpenPrRequest
@@ -54,6 +110,7 @@ func NewGitXargsConfig() *GitXargsConfig {
 		MaxConcurrentRepos:            0,
 		BranchName:                    "",
 		BaseBranchName:                "",
+		RepoBaseBranches:              map[string]string{},
 		CommitMessage:                 common.DefaultCommitMessage,
 		PullRequestTitle:              common.DefaultPullRequestTitle,
 		PullRequestDescription:        common.DefaultPullRequestDescription,
@@ -64,7 +121,50 @@ func NewGitXargsConfig() *GitXargsConfig {
 		RepoSlice:                     []string{},
 		RepoFromStdIn:                 []string{},
 		Args:                          []string{},
-		GithubClient:                  auth.ConfigureGithubClient(),
+		GithubClient:                  auth.ConfigureGithubClient(auth.TokenFromEnvironment(), nil),
+		GitCredentials:                auth.TokenCredentials{Token: auth.TokenFromEnvironment()},
+		GithubTokens:                  auth.TokensFromEnvironment(),
+		TokenRotationStrategy:         common.DefaultTokenRotationStrategy,
+		TokenPool:                     nil,
+		TokenFile:                     "",
+		TokenCommand:                  "",
+		UseGitCredentialHelper:        false,
+		GithubAppID:                   0,
+		GithubAppPrivateKeyPath:       "",
+		GithubAppInstallationID:       0,
+		CommitAuthorName:              "",
+		CommitAuthorEmail:             "",
+		Signoff:                       false,
+		CommitTrailers:                []string{},
+		StageInclude:                  []string{},
+		StageExclude:                  []string{},
+		MaxChangedFiles:               0,
+		MaxChangedLines:               0,
+		MaxFileSize:                   0,
+		SecretPatterns:                []string{},
+		SkipSecretScan:                false,
+		ValidateCmd:                   "",
+		ValidateTimeout:               common.DefaultValidateTimeout,
+		Interactive:                   false,
+		DryRunOutputDir:               "",
+		GeneratingBundle:              false,
+		Bundle:                        nil,
+		BundleDir:                     "",
+		PatchFile:                     "",
+		PatchFuzz:                     0,
+		PatchThreeWay:                 false,
+		CherryPick:                    "",
+		CherryPickSource:              nil,
+		CherryPickCommit:              "",
+		GitTransport:                  common.DefaultGitTransport,
+		SSHKeyPath:                    "",
+		SSHKnownHostsPath:             "",
+		GitBackend:                    common.DefaultGitBackend,
+		BranchStrategy:                common.DefaultBranchStrategy,
+		SignCommits:                   false,
+		SigningKeyPath:                "",
+		SigningKeyPassphraseFile:      "",
+		CommitSigner:                  nil,
 		GitClient:                     local.NewGitClient(local.GitProductionProvider{}),
 		Stats:                         stats.NewStatsTracker(),
 		PRChan:                        make(chan types.OpenPrRequest),
@@ -93,3 +193,9 @@ func NewGitXargsTestConfig() *GitXargsConfig {
 func (c *GitXargsConfig) HasReviewers() bool {
 	return len(c.Reviewers) > 0 || len(c.TeamReviewers) > 0
 }
+
+// UsesGithubApp returns true if the user asked git-xargs to authenticate as a GitHub App rather than with a personal
+// access token
+func (c *GitXargsConfig) UsesGithubApp() bool {
+	return c.GithubAppID != 0
+}
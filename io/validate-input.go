@@ -1,12 +1,20 @@
 package io
 
 import (
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/gruntwork-io/git-xargs/auth"
+	"github.com/gruntwork-io/git-xargs/common"
 	"github.com/gruntwork-io/git-xargs/config"
 	"github.com/gruntwork-io/git-xargs/types"
+	"github.com/gruntwork-io/git-xargs/util"
 	"github.com/gruntwork-io/go-commons/errors"
 )
 
-// EnsureValidOptionsPassed checks that user has provided one valid method for selecting repos to operate on
+// EnsureValidOptionsPassed checks that user has provided one valid method for selecting repos to operate on, and that
+// any authentication options they passed are complete
 func EnsureValidOptionsPassed(config *config.GitXargsConfig) error {
 	if len(config.RepoSlice) < 1 && config.ReposFile == "" && config.GithubOrg == "" && len(config.RepoFromStdIn) == 0 {
 		return errors.WithStackTrace(types.NoRepoSelectionsMadeErr{})
@@ -14,5 +22,69 @@ func EnsureValidOptionsPassed(config *config.GitXargsConfig) error {
 	if config.BranchName == "" {
 		return errors.WithStackTrace(types.NoBranchNameErr{})
 	}
+	if config.UsesGithubApp() && config.GithubAppPrivateKeyPath == "" {
+		return errors.WithStackTrace(types.GithubAppPrivateKeyMissingErr{})
+	}
+	if config.GitTransport != "" && config.GitTransport != common.GitTransportHTTPS && config.GitTransport != common.GitTransportSSH {
+		return errors.WithStackTrace(types.InvalidGitTransportErr{Transport: config.GitTransport})
+	}
+	if config.TokenRotationStrategy != "" && config.TokenRotationStrategy != auth.RoundRobinRotation && config.TokenRotationStrategy != auth.MostRemainingRotation {
+		return errors.WithStackTrace(types.InvalidTokenRotationStrategyErr{Strategy: config.TokenRotationStrategy})
+	}
+	if config.PreflightCheck != "" && config.PreflightCheck != common.PreflightCheckSkip && config.PreflightCheck != common.PreflightCheckAbort {
+		return errors.WithStackTrace(types.InvalidPreflightCheckErr{Mode: config.PreflightCheck})
+	}
+	if config.CloneDepth < 0 {
+		return errors.WithStackTrace(types.InvalidCloneDepthErr{Depth: config.CloneDepth})
+	}
+	if config.MaxChangedFiles < 0 {
+		return errors.WithStackTrace(types.InvalidChangeSizeLimitErr{Flag: common.MaxChangedFilesFlagName, Limit: int64(config.MaxChangedFiles)})
+	}
+	if config.MaxChangedLines < 0 {
+		return errors.WithStackTrace(types.InvalidChangeSizeLimitErr{Flag: common.MaxChangedLinesFlagName, Limit: int64(config.MaxChangedLines)})
+	}
+	if config.MaxFileSize < 0 {
+		return errors.WithStackTrace(types.InvalidChangeSizeLimitErr{Flag: common.MaxFileSizeFlagName, Limit: config.MaxFileSize})
+	}
+	for _, pattern := range config.SecretPatterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return errors.WithStackTrace(types.InvalidSecretPatternErr{Pattern: pattern, Underlying: err})
+		}
+	}
+	if config.ValidateCmd != "" && config.ValidateTimeout <= 0 {
+		return errors.WithStackTrace(types.InvalidValidateTimeoutErr{Timeout: config.ValidateTimeout})
+	}
+	if config.PatchFuzz < 0 {
+		return errors.WithStackTrace(types.InvalidFuzzErr{Fuzz: config.PatchFuzz})
+	}
+	if config.CherryPick != "" && util.ConvertStringToCherryPickSource(config.CherryPick) == nil {
+		return errors.WithStackTrace(types.InvalidCherryPickErr{CherryPick: config.CherryPick})
+	}
+	if config.DryRunOutputDir != "" && !config.DryRun {
+		return errors.WithStackTrace(types.DryRunOutputDirRequiresDryRunErr{})
+	}
+	if config.GitBackend != "" && config.GitBackend != common.GitBackendGoGit && config.GitBackend != common.GitBackendCLI {
+		return errors.WithStackTrace(types.InvalidGitBackendErr{Backend: config.GitBackend})
+	}
+	if config.BranchStrategy != "" && config.BranchStrategy != common.BranchStrategyAppend && config.BranchStrategy != common.BranchStrategyRebase && config.BranchStrategy != common.BranchStrategyRecreate {
+		return errors.WithStackTrace(types.InvalidBranchStrategyErr{Strategy: config.BranchStrategy})
+	}
+	if (config.CommitAuthorName == "") != (config.CommitAuthorEmail == "") {
+		return errors.WithStackTrace(types.IncompleteCommitAuthorErr{})
+	}
+	for _, trailer := range config.CommitTrailers {
+		if !util.IsValidCommitTrailer(trailer) {
+			return errors.WithStackTrace(types.InvalidCommitTrailerErr{Trailer: trailer})
+		}
+	}
+	if config.SignCommits && config.SigningKeyPath == "" {
+		return errors.WithStackTrace(types.SigningKeyMissingErr{})
+	}
+	for _, sparsePath := range config.SparsePaths {
+		cleaned := filepath.ToSlash(filepath.Clean(sparsePath))
+		if sparsePath == "" || filepath.IsAbs(sparsePath) || cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+			return errors.WithStackTrace(types.InvalidSparsePathErr{Path: sparsePath})
+		}
+	}
 	return nil
 }
@@ -2,6 +2,7 @@ package io
 
 import (
 	"testing"
+	"time"
 
 	"github.com/gruntwork-io/git-xargs/config"
 	"github.com/stretchr/testify/assert"
@@ -61,3 +62,216 @@ func TestEnsureValidOptionsPassedAcceptsAllFlagsSimultaneously(t *testing.T) {
 	err := EnsureValidOptionsPassed(testConfigWithAllSelectionCriteria)
 	assert.NoError(t, err)
 }
+
+func TestEnsureValidOptionsPassedRejectsGithubAppWithoutPrivateKey(t *testing.T) {
+	t.Parallel()
+	testConfigWithGithubApp := &config.GitXargsConfig{
+		BranchName:  "test-branch",
+		GithubOrg:   "gruntwork-io",
+		GithubAppID: 12345,
+	}
+
+	err := EnsureValidOptionsPassed(testConfigWithGithubApp)
+	assert.Error(t, err)
+}
+
+func TestEnsureValidOptionsPassedRejectsUnknownGitTransport(t *testing.T) {
+	t.Parallel()
+	testConfigWithGitTransport := &config.GitXargsConfig{
+		BranchName:   "test-branch",
+		GithubOrg:    "gruntwork-io",
+		GitTransport: "ftp",
+	}
+
+	err := EnsureValidOptionsPassed(testConfigWithGitTransport)
+	assert.Error(t, err)
+}
+
+func TestEnsureValidOptionsPassedRejectsUnknownPreflightCheck(t *testing.T) {
+	t.Parallel()
+	testConfigWithPreflightCheck := &config.GitXargsConfig{
+		BranchName:     "test-branch",
+		GithubOrg:      "gruntwork-io",
+		PreflightCheck: "warn",
+	}
+
+	err := EnsureValidOptionsPassed(testConfigWithPreflightCheck)
+	assert.Error(t, err)
+}
+
+func TestEnsureValidOptionsPassedRejectsInvalidSparsePaths(t *testing.T) {
+	t.Parallel()
+
+	for _, sparsePath := range []string{"/modules", "../modules", ".", "modules/../.."} {
+		testConfigWithSparsePaths := &config.GitXargsConfig{
+			BranchName:  "test-branch",
+			GithubOrg:   "gruntwork-io",
+			SparsePaths: []string{"modules/vpc", sparsePath},
+		}
+
+		err := EnsureValidOptionsPassed(testConfigWithSparsePaths)
+		assert.Error(t, err, sparsePath)
+	}
+}
+
+func TestEnsureValidOptionsPassedRejectsInvalidGitBackend(t *testing.T) {
+	t.Parallel()
+
+	testConfigWithGitBackend := &config.GitXargsConfig{
+		BranchName: "test-branch",
+		GithubOrg:  "gruntwork-io",
+		GitBackend: "libgit2",
+	}
+
+	err := EnsureValidOptionsPassed(testConfigWithGitBackend)
+	assert.Error(t, err)
+}
+
+func TestEnsureValidOptionsPassedRejectsNegativeChangeSizeLimits(t *testing.T) {
+	t.Parallel()
+
+	for _, testConfigWithLimit := range []*config.GitXargsConfig{
+		{BranchName: "test-branch", GithubOrg: "gruntwork-io", MaxChangedFiles: -1},
+		{BranchName: "test-branch", GithubOrg: "gruntwork-io", MaxChangedLines: -1},
+		{BranchName: "test-branch", GithubOrg: "gruntwork-io", MaxFileSize: -1},
+	} {
+		err := EnsureValidOptionsPassed(testConfigWithLimit)
+		assert.Error(t, err)
+	}
+}
+
+func TestEnsureValidOptionsPassedRejectsInvalidSecretPattern(t *testing.T) {
+	t.Parallel()
+
+	testConfigWithSecretPattern := &config.GitXargsConfig{
+		BranchName:     "test-branch",
+		GithubOrg:      "gruntwork-io",
+		SecretPatterns: []string{"internal-[0-9a-f"},
+	}
+
+	err := EnsureValidOptionsPassed(testConfigWithSecretPattern)
+	assert.Error(t, err)
+}
+
+func TestEnsureValidOptionsPassedRejectsIncompleteCommitAuthor(t *testing.T) {
+	t.Parallel()
+
+	testConfigWithAuthorName := &config.GitXargsConfig{
+		BranchName:       "test-branch",
+		GithubOrg:        "gruntwork-io",
+		CommitAuthorName: "git-xargs",
+	}
+
+	err := EnsureValidOptionsPassed(testConfigWithAuthorName)
+	assert.Error(t, err)
+}
+
+func TestEnsureValidOptionsPassedRejectsInvalidCommitTrailer(t *testing.T) {
+	t.Parallel()
+
+	for _, trailer := range []string{"Co-authored-by Jane Doe", "Co authored by: Jane Doe", "Reviewed-by:", "Ticket: 1\nRefs: 2"} {
+		testConfigWithTrailer := &config.GitXargsConfig{
+			BranchName:     "test-branch",
+			GithubOrg:      "gruntwork-io",
+			CommitTrailers: []string{"Co-authored-by: Jane Doe <jane@example.com>", trailer},
+		}
+
+		err := EnsureValidOptionsPassed(testConfigWithTrailer)
+		assert.Error(t, err, trailer)
+	}
+}
+
+func TestEnsureValidOptionsPassedRequiresSigningKey(t *testing.T) {
+	t.Parallel()
+
+	testConfigWithSigning := &config.GitXargsConfig{
+		BranchName:  "test-branch",
+		GithubOrg:   "gruntwork-io",
+		SignCommits: true,
+	}
+
+	err := EnsureValidOptionsPassed(testConfigWithSigning)
+	assert.Error(t, err)
+}
+
+func TestEnsureValidOptionsPassedRejectsInvalidBranchStrategy(t *testing.T) {
+	t.Parallel()
+
+	testConfigWithBranchStrategy := &config.GitXargsConfig{
+		BranchName:     "test-branch",
+		GithubOrg:      "gruntwork-io",
+		BranchStrategy: "squash",
+	}
+
+	err := EnsureValidOptionsPassed(testConfigWithBranchStrategy)
+	assert.Error(t, err)
+}
+
+func TestEnsureValidOptionsPassedRequiresDryRunForOutputDir(t *testing.T) {
+	t.Parallel()
+
+	testConfigWithOutputDir := &config.GitXargsConfig{
+		BranchName:      "test-branch",
+		GithubOrg:       "gruntwork-io",
+		DryRunOutputDir: "patches",
+	}
+
+	err := EnsureValidOptionsPassed(testConfigWithOutputDir)
+	assert.Error(t, err)
+
+	testConfigWithOutputDir.DryRun = true
+	assert.NoError(t, EnsureValidOptionsPassed(testConfigWithOutputDir))
+}
+
+func TestEnsureValidOptionsPassedRejectsNegativeFuzz(t *testing.T) {
+	t.Parallel()
+
+	testConfigWithFuzz := &config.GitXargsConfig{
+		BranchName: "test-branch",
+		GithubOrg:  "gruntwork-io",
+		PatchFuzz:  -1,
+	}
+
+	err := EnsureValidOptionsPassed(testConfigWithFuzz)
+	assert.Error(t, err)
+}
+
+func TestEnsureValidOptionsPassedRejectsNonPositiveValidateTimeout(t *testing.T) {
+	t.Parallel()
+
+	testConfigWithValidateCmd := &config.GitXargsConfig{
+		BranchName:  "test-branch",
+		GithubOrg:   "gruntwork-io",
+		ValidateCmd: "go build ./...",
+	}
+
+	err := EnsureValidOptionsPassed(testConfigWithValidateCmd)
+	assert.Error(t, err)
+
+	testConfigWithValidateCmd.ValidateTimeout = time.Minute
+	assert.NoError(t, EnsureValidOptionsPassed(testConfigWithValidateCmd))
+}
+
+func TestEnsureValidOptionsPassedChecksCherryPick(t *testing.T) {
+	t.Parallel()
+
+	for _, cherryPick := range []string{"gruntwork-io/cloud-nuke", "gruntwork-io/cloud-nuke@abc1234", "cloud-nuke@0123456789abcdef0123456789abcdef01234567"} {
+		testConfigWithCherryPick := &config.GitXargsConfig{
+			BranchName: "test-branch",
+			GithubOrg:  "gruntwork-io",
+			CherryPick: cherryPick,
+		}
+
+		err := EnsureValidOptionsPassed(testConfigWithCherryPick)
+		assert.Error(t, err, cherryPick)
+	}
+
+	testConfigWithCherryPick := &config.GitXargsConfig{
+		BranchName: "test-branch",
+		GithubOrg:  "gruntwork-io",
+		CherryPick: "gruntwork-io/cloud-nuke@0123456789abcdef0123456789abcdef01234567",
+	}
+
+	err := EnsureValidOptionsPassed(testConfigWithCherryPick)
+	assert.NoError(t, err)
+}
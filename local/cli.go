@@ -0,0 +1,473 @@
+package local
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/gruntwork-io/git-xargs/types"
+	"github.com/gruntwork-io/go-commons/errors"
+)
+
+// credentialHelper is the git credential helper that answers git's requests for HTTPS credentials with the username and
+// password in the environment, so that they never appear in git's arguments
+const credentialHelper = `!f() { test "$1" = get && printf 'username=%s\npassword=%s\n' "$GIT_XARGS_USERNAME" "$GIT_XARGS_PASSWORD"; }; f`
+
+// defaultPushRefSpec is the refspec go-git pushes when none is given, which the git CLI is asked to push too so that
+// both providers behave alike
+const defaultPushRefSpec = "refs/heads/*:refs/heads/*"
+
+// GitCLIProvider performs git operations by running the git binary, so that the git features go-git lacks, such as
+// LFS, hooks and the user's own configuration, apply to every clone. Credentials passed in the go-git options are
+// handed to git for the single command that needs them, and are never written to a repo's config
+type GitCLIProvider struct {
+	// SSHKeyPath is the private key to authenticate with over SSH. If empty, ssh uses the running ssh-agent and its
+	// default keys
+	SSHKeyPath string
+	// SSHKnownHostsPath is the known_hosts file to verify host keys against, in addition to the user's own
+	SSHKnownHostsPath string
+	// CABundlePath is a PEM file of certificate authorities for git to trust over HTTPS. git trusts only these, rather
+	// than these in addition to the system's
+	CABundlePath string
+}
+
+// RunGitCommand runs git with the given arguments in the supplied repository directory, returning its stdout. The
+// error includes git's stderr, which is usually the only explanation of what went wrong
+func RunGitCommand(repositoryDir string, args ...string) (string, error) {
+	return runGit(repositoryDir, nil, nil, args...)
+}
+
+// runGit runs git with the given arguments and additional environment variables in the supplied directory, returning
+// its stdout. If progress is set, git's stderr is copied to it as well as into any error
+func runGit(dir string, env []string, progress io.Writer, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if progress != nil {
+		cmd.Stderr = io.MultiWriter(&stderr, progress)
+	}
+	// Never let git fall back to prompting on the terminal, which would hang a concurrent run
+	cmd.Env = append(append(os.Environ(), "GIT_TERMINAL_PROMPT=0"), env...)
+
+	if err := cmd.Run(); err != nil {
+		return "", errors.WithStackTrace(types.GitCommandFailedErr{Args: args, Stderr: strings.TrimSpace(stderr.String()), Underlying: err})
+	}
+
+	return stdout.String(), nil
+}
+
+// isMissingRemoteRef returns true if the given error is git reporting that a ref it was asked to fetch doesn't exist
+func isMissingRemoteRef(err error) bool {
+	failure, ok := errors.Unwrap(err).(types.GitCommandFailedErr)
+	return ok && strings.Contains(failure.Stderr, "couldn't find remote ref")
+}
+
+// remoteArgs returns the config arguments and environment variables with which git should reach a remote, given the
+// auth and proxy go-git would have used
+func (g GitCLIProvider) remoteArgs(auth transport.AuthMethod, proxy transport.ProxyOptions) ([]string, []string) {
+	var configArgs, env []string
+
+	switch auth := auth.(type) {
+	case nil:
+	case *http.BasicAuth:
+		// Clear any helpers from the user's config first, so that git neither asks nor tells them about these credentials
+		configArgs = append(configArgs, "-c", "credential.helper=", "-c", "credential.helper="+credentialHelper)
+		env = append(env, "GIT_XARGS_USERNAME="+auth.Username, "GIT_XARGS_PASSWORD="+auth.Password)
+	default:
+		env = append(env, "GIT_SSH_COMMAND="+g.sshCommand())
+	}
+
+	if proxy.URL != "" {
+		configArgs = append(configArgs, "-c", "http.proxy="+proxy.URL)
+	}
+	if g.CABundlePath != "" {
+		configArgs = append(configArgs, "-c", "http.sslCAInfo="+g.CABundlePath)
+	}
+
+	return configArgs, env
+}
+
+// sshCommand returns the ssh command git should connect over, which, like go-git, refuses hosts it doesn't know
+func (g GitCLIProvider) sshCommand() string {
+	command := []string{"ssh", "-o", "BatchMode=yes", "-o", "StrictHostKeyChecking=yes"}
+	if g.SSHKeyPath != "" {
+		command = append(command, "-o", "IdentitiesOnly=yes", "-i", shellQuote(g.SSHKeyPath))
+	}
+	if g.SSHKnownHostsPath != "" {
+		command = append(command, "-o", shellQuote("UserKnownHostsFile="+g.SSHKnownHostsPath+" ~/.ssh/known_hosts"))
+	}
+	return strings.Join(command, " ")
+}
+
+// shellQuote quotes the given string for sh, which git runs GIT_SSH_COMMAND with
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func (g GitCLIProvider) Clone(path string, o *git.CloneOptions) error {
+	configArgs, env := g.remoteArgs(o.Auth, o.ProxyOptions)
+	args := append(configArgs, "clone", "--quiet")
+
+	if o.Depth > 0 {
+		args = append(args, fmt.Sprintf("--depth=%d", o.Depth))
+		// Unlike go-git, git only clones the default branch of shallow clones unless told otherwise
+		if !o.SingleBranch {
+			args = append(args, "--no-single-branch")
+		}
+	}
+	if o.SingleBranch {
+		args = append(args, "--single-branch")
+	}
+	if o.NoCheckout {
+		args = append(args, "--no-checkout")
+	}
+	if o.ReferenceName != "" {
+		args = append(args, "--branch", o.ReferenceName.Short())
+	}
+	if o.RemoteName != "" {
+		args = append(args, "--origin", o.RemoteName)
+	}
+
+	_, err := runGit("", env, o.Progress, append(args, "--", o.URL, path)...)
+	return err
+}
+
+func (g GitCLIProvider) Head(path string) (*plumbing.Reference, error) {
+	hash, err := runGit(path, nil, nil, "rev-parse", "--verify", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+
+	// symbolic-ref fails if HEAD is detached, in which case the reference is HEAD itself
+	name := plumbing.HEAD
+	if branch, err := runGit(path, nil, nil, "symbolic-ref", "--quiet", "HEAD"); err == nil {
+		name = plumbing.ReferenceName(strings.TrimSpace(branch))
+	}
+
+	return plumbing.NewHashReference(name, plumbing.NewHash(strings.TrimSpace(hash))), nil
+}
+
+func (g GitCLIProvider) Checkout(path string, o *git.CheckoutOptions) error {
+	args := []string{"checkout", "--quiet"}
+
+	switch {
+	case o.Create:
+		args = append(args, "-b", o.Branch.Short())
+		if !o.Hash.IsZero() {
+			args = append(args, o.Hash.String())
+		}
+	case o.Branch != "":
+		args = append(args, o.Branch.Short())
+	default:
+		args = append(args, o.Hash.String())
+	}
+
+	if o.Force {
+		args = append(args, "--force")
+	}
+
+	_, err := runGit(path, nil, nil, args...)
+	return err
+}
+
+func (g GitCLIProvider) Status(path string) (git.Status, error) {
+	// Like go-git, only report a submodule as modified if a different commit is checked out in it
+	output, err := runGit(path, nil, nil, "status", "--porcelain=v1", "-z", "--untracked-files=all", "--ignore-submodules=dirty")
+	if err != nil {
+		return nil, err
+	}
+	return parsePorcelainStatus(output), nil
+}
+
+// parsePorcelainStatus converts the output of `git status --porcelain=v1 -z` into a go-git Status. Both use the same
+// single letter codes for the state of each file in the staging area and worktree
+func parsePorcelainStatus(output string) git.Status {
+	status := git.Status{}
+
+	entries := strings.Split(output, "\x00")
+	for i := 0; i < len(entries); i++ {
+		entry := entries[i]
+		if len(entry) < 4 {
+			continue
+		}
+
+		fileStatus := &git.FileStatus{
+			Staging:  git.StatusCode(entry[0]),
+			Worktree: git.StatusCode(entry[1]),
+		}
+
+		// Renames and copies are followed by the path they were renamed or copied from
+		if (fileStatus.Staging == git.Renamed || fileStatus.Staging == git.Copied) && i+1 < len(entries) {
+			i++
+			fileStatus.Extra = entries[i]
+		}
+
+		status[entry[3:]] = fileStatus
+	}
+
+	return status
+}
+
+func (g GitCLIProvider) Add(path string, file string) error {
+	_, err := runGit(path, nil, nil, "add", "--", file)
+	return err
+}
+
+func (g GitCLIProvider) Commit(path string, msg string, o *git.CommitOptions) (plumbing.Hash, error) {
+	args := []string{"commit", "--quiet", "--message", msg}
+	if o.All {
+		args = append(args, "--all")
+	}
+	if o.AllowEmptyCommits {
+		args = append(args, "--allow-empty")
+	}
+	if o.Amend {
+		args = append(args, "--amend")
+	}
+
+	// go-git commits as the author unless a separate committer is given
+	var env []string
+	if o.Author != nil {
+		env = append(env, signatureEnv("AUTHOR", o.Author)...)
+		committer := o.Committer
+		if committer == nil {
+			committer = o.Author
+		}
+		env = append(env, signatureEnv("COMMITTER", committer)...)
+	}
+
+	if _, err := runGit(path, env, nil, args...); err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	if o.Signer != nil {
+		if err := signHead(path, o.Signer); err != nil {
+			return plumbing.ZeroHash, err
+		}
+	}
+
+	head, err := g.Head(path)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	return head.Hash(), nil
+}
+
+// signatureEnv returns the environment variables that make git use the given signature for the given role, either
+// AUTHOR or COMMITTER
+func signatureEnv(role string, signature *object.Signature) []string {
+	when := signature.When
+	if when.IsZero() {
+		when = time.Now()
+	}
+
+	return []string{
+		fmt.Sprintf("GIT_%s_NAME=%s", role, signature.Name),
+		fmt.Sprintf("GIT_%s_EMAIL=%s", role, signature.Email),
+		fmt.Sprintf("GIT_%s_DATE=@%d %s", role, when.Unix(), when.Format("-0700")),
+	}
+}
+
+func (g GitCLIProvider) Fetch(path string, o *git.FetchOptions) error {
+	configArgs, env := g.remoteArgs(o.Auth, o.ProxyOptions)
+	args := append(configArgs, "fetch", "--quiet")
+
+	if o.Depth > 0 {
+		args = append(args, fmt.Sprintf("--depth=%d", o.Depth))
+	}
+	if o.Prune {
+		args = append(args, "--prune")
+	}
+	if o.Force {
+		args = append(args, "--force")
+	}
+
+	remoteName := o.RemoteName
+	if remoteName == "" {
+		remoteName = git.DefaultRemoteName
+	}
+	args = append(args, remoteName)
+	for _, refSpec := range o.RefSpecs {
+		args = append(args, refSpec.String())
+	}
+
+	_, err := runGit(path, env, o.Progress, args...)
+	if isMissingRemoteRef(err) {
+		return git.NoMatchingRefSpecError{}
+	}
+	return err
+}
+
+// Pull fetches the given branch and fast-forwards the current branch to it, which is all go-git's pull does. Like
+// go-git, it returns plumbing.ErrReferenceNotFound if the branch doesn't exist on the remote, and
+// git.NoErrAlreadyUpToDate if there was nothing to pull
+func (g GitCLIProvider) Pull(path string, o *git.PullOptions) error {
+	remoteName := o.RemoteName
+	if remoteName == "" {
+		remoteName = git.DefaultRemoteName
+	}
+
+	branchName := o.ReferenceName
+	if branchName == "" {
+		head, err := g.Head(path)
+		if err != nil {
+			return err
+		}
+		branchName = head.Name()
+	}
+
+	trackingBranch := fmt.Sprintf("refs/remotes/%s/%s", remoteName, branchName.Short())
+	err := g.Fetch(path, &git.FetchOptions{
+		RemoteName:   remoteName,
+		RefSpecs:     []gitconfig.RefSpec{gitconfig.RefSpec(fmt.Sprintf("+%s:%s", branchName, trackingBranch))},
+		Depth:        o.Depth,
+		Auth:         o.Auth,
+		Progress:     o.Progress,
+		ProxyOptions: o.ProxyOptions,
+	})
+	if _, ok := err.(git.NoMatchingRefSpecError); ok {
+		return plumbing.ErrReferenceNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	head, err := g.Head(path)
+	if err != nil {
+		return err
+	}
+	remote, err := runGit(path, nil, nil, "rev-parse", "--verify", trackingBranch)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(remote) == head.Hash().String() {
+		return git.NoErrAlreadyUpToDate
+	}
+
+	_, err = runGit(path, nil, nil, "merge", "--ff-only", "--quiet", trackingBranch)
+	return err
+}
+
+func (g GitCLIProvider) Push(path string, o *git.PushOptions) error {
+	configArgs, env := g.remoteArgs(o.Auth, o.ProxyOptions)
+	args := append(configArgs, "push", "--quiet")
+
+	if o.Force {
+		args = append(args, "--force")
+	}
+	if o.ForceWithLease != nil {
+		lease := "--force-with-lease"
+		if o.ForceWithLease.RefName != "" {
+			lease += "=" + o.ForceWithLease.RefName.String()
+			if !o.ForceWithLease.Hash.IsZero() {
+				lease += ":" + o.ForceWithLease.Hash.String()
+			}
+		}
+		args = append(args, lease)
+	}
+
+	remoteName := o.RemoteName
+	if remoteName == "" {
+		remoteName = git.DefaultRemoteName
+	}
+	args = append(args, remoteName)
+
+	if len(o.RefSpecs) == 0 {
+		args = append(args, defaultPushRefSpec)
+	}
+	for _, refSpec := range o.RefSpecs {
+		args = append(args, refSpec.String())
+	}
+
+	_, err := runGit(path, env, o.Progress, args...)
+	return err
+}
+
+func (g GitCLIProvider) UpdateSubmodules(path string, o *git.SubmoduleUpdateOptions) error {
+	configArgs, env := g.remoteArgs(o.Auth, transport.ProxyOptions{})
+	args := append(configArgs, "submodule", "--quiet", "update")
+
+	if o.Init {
+		args = append(args, "--init")
+	}
+	if o.NoFetch {
+		args = append(args, "--no-fetch")
+	}
+	if o.RecurseSubmodules > git.NoRecurseSubmodules {
+		args = append(args, "--recursive")
+	}
+	if o.Depth > 0 {
+		args = append(args, fmt.Sprintf("--depth=%d", o.Depth))
+	}
+
+	_, err := runGit(path, env, nil, args...)
+	return err
+}
+
+// LFSPull installs the LFS filters into the clone's config, so that git stages files tracked by LFS as pointers, then
+// downloads the LFS objects HEAD refers to and checks out their contents. The LFS hooks are left out, as LFSPush
+// uploads objects explicitly
+func (g GitCLIProvider) LFSPull(path string, o *git.FetchOptions) error {
+	if err := ensureLFSInstalled(); err != nil {
+		return err
+	}
+
+	if _, err := runGit(path, nil, nil, "lfs", "install", "--local", "--skip-repo"); err != nil {
+		return err
+	}
+
+	remoteName := o.RemoteName
+	if remoteName == "" {
+		remoteName = git.DefaultRemoteName
+	}
+
+	// git passes its -c arguments on to git-lfs, which reaches the remote with the same credentials and proxy as git
+	configArgs, env := g.remoteArgs(o.Auth, o.ProxyOptions)
+	_, err := runGit(path, env, o.Progress, append(configArgs, "lfs", "pull", remoteName)...)
+	return err
+}
+
+func (g GitCLIProvider) LFSPush(path string, o *git.PushOptions) error {
+	if err := ensureLFSInstalled(); err != nil {
+		return err
+	}
+
+	remoteName := o.RemoteName
+	if remoteName == "" {
+		remoteName = git.DefaultRemoteName
+	}
+
+	configArgs, env := g.remoteArgs(o.Auth, o.ProxyOptions)
+	args := append(configArgs, "lfs", "push", remoteName)
+
+	if len(o.RefSpecs) == 0 {
+		args = append(args, "HEAD")
+	}
+	for _, refSpec := range o.RefSpecs {
+		args = append(args, refSpec.Src())
+	}
+
+	_, err := runGit(path, env, o.Progress, args...)
+	return err
+}
+
+// ensureLFSInstalled returns types.LFSNotSupportedErr if git-lfs isn't installed
+func ensureLFSInstalled() error {
+	if _, err := runGit("", nil, nil, "lfs", "version"); err != nil {
+		return errors.WithStackTrace(types.LFSNotSupportedErr{Reason: "git-lfs is not installed"})
+	}
+	return nil
+}
@@ -1,26 +1,263 @@
 package local
 
-import "github.com/go-git/go-git/v5"
+import (
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
 
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/gruntwork-io/git-xargs/types"
+	"github.com/gruntwork-io/go-commons/errors"
+)
+
+// GitProvider performs the git operations git-xargs runs against each repo. Clone creates a clone in the given
+// directory, and every other operation acts on the clone in the given directory. The options are expressed as go-git's
+// option types, which every provider understands
 type GitProvider interface {
-	PlainClone(path string, isBare bool, o *git.CloneOptions) (*git.Repository, error)
+	Clone(path string, o *git.CloneOptions) error
+	Head(path string) (*plumbing.Reference, error)
+	Checkout(path string, o *git.CheckoutOptions) error
+	Status(path string) (git.Status, error)
+	Add(path string, file string) error
+	Commit(path string, msg string, o *git.CommitOptions) (plumbing.Hash, error)
+	Fetch(path string, o *git.FetchOptions) error
+	Pull(path string, o *git.PullOptions) error
+	Push(path string, o *git.PushOptions) error
+	// UpdateSubmodules initializes the clone's submodules and checks out the commits the clone records for them
+	UpdateSubmodules(path string, o *git.SubmoduleUpdateOptions) error
+	// LFSPull downloads the Git LFS objects HEAD refers to and writes their contents to the worktree, and configures
+	// the clone so that files tracked by LFS are staged as pointers
+	LFSPull(path string, o *git.FetchOptions) error
+	// LFSPush uploads the Git LFS objects the refs in the refspecs refer to, or those HEAD refers to if none are given
+	LFSPush(path string, o *git.PushOptions) error
 }
 
+// GitProductionProvider performs git operations in-process with go-git
 type GitProductionProvider struct{}
 
-func (g GitProductionProvider) PlainClone(path string, isBare bool, o *git.CloneOptions) (*git.Repository, error) {
-	return git.PlainClone(path, isBare, o)
+func (g GitProductionProvider) Clone(path string, o *git.CloneOptions) error {
+	_, err := git.PlainClone(path, false, o)
+	return err
+}
+
+func (g GitProductionProvider) Head(path string) (*plumbing.Reference, error) {
+	repo, err := git.PlainOpen(path)
+	if err != nil {
+		return nil, err
+	}
+	return repo.Head()
+}
+
+func (g GitProductionProvider) Checkout(path string, o *git.CheckoutOptions) error {
+	worktree, err := openWorktree(path)
+	if err != nil {
+		return err
+	}
+	return worktree.Checkout(o)
+}
+
+func (g GitProductionProvider) Status(path string) (git.Status, error) {
+	worktree, err := openWorktree(path)
+	if err != nil {
+		return nil, err
+	}
+	return worktree.Status()
+}
+
+func (g GitProductionProvider) Add(path string, file string) error {
+	worktree, err := openWorktree(path)
+	if err != nil {
+		return err
+	}
+	_, err = worktree.Add(file)
+	return err
+}
+
+func (g GitProductionProvider) Commit(path string, msg string, o *git.CommitOptions) (plumbing.Hash, error) {
+	worktree, err := openWorktree(path)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	return worktree.Commit(msg, o)
+}
+
+func (g GitProductionProvider) Fetch(path string, o *git.FetchOptions) error {
+	repo, err := git.PlainOpen(path)
+	if err != nil {
+		return err
+	}
+	return repo.Fetch(o)
+}
+
+func (g GitProductionProvider) Pull(path string, o *git.PullOptions) error {
+	worktree, err := openWorktree(path)
+	if err != nil {
+		return err
+	}
+	return worktree.Pull(o)
+}
+
+func (g GitProductionProvider) Push(path string, o *git.PushOptions) error {
+	repo, err := git.PlainOpen(path)
+	if err != nil {
+		return err
+	}
+	return repo.Push(o)
+}
+
+// UpdateSubmodules updates each submodule, and recurses into their own submodules itself, so that relative submodule
+// URLs are resolved against the origin of the repo that lists them, as git does. go-git resolves them against the
+// current directory instead. go-git doesn't apply a CA bundle or proxy to submodules, which are fetched with the
+// system's defaults
+func (g GitProductionProvider) UpdateSubmodules(path string, o *git.SubmoduleUpdateOptions) error {
+	repo, err := git.PlainOpen(path)
+	if err != nil {
+		return err
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return err
+	}
+	submodules, err := worktree.Submodules()
+	if err != nil {
+		return err
+	}
+
+	originURL := ""
+	if origin, err := repo.Remote(git.DefaultRemoteName); err == nil && len(origin.Config().URLs) > 0 {
+		originURL = origin.Config().URLs[0]
+	}
+
+	for _, submodule := range submodules {
+		submoduleConfig := submodule.Config()
+		submoduleConfig.URL = resolveSubmoduleURL(originURL, submoduleConfig.URL)
+
+		submoduleOptions := *o
+		submoduleOptions.RecurseSubmodules = git.NoRecurseSubmodules
+		if err := submodule.Update(&submoduleOptions); err != nil {
+			return err
+		}
+
+		if o.RecurseSubmodules > git.NoRecurseSubmodules {
+			nestedOptions := *o
+			nestedOptions.RecurseSubmodules--
+			if err := g.UpdateSubmodules(filepath.Join(path, submoduleConfig.Path), &nestedOptions); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// resolveSubmoduleURL returns the URL of a submodule whose URL in .gitmodules is relative, by resolving it against the
+// URL of the repo that lists it, as git does. Other URLs are returned as is
+func resolveSubmoduleURL(remoteURL string, submoduleURL string) string {
+	if remoteURL == "" || !(strings.HasPrefix(submoduleURL, "./") || strings.HasPrefix(submoduleURL, "../")) {
+		return submoduleURL
+	}
+
+	remoteURL = strings.TrimSuffix(remoteURL, "/")
+
+	if parsed, err := url.Parse(remoteURL); err == nil && parsed.Scheme != "" && parsed.Host+parsed.Path != "" {
+		parsed.Path = path.Join(parsed.Path, submoduleURL)
+		return parsed.String()
+	}
+
+	// scp-like SSH URLs, such as git@github.com:gruntwork-io/git-xargs.git
+	if host, repoPath, ok := strings.Cut(remoteURL, ":"); ok && !strings.Contains(host, "/") {
+		return host + ":" + path.Join(repoPath, submoduleURL)
+	}
+
+	return filepath.Join(remoteURL, submoduleURL)
+}
+
+func (g GitProductionProvider) LFSPull(path string, o *git.FetchOptions) error {
+	return errors.WithStackTrace(errGoGitLFSNotSupported)
+}
+
+func (g GitProductionProvider) LFSPush(path string, o *git.PushOptions) error {
+	return errors.WithStackTrace(errGoGitLFSNotSupported)
 }
 
-type MockGitProvider struct{}
+// errGoGitLFSNotSupported is returned by every LFS operation of the go-git provider, as go-git neither replaces LFS
+// pointers with file contents on checkout nor files with pointers when they're staged
+var errGoGitLFSNotSupported = types.LFSNotSupportedErr{Reason: "go-git can't check out or stage files tracked by LFS. Pass --git-backend cli to use git and git-lfs instead"}
 
-func (g MockGitProvider) PlainClone(path string, isBare bool, o *git.CloneOptions) (*git.Repository, error) {
+// openWorktree opens the worktree of the clone in the given directory
+func openWorktree(path string) (*git.Worktree, error) {
+	repo, err := git.PlainOpen(path)
+	if err != nil {
+		return nil, err
+	}
+	return repo.Worktree()
+}
+
+// MockGitProvider wraps another GitProvider, which defaults to go-git, and redirects its clones to a local test repo
+type MockGitProvider struct {
+	Provider GitProvider
+}
+
+func (g MockGitProvider) provider() GitProvider {
+	if g.Provider == nil {
+		return GitProductionProvider{}
+	}
+	return g.Provider
+}
+
+func (g MockGitProvider) Clone(path string, o *git.CloneOptions) error {
 
 	// Intercept the provided clone options and point to the locally checked out copy of github.com/gruntwork-io/fetch
 	// to prevent any actual cloning or pushing being done to a real remote repo during testing
 	o.URL = "../data/test/test-repo"
 
-	return git.PlainClone(path, isBare, o)
+	return g.provider().Clone(path, o)
+}
+
+func (g MockGitProvider) Head(path string) (*plumbing.Reference, error) {
+	return g.provider().Head(path)
+}
+
+func (g MockGitProvider) Checkout(path string, o *git.CheckoutOptions) error {
+	return g.provider().Checkout(path, o)
+}
+
+func (g MockGitProvider) Status(path string) (git.Status, error) {
+	return g.provider().Status(path)
+}
+
+func (g MockGitProvider) Add(path string, file string) error {
+	return g.provider().Add(path, file)
+}
+
+func (g MockGitProvider) Commit(path string, msg string, o *git.CommitOptions) (plumbing.Hash, error) {
+	return g.provider().Commit(path, msg, o)
+}
+
+func (g MockGitProvider) Fetch(path string, o *git.FetchOptions) error {
+	return g.provider().Fetch(path, o)
+}
+
+func (g MockGitProvider) Pull(path string, o *git.PullOptions) error {
+	return g.provider().Pull(path, o)
+}
+
+func (g MockGitProvider) Push(path string, o *git.PushOptions) error {
+	return g.provider().Push(path, o)
+}
+
+func (g MockGitProvider) UpdateSubmodules(path string, o *git.SubmoduleUpdateOptions) error {
+	return g.provider().UpdateSubmodules(path, o)
+}
+
+func (g MockGitProvider) LFSPull(path string, o *git.FetchOptions) error {
+	return g.provider().LFSPull(path, o)
+}
+
+func (g MockGitProvider) LFSPush(path string, o *git.PushOptions) error {
+	return g.provider().LFSPush(path, o)
 }
 
 type GitClient struct {
@@ -0,0 +1,402 @@
+package local
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/gruntwork-io/git-xargs/types"
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	cryptossh "golang.org/x/crypto/ssh"
+)
+
+// testProviders are the providers every test in this file runs against
+var testProviders = map[string]GitProvider{
+	"go-git": GitProductionProvider{},
+	"cli":    GitCLIProvider{},
+}
+
+// forEachProvider runs the given test against each provider
+func forEachProvider(t *testing.T, test func(t *testing.T, provider GitProvider)) {
+	for name, provider := range testProviders {
+		provider := provider
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			test(t, provider)
+		})
+	}
+}
+
+// createTestRemote creates a bare repo with a single commit on main, and returns its path
+func createTestRemote(t *testing.T) string {
+	remoteDir := filepath.Join(t.TempDir(), "remote.git")
+
+	script := `
+set -e
+git init -q --bare "$REMOTE"
+git -C "$REMOTE" symbolic-ref HEAD refs/heads/main
+git init -q -b main .
+echo "hello" > README.md
+git add -A
+git commit -q -m "initial commit"
+git push -q "$REMOTE" main
+`
+	cmd := exec.Command("bash", "-c", script)
+	cmd.Dir = t.TempDir()
+	cmd.Env = append(os.Environ(), "REMOTE="+remoteDir)
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	return remoteDir
+}
+
+// pushToTestRemote commits a new file to the given branch of the remote from a separate clone
+func pushToTestRemote(t *testing.T, remoteDir string, branch string) {
+	script := `
+set -e
+git clone -q "$REMOTE" .
+git checkout -q -B "$BRANCH"
+date +%s%N > "$BRANCH.txt"
+git add -A
+git commit -q -m "update $BRANCH"
+git push -q origin "$BRANCH"
+`
+	cmd := exec.Command("bash", "-c", script)
+	cmd.Dir = t.TempDir()
+	cmd.Env = append(os.Environ(), "REMOTE="+remoteDir, "BRANCH="+branch)
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+}
+
+func cloneTestRemote(t *testing.T, provider GitProvider, remoteDir string) string {
+	repositoryDir := t.TempDir()
+	require.NoError(t, provider.Clone(repositoryDir, &git.CloneOptions{URL: remoteDir}))
+	return repositoryDir
+}
+
+func TestCloneCommitAndPush(t *testing.T) {
+	t.Parallel()
+
+	forEachProvider(t, func(t *testing.T, provider GitProvider) {
+		remoteDir := createTestRemote(t)
+		repositoryDir := cloneTestRemote(t, provider, remoteDir)
+
+		head, err := provider.Head(repositoryDir)
+		require.NoError(t, err)
+		assert.Equal(t, plumbing.NewBranchReferenceName("main"), head.Name())
+
+		branchName := plumbing.NewBranchReferenceName("feature")
+		require.NoError(t, provider.Checkout(repositoryDir, &git.CheckoutOptions{Hash: head.Hash(), Branch: branchName, Create: true}))
+
+		require.NoError(t, os.WriteFile(filepath.Join(repositoryDir, "README.md"), []byte("updated"), 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(repositoryDir, "new.txt"), []byte("new"), 0644))
+
+		status, err := provider.Status(repositoryDir)
+		require.NoError(t, err)
+		assert.Equal(t, git.Modified, status.File("README.md").Worktree)
+		assert.True(t, status.IsUntracked("new.txt"))
+
+		require.NoError(t, provider.Add(repositoryDir, "new.txt"))
+
+		author := &object.Signature{Name: "git-xargs", Email: "git-xargs@example.com", When: time.Unix(1700000000, 0)}
+		hash, err := provider.Commit(repositoryDir, "update files", &git.CommitOptions{All: true, Author: author})
+		require.NoError(t, err)
+
+		status, err = provider.Status(repositoryDir)
+		require.NoError(t, err)
+		assert.True(t, status.IsClean())
+
+		commit, err := RunGitCommand(repositoryDir, "log", "-1", "--format=%H %an <%ae> %at %cn", "HEAD")
+		require.NoError(t, err)
+		assert.Equal(t, hash.String()+" git-xargs <git-xargs@example.com> 1700000000 git-xargs", strings.TrimSpace(commit))
+
+		require.NoError(t, provider.Push(repositoryDir, &git.PushOptions{RemoteName: "origin"}))
+
+		remoteBranch, err := RunGitCommand(remoteDir, "rev-parse", "refs/heads/feature")
+		require.NoError(t, err)
+		assert.Equal(t, hash.String(), strings.TrimSpace(remoteBranch))
+	})
+}
+
+func TestCheckoutExistingBranchFails(t *testing.T) {
+	t.Parallel()
+
+	forEachProvider(t, func(t *testing.T, provider GitProvider) {
+		repositoryDir := cloneTestRemote(t, provider, createTestRemote(t))
+
+		head, err := provider.Head(repositoryDir)
+		require.NoError(t, err)
+
+		err = provider.Checkout(repositoryDir, &git.CheckoutOptions{Hash: head.Hash(), Branch: head.Name(), Create: true})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "already exists")
+	})
+}
+
+func TestPull(t *testing.T) {
+	t.Parallel()
+
+	forEachProvider(t, func(t *testing.T, provider GitProvider) {
+		remoteDir := createTestRemote(t)
+		repositoryDir := cloneTestRemote(t, provider, remoteDir)
+
+		mainBranch := plumbing.NewBranchReferenceName("main")
+
+		err := provider.Pull(repositoryDir, &git.PullOptions{RemoteName: "origin", ReferenceName: mainBranch})
+		assert.Equal(t, git.NoErrAlreadyUpToDate, err)
+
+		err = provider.Pull(repositoryDir, &git.PullOptions{RemoteName: "origin", ReferenceName: plumbing.NewBranchReferenceName("missing")})
+		assert.Equal(t, plumbing.ErrReferenceNotFound, err)
+
+		pushToTestRemote(t, remoteDir, "main")
+		require.NoError(t, provider.Pull(repositoryDir, &git.PullOptions{RemoteName: "origin", ReferenceName: mainBranch}))
+		assert.FileExists(t, filepath.Join(repositoryDir, "main.txt"))
+	})
+}
+
+func TestFetch(t *testing.T) {
+	t.Parallel()
+
+	forEachProvider(t, func(t *testing.T, provider GitProvider) {
+		remoteDir := createTestRemote(t)
+		repositoryDir := cloneTestRemote(t, provider, remoteDir)
+
+		pushToTestRemote(t, remoteDir, "other")
+
+		refSpec := gitconfig.RefSpec("+refs/heads/other:refs/remotes/origin/other")
+		err := provider.Fetch(repositoryDir, &git.FetchOptions{RemoteName: "origin", RefSpecs: []gitconfig.RefSpec{refSpec}})
+		require.NoError(t, err)
+
+		fetched, err := RunGitCommand(repositoryDir, "rev-parse", "refs/remotes/origin/other")
+		require.NoError(t, err)
+		remote, err := RunGitCommand(remoteDir, "rev-parse", "refs/heads/other")
+		require.NoError(t, err)
+		assert.Equal(t, remote, fetched)
+
+		missing := gitconfig.RefSpec("+refs/heads/missing:refs/remotes/origin/missing")
+		err = provider.Fetch(repositoryDir, &git.FetchOptions{RemoteName: "origin", RefSpecs: []gitconfig.RefSpec{missing}})
+		assert.IsType(t, git.NoMatchingRefSpecError{}, err)
+	})
+}
+
+func TestParsePorcelainStatus(t *testing.T) {
+	t.Parallel()
+
+	status := parsePorcelainStatus("M  staged.txt\x00 M modified.txt\x00R  new name.txt\x00old name.txt\x00?? untracked.txt\x00 D deleted.txt\x00")
+
+	assert.Equal(t, &git.FileStatus{Staging: git.Modified, Worktree: git.Unmodified}, status["staged.txt"])
+	assert.Equal(t, &git.FileStatus{Staging: git.Unmodified, Worktree: git.Modified}, status["modified.txt"])
+	assert.Equal(t, &git.FileStatus{Staging: git.Renamed, Worktree: git.Unmodified, Extra: "old name.txt"}, status["new name.txt"])
+	assert.True(t, status.IsUntracked("untracked.txt"))
+	assert.Equal(t, git.Deleted, status["deleted.txt"].Worktree)
+	assert.Len(t, status, 5)
+}
+
+func TestGitCLIProviderRemoteArgs(t *testing.T) {
+	t.Parallel()
+
+	provider := GitCLIProvider{SSHKeyPath: "/keys/id's", CABundlePath: "/certs/ca.pem"}
+
+	configArgs, env := provider.remoteArgs(nil, transport.ProxyOptions{URL: "http://proxy.example.com:3128"})
+	assert.Equal(t, []string{"-c", "http.proxy=http://proxy.example.com:3128", "-c", "http.sslCAInfo=/certs/ca.pem"}, configArgs)
+	assert.Empty(t, env)
+
+	configArgs, env = provider.remoteArgs(&http.BasicAuth{Username: "x-access-token", Password: "secret"}, transport.ProxyOptions{})
+	assert.Contains(t, configArgs, "credential.helper="+credentialHelper)
+	assert.NotContains(t, strings.Join(configArgs, " "), "secret")
+	assert.Equal(t, []string{"GIT_XARGS_USERNAME=x-access-token", "GIT_XARGS_PASSWORD=secret"}, env)
+
+	_, env = provider.remoteArgs(&ssh.PublicKeysCallback{}, transport.ProxyOptions{})
+	assert.Equal(t, []string{`GIT_SSH_COMMAND=ssh -o BatchMode=yes -o StrictHostKeyChecking=yes -o IdentitiesOnly=yes -i '/keys/id'\''s'`}, env)
+}
+
+// TestGitCLIProviderCredentialHelper ensures git answers requests for credentials with the ones in the environment
+func TestGitCLIProviderCredentialHelper(t *testing.T) {
+	t.Parallel()
+
+	configArgs, env := GitCLIProvider{}.remoteArgs(&http.BasicAuth{Username: "x-access-token", Password: "secret"}, transport.ProxyOptions{})
+
+	cmd := exec.Command("git", append(configArgs, "credential", "fill")...)
+	cmd.Env = append(append(os.Environ(), "GIT_TERMINAL_PROMPT=0"), env...)
+	cmd.Stdin = strings.NewReader("protocol=https\nhost=github.com\n\n")
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	assert.Contains(t, string(out), "username=x-access-token\n")
+	assert.Contains(t, string(out), "password=secret\n")
+}
+
+// TestLFSNotSupported ensures providers that can't handle LFS say so, rather than failing in some other way
+func TestLFSNotSupported(t *testing.T) {
+	t.Parallel()
+
+	err := GitProductionProvider{}.LFSPull(t.TempDir(), &git.FetchOptions{})
+	assert.IsType(t, types.LFSNotSupportedErr{}, errors.Unwrap(err))
+
+	if _, lookErr := RunGitCommand("", "lfs", "version"); lookErr == nil {
+		t.Skip("git-lfs is installed")
+	}
+	err = GitCLIProvider{}.LFSPush(t.TempDir(), &git.PushOptions{})
+	assert.Equal(t, types.LFSNotSupportedErr{Reason: "git-lfs is not installed"}, errors.Unwrap(err))
+}
+
+func TestResolveSubmoduleURL(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		remoteURL    string
+		submoduleURL string
+		expected     string
+	}{
+		{"https://github.com/gruntwork-io/app.git", "../lib.git", "https://github.com/gruntwork-io/lib.git"},
+		{"https://github.com/gruntwork-io/app", "../../other-org/lib", "https://github.com/other-org/lib"},
+		{"https://github.com/gruntwork-io/app.git/", "./lib.git", "https://github.com/gruntwork-io/app.git/lib.git"},
+		{"git@github.com:gruntwork-io/app.git", "../lib.git", "git@github.com:gruntwork-io/lib.git"},
+		{"file:///repos/app.git", "../lib.git", "file:///repos/lib.git"},
+		{"/repos/app.git", "../lib.git", "/repos/lib.git"},
+		{"https://github.com/gruntwork-io/app.git", "https://github.com/gruntwork-io/lib.git", "https://github.com/gruntwork-io/lib.git"},
+		{"", "../lib.git", "../lib.git"},
+	}
+
+	for _, testCase := range testCases {
+		assert.Equal(t, testCase.expected, resolveSubmoduleURL(testCase.remoteURL, testCase.submoduleURL), "%+v", testCase)
+	}
+}
+
+// commitSigned commits a change to a clone of a new test remote with the given signer, and returns the clone's path
+func commitSigned(t *testing.T, provider GitProvider, signer git.Signer) string {
+	repositoryDir := cloneTestRemote(t, provider, createTestRemote(t))
+	require.NoError(t, os.WriteFile(filepath.Join(repositoryDir, "README.md"), []byte("signed"), 0644))
+
+	author := &object.Signature{Name: "git-xargs", Email: "git-xargs@example.com", When: time.Now()}
+	_, err := provider.Commit(repositoryDir, "signed commit", &git.CommitOptions{All: true, Author: author, Signer: signer})
+	require.NoError(t, err)
+	return repositoryDir
+}
+
+func TestOpenPGPCommitSigning(t *testing.T) {
+	t.Parallel()
+
+	entity, err := openpgp.NewEntity("git-xargs", "", "git-xargs@example.com", nil)
+	require.NoError(t, err)
+	require.NoError(t, entity.PrivateKey.Encrypt([]byte("passphrase")))
+
+	var privateKey, publicKey bytes.Buffer
+	privateKeyWriter, err := armor.Encode(&privateKey, openpgp.PrivateKeyType, nil)
+	require.NoError(t, err)
+	require.NoError(t, entity.SerializePrivateWithoutSigning(privateKeyWriter, nil))
+	require.NoError(t, privateKeyWriter.Close())
+	publicKeyWriter, err := armor.Encode(&publicKey, openpgp.PublicKeyType, nil)
+	require.NoError(t, err)
+	require.NoError(t, entity.Serialize(publicKeyWriter))
+	require.NoError(t, publicKeyWriter.Close())
+
+	_, err = NewCommitSigner(privateKey.Bytes(), "wrong")
+	require.Error(t, err)
+
+	signer, err := NewCommitSigner(privateKey.Bytes(), "passphrase")
+	require.NoError(t, err)
+
+	forEachProvider(t, func(t *testing.T, provider GitProvider) {
+		repositoryDir := commitSigned(t, provider, signer)
+
+		repo, err := git.PlainOpen(repositoryDir)
+		require.NoError(t, err)
+		head, err := repo.Head()
+		require.NoError(t, err)
+		commit, err := repo.CommitObject(head.Hash())
+		require.NoError(t, err)
+
+		_, err = commit.Verify(publicKey.String())
+		assert.NoError(t, err)
+	})
+}
+
+func TestSSHCommitSigning(t *testing.T) {
+	t.Parallel()
+
+	if _, err := exec.LookPath("ssh-keygen"); err != nil {
+		t.Skip("ssh-keygen is needed to verify SSH signatures")
+	}
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	block, err := cryptossh.MarshalPrivateKey(key, "")
+	require.NoError(t, err)
+
+	signer, err := NewCommitSigner(pem.EncodeToMemory(block), "")
+	require.NoError(t, err)
+
+	sshPublicKey, err := cryptossh.NewPublicKey(key.Public())
+	require.NoError(t, err)
+	allowedSigners := filepath.Join(t.TempDir(), "allowed_signers")
+	require.NoError(t, os.WriteFile(allowedSigners, []byte("git-xargs@example.com "+string(cryptossh.MarshalAuthorizedKey(sshPublicKey))), 0644))
+
+	forEachProvider(t, func(t *testing.T, provider GitProvider) {
+		repositoryDir := commitSigned(t, provider, signer)
+
+		_, err := RunGitCommand(repositoryDir, "-c", "gpg.ssh.allowedSignersFile="+allowedSigners, "verify-commit", "HEAD")
+		assert.NoError(t, err)
+	})
+}
+
+// TestSignCommits ensures each commit on a branch is replaced by a signed copy whose parent is the signed copy of the
+// commit before it
+func TestSignCommits(t *testing.T) {
+	t.Parallel()
+
+	if _, err := exec.LookPath("ssh-keygen"); err != nil {
+		t.Skip("ssh-keygen is needed to verify SSH signatures")
+	}
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	sshSigner, err := cryptossh.NewSignerFromKey(key)
+	require.NoError(t, err)
+	allowedSigners := filepath.Join(t.TempDir(), "allowed_signers")
+	require.NoError(t, os.WriteFile(allowedSigners, []byte("git-xargs@example.com "+string(cryptossh.MarshalAuthorizedKey(sshSigner.PublicKey()))), 0644))
+
+	repositoryDir := cloneTestRemote(t, GitCLIProvider{}, createTestRemote(t))
+	base, err := GitCLIProvider{}.Head(repositoryDir)
+	require.NoError(t, err)
+
+	author := &object.Signature{Name: "git-xargs", Email: "git-xargs@example.com", When: time.Now()}
+	for _, contents := range []string{"first", "second"} {
+		require.NoError(t, os.WriteFile(filepath.Join(repositoryDir, "README.md"), []byte(contents), 0644))
+		_, err := GitCLIProvider{}.Commit(repositoryDir, contents, &git.CommitOptions{All: true, Author: author})
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, SignCommits(repositoryDir, base.Hash(), sshCommitSigner{signer: sshSigner}))
+
+	for _, commit := range []string{"HEAD", "HEAD~1"} {
+		_, err := RunGitCommand(repositoryDir, "-c", "gpg.ssh.allowedSignersFile="+allowedSigners, "verify-commit", commit)
+		assert.NoError(t, err, commit)
+	}
+
+	parent, err := RunGitCommand(repositoryDir, "rev-parse", "HEAD~2")
+	require.NoError(t, err)
+	assert.Equal(t, base.Hash().String(), strings.TrimSpace(parent))
+
+	status, err := GitCLIProvider{}.Status(repositoryDir)
+	require.NoError(t, err)
+	assert.True(t, status.IsClean())
+}
@@ -57,7 +57,8 @@ func setupApp() *cli.App {
 
 	app.Before = initCli
 
-	app.Flags = []cli.Flag{
+	// Generating a bundle or applying a patch works just as a run does, so `generate` and `apply-patch` take the same flags
+	runFlags := []cli.Flag{
 		LogLevelFlag,
 		common.GenericGithubOrgFlag,
 		common.GenericDraftPullRequestFlag,
@@ -69,6 +70,21 @@ func setupApp() *cli.App {
 		common.GenericBranchFlag,
 		common.GenericBaseBranchFlag,
 		common.GenericCommitMessageFlag,
+		common.GenericCommitAuthorNameFlag,
+		common.GenericCommitAuthorEmailFlag,
+		common.GenericSignoffFlag,
+		common.GenericCommitTrailerFlag,
+		common.GenericStageIncludeFlag,
+		common.GenericStageExcludeFlag,
+		common.GenericMaxChangedFilesFlag,
+		common.GenericMaxChangedLinesFlag,
+		common.GenericMaxFileSizeFlag,
+		common.GenericSecretPatternFlag,
+		common.GenericSkipSecretScanFlag,
+		common.GenericValidateCmdFlag,
+		common.GenericValidateTimeoutFlag,
+		common.GenericInteractiveFlag,
+		common.GenericDryRunOutputDirFlag,
 		common.GenericPullRequestTitleFlag,
 		common.GenericPullRequestDescriptionFlag,
 		common.GenericPullRequestReviewersFlag,
@@ -79,10 +95,130 @@ func setupApp() *cli.App {
 		common.GenericMaxConcurrentClonesFlag,
 		common.GenericNoSkipCIFlag,
 		common.GenericKeepClonedRepositoriesFlag,
+		common.GenericGithubAppIDFlag,
+		common.GenericGithubAppPrivateKeyPathFlag,
+		common.GenericGithubAppInstallationIDFlag,
+		common.GenericGitTransportFlag,
+		common.GenericSSHKeyPathFlag,
+		common.GenericSSHKnownHostsPathFlag,
+		common.GenericTokenFileFlag,
+		common.GenericTokenCommandFlag,
+		common.GenericGitCredentialHelperFlag,
+		common.GenericTokenRotationStrategyFlag,
+		common.GenericPreflightCheckFlag,
+		common.GenericGithubHostnameFlag,
+		common.GenericGithubAPIURLFlag,
+		common.GenericGithubUploadURLFlag,
+		common.GenericCABundleFlag,
+		common.GenericProxyFlag,
+		common.GenericCloneDepthFlag,
+		common.GenericSingleBranchFlag,
+		common.GenericSparsePathsFlag,
+		common.GenericRecurseSubmodulesFlag,
+		common.GenericBranchStrategyFlag,
+		common.GenericSignCommitsFlag,
+		common.GenericSigningKeyFlag,
+		common.GenericSigningKeyPassphraseFileFlag,
+		common.GenericCacheDirFlag,
+		common.GenericGitBackendFlag,
 	}
+	// Cherry-picking a commit takes the place of a command, which generating a bundle needs, or of a patch
+	app.Flags = append(append([]cli.Flag{}, runFlags...), common.GenericCherryPickFlag)
 
 	app.Action = cmd.RunGitXargs
 
+	app.Commands = []cli.Command{
+		{
+			Name:      "generate",
+			Usage:     "Run the command against each repo without pushing anything, and write the changes it made to a bundle that git-xargs publish can push later.",
+			ArgsUsage: "<command>",
+			Before:    initCli,
+			Action:    cmd.RunGenerate,
+			Flags:     append(append([]cli.Flag{}, runFlags...), common.GenericBundleDirFlag),
+		},
+		{
+			Name:      "apply-patch",
+			Usage:     "Apply a unified diff or a series written by git format-patch to each repo, instead of running a command.",
+			ArgsUsage: "<patch-file>",
+			Before:    initCli,
+			Action:    cmd.RunApplyPatch,
+			Flags:     append(append([]cli.Flag{}, runFlags...), common.GenericFuzzFlag, common.GenericThreeWayFlag),
+		},
+		{
+			Name:      "publish",
+			Usage:     "Apply each patch in a bundle written by git-xargs generate to a fresh clone of its repo, then push it and open a pull request.",
+			ArgsUsage: "<bundle-dir>",
+			Before:    initCli,
+			Action:    cmd.RunPublish,
+			Flags: []cli.Flag{
+				LogLevelFlag,
+				common.GenericDryRunFlag,
+				common.GenericSkipPullRequestFlag,
+				common.GenericCommitAuthorNameFlag,
+				common.GenericCommitAuthorEmailFlag,
+				common.GenericMaxChangedFilesFlag,
+				common.GenericMaxChangedLinesFlag,
+				common.GenericMaxFileSizeFlag,
+				common.GenericSecretPatternFlag,
+				common.GenericSkipSecretScanFlag,
+				common.GenericValidateCmdFlag,
+				common.GenericValidateTimeoutFlag,
+				common.GenericInteractiveFlag,
+				common.GenericSecondsToWaitFlag,
+				common.GenericMaxPullRequestRetriesFlag,
+				common.GenericSecondsToWaitWhenRateLimitedFlag,
+				common.GenericMaxConcurrentClonesFlag,
+				common.GenericKeepClonedRepositoriesFlag,
+				common.GenericGithubAppIDFlag,
+				common.GenericGithubAppPrivateKeyPathFlag,
+				common.GenericGithubAppInstallationIDFlag,
+				common.GenericGitTransportFlag,
+				common.GenericSSHKeyPathFlag,
+				common.GenericSSHKnownHostsPathFlag,
+				common.GenericTokenFileFlag,
+				common.GenericTokenCommandFlag,
+				common.GenericGitCredentialHelperFlag,
+				common.GenericTokenRotationStrategyFlag,
+				common.GenericPreflightCheckFlag,
+				common.GenericGithubHostnameFlag,
+				common.GenericGithubAPIURLFlag,
+				common.GenericGithubUploadURLFlag,
+				common.GenericCABundleFlag,
+				common.GenericProxyFlag,
+				common.GenericCloneDepthFlag,
+				common.GenericSingleBranchFlag,
+				common.GenericRecurseSubmodulesFlag,
+				common.GenericBranchStrategyFlag,
+				common.GenericSignCommitsFlag,
+				common.GenericSigningKeyFlag,
+				common.GenericSigningKeyPassphraseFileFlag,
+				common.GenericCacheDirFlag,
+				common.GenericGitBackendFlag,
+			},
+		},
+		{
+			Name:  "cache",
+			Usage: "Manage the repos cached via --cache-dir.",
+			Subcommands: []cli.Command{
+				{
+					Name:   "prune",
+					Usage:  "Remove cached repos that haven't been used recently, or that have been deleted, renamed or transferred.",
+					Action: cmd.RunCachePrune,
+					Flags: []cli.Flag{
+						common.GenericCacheDirFlag,
+						common.GenericCacheMaxAgeFlag,
+						common.GenericTokenFileFlag,
+						common.GenericTokenCommandFlag,
+						common.GenericGithubHostnameFlag,
+						common.GenericGithubAPIURLFlag,
+						common.GenericCABundleFlag,
+						common.GenericProxyFlag,
+					},
+				},
+			},
+		},
+	}
+
 	return app
 }
 
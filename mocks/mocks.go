@@ -2,6 +2,7 @@ package mocks
 
 import (
 	"context"
+	"errors"
 	"net/http"
 
 	"github.com/google/go-github/v43/github"
@@ -91,7 +92,10 @@ func (m mockGithubPullRequestService) RequestReviewers(ctx context.Context, owne
 type mockGithubRepositoriesService struct {
 	Repository   *github.Repository
 	Repositories []*github.Repository
-	Response     *github.Response
+	Signatures   *github.SignaturesProtectedBranch
+	// SignaturesStatus, if set, is the status code GitHub refuses to return branch protection with
+	SignaturesStatus int
+	Response         *github.Response
 }
 
 func (m mockGithubRepositoriesService) Get(ctx context.Context, owner, repo string) (*github.Repository, *github.Response, error) {
@@ -102,6 +106,26 @@ func (m mockGithubRepositoriesService) ListByOrg(ctx context.Context, org string
 	return m.Repositories, m.Response, nil
 }
 
+func (m mockGithubRepositoriesService) GetSignaturesProtectedBranch(ctx context.Context, owner, repo, branch string) (*github.SignaturesProtectedBranch, *github.Response, error) {
+	if m.SignaturesStatus != 0 {
+		return nil, &github.Response{Response: &http.Response{StatusCode: m.SignaturesStatus}}, errors.New(http.StatusText(m.SignaturesStatus))
+	}
+	if m.Signatures == nil {
+		return nil, &github.Response{Response: &http.Response{StatusCode: 404}}, errors.New("Branch not protected")
+	}
+	return m.Signatures, m.Response, nil
+}
+
+// This mocks the Users service in go-github that is used in production to call the associated GitHub endpoint
+type mockGithubUsersService struct {
+	User     *github.User
+	Response *github.Response
+}
+
+func (m mockGithubUsersService) Get(ctx context.Context, user string) (*github.User, *github.Response, error) {
+	return m.User, m.Response, nil
+}
+
 // ConfigureMockGithubClient returns a valid GithubClient configured for testing purposes, complete with the mocked services
 func ConfigureMockGithubClient() auth.GithubClient {
 	// Call the same NewClient method that is used by the actual CLI to obtain a GitHub client that calls the
@@ -136,6 +160,40 @@ func ConfigureMockGithubClient() auth.GithubClient {
 		},
 		Response: &github.Response{},
 	}
+	client.Users = mockGithubUsersService{
+		User: &github.User{
+			Login: &ownerName,
+		},
+		Response: &github.Response{
+			Response: &http.Response{
+				StatusCode: 200,
+			},
+		},
+	}
+
+	return client
+}
+
+// ConfigureMockGithubClientRequiringSignedCommits returns a mock GithubClient whose repos' branches require signed
+// commits
+func ConfigureMockGithubClientRequiringSignedCommits() auth.GithubClient {
+	client := ConfigureMockGithubClient()
+
+	repositories := client.Repositories.(mockGithubRepositoriesService)
+	repositories.Signatures = &github.SignaturesProtectedBranch{Enabled: github.Bool(true)}
+	client.Repositories = repositories
+
+	return client
+}
+
+// ConfigureMockGithubClientForbiddingBranchProtection returns a mock GithubClient whose token isn't allowed to read the
+// branch protection of its repos
+func ConfigureMockGithubClientForbiddingBranchProtection() auth.GithubClient {
+	client := ConfigureMockGithubClient()
+
+	repositories := client.Repositories.(mockGithubRepositoriesService)
+	repositories.SignaturesStatus = http.StatusForbidden
+	client.Repositories = repositories
 
 	return client
 }
@@ -11,11 +11,27 @@ import (
 func PrintRepoReport(allEvents []types.AnnotatedEvent, runReport *types.RunReport) {
 	renderSection(fmt.Sprintf("Git-xargs run summary @ %s", time.Now().UTC()))
 
-	pterm.DefaultBulletList.WithItems([]pterm.BulletListItem{
+	summary := []pterm.BulletListItem{
 		{Level: 0, Text: fmt.Sprintf("Runtime in seconds: %d", runReport.RuntimeSeconds)},
-		{Level: 0, Text: fmt.Sprintf("Command supplied: %s", runReport.Command)},
-		{Level: 0, Text: fmt.Sprintf("Repo selection method: %s", runReport.SelectionMode)},
-	}).Render()
+	}
+	if runReport.CherryPick != "" {
+		summary = append(summary, pterm.BulletListItem{Level: 0, Text: fmt.Sprintf("Commit cherry-picked: %s", runReport.CherryPick)})
+	} else if runReport.PatchFile != "" {
+		summary = append(summary, pterm.BulletListItem{Level: 0, Text: fmt.Sprintf("Patch applied: %s", runReport.PatchFile)})
+	} else {
+		summary = append(summary, pterm.BulletListItem{Level: 0, Text: fmt.Sprintf("Command supplied: %s", runReport.Command)})
+	}
+	summary = append(summary, pterm.BulletListItem{Level: 0, Text: fmt.Sprintf("Repo selection method: %s", runReport.SelectionMode)})
+	if runReport.CommitAuthor != "" {
+		summary = append(summary, pterm.BulletListItem{Level: 0, Text: fmt.Sprintf("Commit author: %s", runReport.CommitAuthor)})
+	}
+	if len(runReport.CommitTrailers) > 0 {
+		summary = append(summary, pterm.BulletListItem{Level: 0, Text: "Commit trailers:"})
+		for _, trailer := range runReport.CommitTrailers {
+			summary = append(summary, pterm.BulletListItem{Level: 1, Text: trailer})
+		}
+	}
+	pterm.DefaultBulletList.WithItems(summary).Render()
 
 	if len(runReport.FileProvidedRepos) > 0 {
 		renderSection("Repos supplied via --repos file flag")
@@ -42,6 +58,19 @@ func PrintRepoReport(allEvents []types.AnnotatedEvent, runReport *types.RunRepor
 		if len(reducedRepos) > 0 {
 
 			renderSection(ae.Description)
+
+			// Some events record an explanation of what happened to each repo, which gets its own column
+			details := runReport.Details[ae.Event]
+			if len(details) > 0 {
+				data := make([][]string, len(reducedRepos))
+				for idx, repo := range runReport.Repos[ae.Event] {
+					data[idx] = []string{repo.GetName(), repo.GetHTMLURL(), details[repo.GetFullName()]}
+				}
+
+				renderTableWithHeader([]string{"Repo name", "Repo URL", "Details"}, data)
+				continue
+			}
+
 			data := make([][]string, len(reducedRepos))
 			for idx, repo := range reducedRepos {
 				data[idx] = []string{repo.Name, repo.URL}
@@ -92,6 +121,28 @@ func PrintRepoReport(allEvents []types.AnnotatedEvent, runReport *types.RunRepor
 
 		renderTableWithHeader([]string{"Repo name", "Draft Pull request URL"}, data)
 	}
+
+	if len(runReport.TokenUsage) > 0 {
+		renderSection("API token usage")
+
+		data := make([][]string, len(runReport.TokenUsage))
+		for idx, usage := range runReport.TokenUsage {
+			data[idx] = []string{usage.Token, fmt.Sprintf("%d", usage.Requests), usage.Remaining}
+		}
+
+		renderTableWithHeader([]string{"Token", "API requests", "Rate limit remaining"}, data)
+	}
+
+	if len(runReport.Patches) > 0 {
+		renderSection("Patches written")
+
+		data := make([][]string, len(runReport.Patches))
+		for idx, patch := range runReport.Patches {
+			data[idx] = []string{patch.Repo, fmt.Sprintf("%d", patch.Commits), fmt.Sprintf("%d", patch.Files), fmt.Sprintf("+%d", patch.Additions), fmt.Sprintf("-%d", patch.Deletions), patch.Path}
+		}
+
+		renderTableWithHeader([]string{"Repo name", "Commits", "Files changed", "Lines added", "Lines removed", "Patch"}, data)
+	}
 }
 
 func renderSection(sectionTitle string) {
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/google/go-github/v43/github"
+	"github.com/gruntwork-io/git-xargs/config"
+	"github.com/gruntwork-io/git-xargs/local"
+	"github.com/gruntwork-io/git-xargs/stats"
+	"github.com/gruntwork-io/git-xargs/types"
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/gruntwork-io/go-commons/logging"
+	"github.com/sirupsen/logrus"
+)
+
+// repoKey returns the key a repo's base branch is recorded under in config.RepoBaseBranches
+func repoKey(repo *github.Repository) string {
+	return strings.ToLower(repo.GetOwner().GetLogin() + "/" + repo.GetName())
+}
+
+// explicitBaseBranchName returns the base branch the user asked for the repo, either alongside the repo itself or via
+// --base-branch-name, or an empty string if they didn't ask for one
+func explicitBaseBranchName(config *config.GitXargsConfig, repo *github.Repository) string {
+	if baseBranch, ok := config.RepoBaseBranches[repoKey(repo)]; ok {
+		return baseBranch
+	}
+	return config.BaseBranchName
+}
+
+// baseBranchName returns the branch the repo's changes are made on top of, and pull requests are opened against
+func baseBranchName(config *config.GitXargsConfig, repo *github.Repository) string {
+	if baseBranch := explicitBaseBranchName(config, repo); baseBranch != "" {
+		return baseBranch
+	}
+	return repo.GetDefaultBranch()
+}
+
+// resolveBaseRef returns the reference the local branch is created from. That's the clone's HEAD, unless the user asked
+// for a different base branch, in which case the base branch is fetched and checked out. A repo that doesn't have the
+// base branch is reported, before the supplied command runs, with types.BaseBranchNotFoundErr
+func resolveBaseRef(config *config.GitXargsConfig, repositoryDir string, repo *github.Repository) (*plumbing.Reference, error) {
+	logger := logging.GetLogger("git-xargs")
+
+	head, err := getLocalRepoHeadRef(config, repositoryDir, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	baseBranch := explicitBaseBranchName(config, repo)
+	if baseBranch == "" || head.Name() == plumbing.NewBranchReferenceName(baseBranch) {
+		return head, nil
+	}
+	baseBranchRef := plumbing.NewBranchReferenceName(baseBranch)
+
+	gitAuth, authErr := getGitAuth(config, repo)
+	if authErr != nil {
+		config.Stats.TrackSingle(stats.BranchRemotePullFailed, repo)
+		return nil, errors.WithStackTrace(authErr)
+	}
+
+	fetchErr := fetchRemoteBranch(config, repositoryDir, baseBranchRef, gitAuth, false)
+	if fetchErr == plumbing.ErrReferenceNotFound {
+		logger.WithFields(logrus.Fields{
+			"Repo":        repo.GetName(),
+			"Base branch": baseBranch,
+		}).Debug("Base branch does not exist in repo")
+
+		config.Stats.TrackSingle(stats.BaseBranchTargetInvalidErr, repo)
+		return nil, errors.WithStackTrace(types.BaseBranchNotFoundErr{Branch: baseBranch})
+	}
+	if fetchErr != nil {
+		config.Stats.TrackSingle(stats.BranchRemotePullFailed, repo)
+		return nil, errors.WithStackTrace(fetchErr)
+	}
+
+	baseHash, _, err := remoteTrackingHash(repositoryDir, baseBranchRef)
+	if err != nil {
+		config.Stats.TrackSingle(stats.GetHeadRefFailed, repo)
+		return nil, err
+	}
+
+	// go-git would write out the files a sparse checkout left out, so git checks those out
+	var checkoutErr error
+	if isSparseCheckout(config) {
+		_, checkoutErr = local.RunGitCommand(repositoryDir, "checkout", "--quiet", "--detach", baseHash.String())
+	} else {
+		checkoutErr = config.GitClient.Checkout(repositoryDir, &git.CheckoutOptions{Hash: baseHash})
+	}
+	if checkoutErr != nil {
+		config.Stats.TrackSingle(stats.BranchCheckoutFailed, repo)
+		return nil, errors.WithStackTrace(checkoutErr)
+	}
+
+	return plumbing.NewHashReference(baseBranchRef, baseHash), nil
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gruntwork-io/git-xargs/local"
+	"github.com/gruntwork-io/git-xargs/stats"
+	"github.com/gruntwork-io/git-xargs/types"
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBaseBranchName(t *testing.T) {
+	t.Parallel()
+
+	testConfig, repo := newPartialCloneTestConfig("", local.GitProductionProvider{}, "new-branch")
+	assert.Equal(t, repo.GetDefaultBranch(), baseBranchName(testConfig, repo))
+
+	testConfig.BaseBranchName = "develop"
+	assert.Equal(t, "develop", baseBranchName(testConfig, repo))
+
+	testConfig.RepoBaseBranches[repoKey(repo)] = "release"
+	assert.Equal(t, "release", baseBranchName(testConfig, repo))
+}
+
+// TestBranchFromBaseBranch ensures the local branch is created from the requested base branch rather than the clone's
+// HEAD, including in partial clones that didn't fetch the base branch
+func TestBranchFromBaseBranch(t *testing.T) {
+	t.Parallel()
+
+	forEachGitBackend(t, func(t *testing.T, provider local.GitProvider) {
+		remoteURL, branchTip := createTestRemote(t)
+		testConfig, repo := newPartialCloneTestConfig(remoteURL, provider, "new-branch")
+		testConfig.RepoBaseBranches[repoKey(repo)] = "existing-branch"
+
+		repositoryDir, err := cloneLocalRepository(testConfig, repo)
+		require.NoError(t, err)
+		t.Cleanup(func() { os.RemoveAll(repositoryDir) })
+
+		ref, err := resolveBaseRef(testConfig, repositoryDir, repo)
+		require.NoError(t, err)
+		assert.Equal(t, "refs/heads/existing-branch", ref.Name().String())
+		assert.Equal(t, branchTip, ref.Hash().String())
+
+		_, err = checkoutLocalBranch(testConfig, ref, repositoryDir, repo)
+		require.NoError(t, err)
+
+		head, err := local.RunGitCommand(repositoryDir, "rev-parse", "HEAD")
+		require.NoError(t, err)
+		assert.Equal(t, branchTip, strings.TrimSpace(head))
+		assert.NoDirExists(t, filepath.Join(repositoryDir, "docs"))
+	})
+}
+
+// TestMissingBaseBranch ensures a repo that doesn't have the requested base branch is reported before the command runs
+func TestMissingBaseBranch(t *testing.T) {
+	t.Parallel()
+
+	forEachGitBackend(t, func(t *testing.T, provider local.GitProvider) {
+		remoteURL, _ := createTestRemote(t)
+		testConfig, repo := newPartialCloneTestConfig(remoteURL, provider, "new-branch")
+		testConfig.BaseBranchName = "does-not-exist"
+
+		repositoryDir, err := cloneLocalRepository(testConfig, repo)
+		require.NoError(t, err)
+		t.Cleanup(func() { os.RemoveAll(repositoryDir) })
+
+		_, err = resolveBaseRef(testConfig, repositoryDir, repo)
+		require.Error(t, err)
+		assert.IsType(t, types.BaseBranchNotFoundErr{}, errors.Unwrap(err))
+		assert.Contains(t, testConfig.Stats.GetRepos()[stats.BaseBranchTargetInvalidErr], repo)
+	})
+}
@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/google/go-github/v43/github"
+	"github.com/gruntwork-io/git-xargs/common"
+	"github.com/gruntwork-io/git-xargs/config"
+	"github.com/gruntwork-io/git-xargs/local"
+	"github.com/gruntwork-io/git-xargs/stats"
+	"github.com/gruntwork-io/git-xargs/types"
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/gruntwork-io/go-commons/logging"
+	"github.com/sirupsen/logrus"
+)
+
+// rewritesBranch returns true if the branch strategy replaces an existing branch, rather than adding commits on top of
+// it. A repo's default and base branches are never rewritten, even when one is the branch --skip-pull-requests commits to
+func rewritesBranch(config *config.GitXargsConfig, remoteRepository *github.Repository) bool {
+	if config.BranchName == remoteRepository.GetDefaultBranch() || config.BranchName == baseBranchName(config, remoteRepository) {
+		return false
+	}
+	return config.BranchStrategy == common.BranchStrategyRebase || config.BranchStrategy == common.BranchStrategyRecreate
+}
+
+// rebuildLocalBranch prepares the local branch, which starts at the latest base branch, to replace the branch of the
+// same name on origin, if there is one. With the recreate strategy, the branch is left as it is. With the rebase
+// strategy, the commits on origin's branch are replayed on top of it, and commits whose changes the base branch
+// already contains are dropped. Either way, origin's branch is fetched, so that pushLocalBranch knows which commit it
+// expects to replace
+func rebuildLocalBranch(config *config.GitXargsConfig, repositoryDir string, remoteRepository *github.Repository, branchName plumbing.ReferenceName, base *plumbing.Reference, gitAuth transport.AuthMethod) error {
+	logger := logging.GetLogger("git-xargs")
+
+	rebase := config.BranchStrategy == common.BranchStrategyRebase
+
+	// Rebasing needs the history the branch shares with the base branch, which a partial clone may not contain
+	fetchErr := fetchRemoteBranch(config, repositoryDir, branchName, gitAuth, rebase && isPartialClone(config))
+	if fetchErr == nil && rebase && isPartialClone(config) {
+		fetchErr = fetchRemoteBranch(config, repositoryDir, base.Name(), gitAuth, true)
+	}
+
+	if fetchErr == plumbing.ErrReferenceNotFound {
+		config.Stats.TrackSingle(stats.BranchRemoteDidntExistYet, remoteRepository)
+		return nil
+	}
+	if fetchErr != nil {
+		config.Stats.TrackSingle(stats.BranchRemotePullFailed, remoteRepository)
+		return errors.WithStackTrace(fetchErr)
+	}
+
+	if !rebase {
+		config.Stats.TrackSingle(stats.BranchRecreated, remoteRepository)
+		return nil
+	}
+
+	remoteBranch := plumbing.NewRemoteReferenceName("origin", branchName.Short())
+	if _, err := local.RunGitCommand(repositoryDir, "reset", "--quiet", "--hard", remoteBranch.String()); err != nil {
+		config.Stats.TrackSingle(stats.BranchRemotePullFailed, remoteRepository)
+		return err
+	}
+
+	rebaseErr := rebaseLocalBranch(config, repositoryDir, branchName, base.Hash())
+	if rebaseErr != nil {
+		logger.WithFields(logrus.Fields{
+			"Error": rebaseErr,
+			"Repo":  remoteRepository.GetName(),
+		}).Debug("Error rebasing branch onto the latest base branch")
+
+		if conflict, ok := errors.Unwrap(rebaseErr).(types.BranchRebaseConflictErr); ok {
+			config.Stats.TrackSingleWithDetail(stats.BranchRebaseConflict, remoteRepository, conflict.Stderr)
+		} else {
+			config.Stats.TrackSingle(stats.BranchRemotePullFailed, remoteRepository)
+		}
+		return rebaseErr
+	}
+
+	config.Stats.TrackSingle(stats.BranchRebased, remoteRepository)
+	return nil
+}
+
+// rebaseLocalBranch replays the commits of the current branch that the base doesn't contain on top of the base. go-git
+// can't rebase, so git does. If the commits conflict with the base, the rebase is aborted and
+// types.BranchRebaseConflictErr is returned
+func rebaseLocalBranch(config *config.GitXargsConfig, repositoryDir string, branchName plumbing.ReferenceName, base plumbing.Hash) error {
+	// The replayed commits keep their authors, but are committed by whoever git-xargs commits as
+	args := append(committerArgs(config), "rebase", "--quiet", base.String())
+
+	_, err := local.RunGitCommand(repositoryDir, args...)
+	if err == nil {
+		// Replaying the commits drops their signatures, which git can't replace with the key git-xargs signs with
+		if config.CommitSigner != nil {
+			return local.SignCommits(repositoryDir, base, config.CommitSigner)
+		}
+		return nil
+	}
+
+	if !rebaseInProgress(repositoryDir) {
+		return err
+	}
+
+	if _, abortErr := local.RunGitCommand(repositoryDir, "rebase", "--abort"); abortErr != nil {
+		return abortErr
+	}
+
+	stderr := err.Error()
+	if failure, ok := errors.Unwrap(err).(types.GitCommandFailedErr); ok {
+		stderr = failure.Stderr
+	}
+	return errors.WithStackTrace(types.BranchRebaseConflictErr{Branch: branchName.Short(), Stderr: stderr})
+}
+
+// rebaseInProgress returns true if a rebase stopped partway through, which git does when it hits a conflict
+func rebaseInProgress(repositoryDir string) bool {
+	for _, dir := range []string{"rebase-merge", "rebase-apply"} {
+		if _, err := os.Stat(filepath.Join(repositoryDir, ".git", dir)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// remoteTrackingHash returns the commit origin's copy of the given branch was at when it was last fetched, and false if
+// it wasn't fetched
+func remoteTrackingHash(repositoryDir string, branchName plumbing.ReferenceName) (plumbing.Hash, bool, error) {
+	localRepository, err := git.PlainOpen(repositoryDir)
+	if err != nil {
+		return plumbing.ZeroHash, false, errors.WithStackTrace(err)
+	}
+
+	ref, err := localRepository.Reference(plumbing.NewRemoteReferenceName("origin", branchName.Short()), true)
+	if err == plumbing.ErrReferenceNotFound {
+		return plumbing.ZeroHash, false, nil
+	}
+	if err != nil {
+		return plumbing.ZeroHash, false, errors.WithStackTrace(err)
+	}
+	return ref.Hash(), true, nil
+}
+
+// configureBranchReplacement sets the push options up to replace origin's branch with the local one, but only if
+// origin's branch is still at the commit it was at when it was fetched, so that commits pushed to it since are not lost
+func configureBranchReplacement(repositoryDir string, branchName plumbing.ReferenceName, po *git.PushOptions) error {
+	lease, fetched, err := remoteTrackingHash(repositoryDir, branchName)
+	if err != nil || !fetched {
+		return err
+	}
+
+	// A lease takes the place of the fast-forward check, whereas a forced refspec would skip the lease altogether
+	po.RefSpecs = []gitconfig.RefSpec{gitconfig.RefSpec(branchName.String() + ":" + branchName.String())}
+	po.ForceWithLease = &git.ForceWithLease{RefName: branchName, Hash: lease}
+	return nil
+}
+
+// rewrittenBranchNeedsPush returns true if origin's branch must be replaced even though the supplied command made no
+// further changes. With the rebase strategy, that's when the rebase left the local branch with commits of its own that
+// origin's branch doesn't have yet. With the recreate strategy, the local branch is the base branch's tip, which
+// origin's branch is reset to, rather than left with the changes of an earlier run
+func rewrittenBranchNeedsPush(config *config.GitXargsConfig, repositoryDir string, remoteRepository *github.Repository, base *plumbing.Reference) (bool, error) {
+	if !rewritesBranch(config, remoteRepository) {
+		return false, nil
+	}
+
+	head, err := config.GitClient.Head(repositoryDir)
+	if err != nil {
+		return false, errors.WithStackTrace(err)
+	}
+
+	lease, fetched, err := remoteTrackingHash(repositoryDir, head.Name())
+	if err != nil || !fetched {
+		return false, err
+	}
+
+	if head.Hash() == lease {
+		return false, nil
+	}
+	return config.BranchStrategy == common.BranchStrategyRecreate || head.Hash() != base.Hash(), nil
+}
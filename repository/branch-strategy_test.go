@@ -0,0 +1,186 @@
+package repository
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/gruntwork-io/git-xargs/common"
+	"github.com/gruntwork-io/git-xargs/local"
+	"github.com/gruntwork-io/git-xargs/stats"
+	"github.com/gruntwork-io/git-xargs/types"
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// commitToTestRemote writes the given file on the given branch of the remote created by createTestRemote, from a
+// separate clone, and commits it as the given author, such as "Jane Doe <jane@example.com>", or as the default git
+// identity if the author is empty. Each of the later files is then written and committed on top, so that the first
+// commit isn't the branch's tip. The commits are pushed, and the first of them is returned
+func commitToTestRemote(t *testing.T, remoteURL string, branch string, author string, file string, contents string, laterFiles ...string) string {
+	script := `
+set -e
+git clone -q --branch "$BRANCH" "$REMOTE" .
+author_args=()
+if [ -n "$AUTHOR" ]; then
+  author_args=(--author "$AUTHOR")
+fi
+commit_file() {
+  mkdir -p "$(dirname "$1")"
+  echo "$2" > "$1"
+  git add -A
+  git commit -q "${author_args[@]}" -m "update $1" -m "Changed by the test."
+}
+commit_file "$FILE" "$CONTENTS"
+first=$(git rev-parse HEAD)
+for later in $LATER_FILES; do
+  commit_file "$later" "later"
+done
+git push -q origin "$BRANCH"
+echo "$first"
+`
+	cmd := exec.Command("bash", "-c", script)
+	cmd.Dir = t.TempDir()
+	cmd.Env = append(os.Environ(), "REMOTE="+remoteURL, "BRANCH="+branch, "AUTHOR="+author, "FILE="+file, "CONTENTS="+contents, "LATER_FILES="+strings.Join(laterFiles, " "))
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	return lines[len(lines)-1]
+}
+
+func remoteBranchTip(t *testing.T, remoteURL string, branch string) string {
+	out, err := local.RunGitCommand("", "ls-remote", remoteURL, "refs/heads/"+branch)
+	require.NoError(t, err)
+	return strings.Fields(out)[0]
+}
+
+// TestBranchStrategyRecreate ensures the existing branch is started over from the latest default branch, and that it's
+// only replaced if nobody pushed to it in the meantime
+func TestBranchStrategyRecreate(t *testing.T) {
+	t.Parallel()
+
+	forEachGitBackend(t, func(t *testing.T, provider local.GitProvider) {
+		remoteURL, _ := createTestRemote(t)
+		testConfig, repo := newCloneTestConfig(remoteURL, provider, "existing-branch")
+		testConfig.BranchStrategy = common.BranchStrategyRecreate
+		mainTip := commitToTestRemote(t, remoteURL, "main", "", "modules/new.tf", "new")
+
+		repositoryDir := cloneAndCheckoutTestBranch(t, testConfig, repo)
+		assert.NoFileExists(t, filepath.Join(repositoryDir, "docs", "branch.md"))
+		assert.Len(t, testConfig.Stats.GetRepos()[stats.BranchRecreated], 1)
+
+		require.NoError(t, os.WriteFile(filepath.Join(repositoryDir, "modules", "main.tf"), []byte("recreated"), 0644))
+		commitTestChanges(t, testConfig, repositoryDir, repo)
+
+		// Someone else pushes to the branch before git-xargs does
+		otherTip := commitToTestRemote(t, remoteURL, "existing-branch", "", "docs/other.md", "other")
+		require.Error(t, pushLocalBranch(testConfig, repo, repositoryDir, false, nil))
+		assert.Equal(t, otherTip, remoteBranchTip(t, remoteURL, "existing-branch"))
+
+		repositoryDir = cloneAndCheckoutTestBranch(t, testConfig, repo)
+		require.NoError(t, os.WriteFile(filepath.Join(repositoryDir, "modules", "main.tf"), []byte("recreated"), 0644))
+		commitTestChanges(t, testConfig, repositoryDir, repo)
+		require.NoError(t, pushLocalBranch(testConfig, repo, repositoryDir, false, nil))
+
+		parent, err := local.RunGitCommand(repositoryDir, "rev-parse", remoteBranchTip(t, remoteURL, "existing-branch")+"^")
+		require.NoError(t, err)
+		assert.Equal(t, mainTip, strings.TrimSpace(parent))
+	})
+}
+
+// TestBranchStrategyRecreateWithoutChanges ensures the existing branch is reset to the latest default branch if the
+// command makes no changes, rather than left with the changes of an earlier run, and that no pull request is opened
+func TestBranchStrategyRecreateWithoutChanges(t *testing.T) {
+	t.Parallel()
+
+	forEachGitBackend(t, func(t *testing.T, provider local.GitProvider) {
+		remoteURL, _ := createTestRemote(t)
+		testConfig, repo := newCloneTestConfig(remoteURL, provider, "existing-branch")
+		testConfig.BranchStrategy = common.BranchStrategyRecreate
+		testConfig.SkipPullRequests = false
+		mainTip := commitToTestRemote(t, remoteURL, "main", "", "modules/new.tf", "new")
+
+		repositoryDir := cloneAndCheckoutTestBranch(t, testConfig, repo)
+		beforeCommand, err := getLocalRepoHeadRef(testConfig, repositoryDir, repo)
+		require.NoError(t, err)
+		assert.Equal(t, mainTip, beforeCommand.Hash().String())
+
+		require.NoError(t, updateRepo(testConfig, repositoryDir, repo, "refs/heads/existing-branch", beforeCommand, beforeCommand, false))
+		assert.Equal(t, mainTip, remoteBranchTip(t, remoteURL, "existing-branch"))
+		assert.Len(t, testConfig.Stats.GetRepos()[stats.BranchResetToBase], 1)
+		assert.Empty(t, testConfig.Stats.GetPullRequests())
+		assert.Empty(t, testConfig.Stats.GetRepos()[stats.PullRequestOpenErr])
+
+		// Once reset, there's nothing left to push
+		repositoryDir = cloneAndCheckoutTestBranch(t, testConfig, repo)
+		needsPush, err := rewrittenBranchNeedsPush(testConfig, repositoryDir, repo, beforeCommand)
+		require.NoError(t, err)
+		assert.False(t, needsPush)
+	})
+}
+
+// TestBranchStrategyRebase ensures the commits on the existing branch are replayed on top of the latest default branch,
+// and that the rebased branch is pushed even if the command makes no further changes
+func TestBranchStrategyRebase(t *testing.T) {
+	t.Parallel()
+
+	forEachGitBackend(t, func(t *testing.T, provider local.GitProvider) {
+		remoteURL, _ := createTestRemote(t)
+		testConfig, repo := newCloneTestConfig(remoteURL, provider, "existing-branch")
+		testConfig.BranchStrategy = common.BranchStrategyRebase
+		mainTip := commitToTestRemote(t, remoteURL, "main", "", "modules/new.tf", "new")
+
+		repositoryDir := cloneAndCheckoutTestBranch(t, testConfig, repo)
+		assert.FileExists(t, filepath.Join(repositoryDir, "docs", "branch.md"))
+		assert.FileExists(t, filepath.Join(repositoryDir, "modules", "new.tf"))
+		assert.Len(t, testConfig.Stats.GetRepos()[stats.BranchRebased], 1)
+
+		base, err := local.RunGitCommand(repositoryDir, "rev-parse", "HEAD~2")
+		require.NoError(t, err)
+		assert.Equal(t, mainTip, strings.TrimSpace(base))
+
+		head, err := testConfig.GitClient.Head(repositoryDir)
+		require.NoError(t, err)
+		assert.Equal(t, "refs/heads/existing-branch", head.Name().String())
+
+		mainRef := plumbing.NewHashReference(plumbing.NewBranchReferenceName("main"), plumbing.NewHash(mainTip))
+		needsPush, err := rewrittenBranchNeedsPush(testConfig, repositoryDir, repo, mainRef)
+		require.NoError(t, err)
+		assert.True(t, needsPush)
+
+		require.NoError(t, pushLocalBranch(testConfig, repo, repositoryDir, false, nil))
+		assert.Equal(t, head.Hash().String(), remoteBranchTip(t, remoteURL, "existing-branch"))
+	})
+}
+
+// TestBranchStrategyRebaseConflict ensures a branch that conflicts with the latest default branch is reported, and
+// left unchanged
+func TestBranchStrategyRebaseConflict(t *testing.T) {
+	t.Parallel()
+
+	forEachGitBackend(t, func(t *testing.T, provider local.GitProvider) {
+		remoteURL, branchTip := createTestRemote(t)
+		testConfig, repo := newCloneTestConfig(remoteURL, provider, "existing-branch")
+		testConfig.BranchStrategy = common.BranchStrategyRebase
+		commitToTestRemote(t, remoteURL, "main", "", "docs/branch.md", "conflicting")
+
+		repositoryDir, err := cloneLocalRepository(testConfig, repo)
+		require.NoError(t, err)
+		t.Cleanup(func() { os.RemoveAll(repositoryDir) })
+
+		ref, err := getLocalRepoHeadRef(testConfig, repositoryDir, repo)
+		require.NoError(t, err)
+		_, err = checkoutLocalBranch(testConfig, ref, repositoryDir, repo)
+		require.Error(t, err)
+		assert.IsType(t, types.BranchRebaseConflictErr{}, errors.Unwrap(err))
+
+		assert.Len(t, testConfig.Stats.GetRepos()[stats.BranchRebaseConflict], 1)
+		assert.False(t, rebaseInProgress(repositoryDir))
+		assert.Equal(t, branchTip, remoteBranchTip(t, remoteURL, "existing-branch"))
+	})
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/google/go-github/v43/github"
+	"github.com/gruntwork-io/git-xargs/bundle"
+	"github.com/gruntwork-io/git-xargs/config"
+	"github.com/gruntwork-io/git-xargs/stats"
+	"github.com/gruntwork-io/git-xargs/types"
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/gruntwork-io/go-commons/logging"
+	"github.com/sirupsen/logrus"
+)
+
+// applyBundlePatch applies the repo's patch from the bundle being published, in place of running the command that
+// made it. The patch is applied on top of the branch as it is now, which may have moved on since the bundle was
+// generated. A patch that no longer applies is reported with types.PatchDidNotApplyErr, and the repo is left as it was
+func applyBundlePatch(config *config.GitXargsConfig, repositoryDir string, remoteRepository *github.Repository, beforeCommand *plumbing.Reference) error {
+	logger := logging.GetLogger("git-xargs")
+
+	bundleRepo, ok := config.Bundle.Repo(remoteRepository.GetOwner().GetLogin(), remoteRepository.GetName())
+	if !ok {
+		config.Stats.TrackSingleWithDetail(stats.PatchDidNotApply, remoteRepository, "not in the bundle")
+		return errors.WithStackTrace(types.RepoNotInBundleErr{Repo: remoteRepository.GetFullName()})
+	}
+
+	// If the branch moved on, the patch may still apply, but whoever publishes the bundle should know it's not quite
+	// what was reviewed
+	var moved string
+	if beforeCommand.Hash().String() != bundleRepo.BaseCommit {
+		moved = fmt.Sprintf("%s moved from %s to %s", bundleRepo.BaseBranch, shortHash(bundleRepo.BaseCommit), shortHash(beforeCommand.Hash().String()))
+	}
+
+	patchPath, err := filepath.Abs(bundle.PatchPath(config.BundleDir, bundleRepo))
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+
+	if rejected, applyErr := applyPatch(config, repositoryDir, patchPath); applyErr != nil {
+		logger.WithFields(logrus.Fields{
+			"Error": applyErr,
+			"Repo":  remoteRepository.GetName(),
+			"Patch": patchPath,
+		}).Debug("Error applying the repo's patch from the bundle")
+
+		detail := patchFailureSummary(applyErr, rejected)
+		if moved != "" {
+			detail = moved + " | " + detail
+		}
+		config.Stats.TrackSingleWithDetail(stats.PatchDidNotApply, remoteRepository, detail)
+		return errors.WithStackTrace(types.PatchDidNotApplyErr{Patch: bundleRepo.Patch, Underlying: applyErr})
+	}
+
+	if moved != "" {
+		config.Stats.TrackSingleWithDetail(stats.BundleBaseMoved, remoteRepository, moved)
+	}
+	return nil
+}
+
+// shortHash abbreviates the given commit hash as git does
+func shortHash(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v43/github"
+	"github.com/gruntwork-io/git-xargs/bundle"
+	"github.com/gruntwork-io/git-xargs/config"
+	"github.com/gruntwork-io/git-xargs/local"
+	"github.com/gruntwork-io/git-xargs/stats"
+	"github.com/gruntwork-io/git-xargs/types"
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// generateTestBundle runs a command against the test remote as `git-xargs generate` would, and returns the directory
+// of the bundle it wrote, along with the tree of the branch it would have pushed
+func generateTestBundle(t *testing.T, remoteURL string, provider local.GitProvider) (string, string) {
+	testConfig, repo := newCloneTestConfig(remoteURL, provider, "published")
+	testConfig.DryRun = true
+	testConfig.GeneratingBundle = true
+	testConfig.DryRunOutputDir = t.TempDir()
+
+	repositoryDir := cloneAndCheckoutTestBranch(t, testConfig, repo)
+	beforeCommand, err := getLocalRepoHeadRef(testConfig, repositoryDir, repo)
+	require.NoError(t, err)
+
+	// The command isn't told it's a dry run, as the changes it makes are published later. It changes a binary file too,
+	// whose contents the bundle must carry
+	testConfig.Args = []string{"bash", "-c", `echo "$XARGS_DRY_RUN" > modules/main.tf && printf '\x00\x01\x02' > modules/plugin.bin`}
+	require.NoError(t, executeCommand(testConfig, repositoryDir, repo))
+	require.NoError(t, updateRepo(testConfig, repositoryDir, repo, "refs/heads/published", beforeCommand, beforeCommand, false))
+
+	patches := testConfig.Stats.GetPatches()
+	require.Len(t, patches, 1)
+	manifest := &bundle.Manifest{
+		Command:    testConfig.Args,
+		BranchName: testConfig.BranchName,
+		Repos: []bundle.Repo{
+			{Owner: "gruntwork-io", Name: "terragrunt", BaseBranch: "main", BaseCommit: patches[0].BaseCommit, Patch: "gruntwork-io/terragrunt.patch"},
+		},
+	}
+	require.NoError(t, manifest.Save(testConfig.DryRunOutputDir))
+
+	tree, err := local.RunGitCommand(repositoryDir, "rev-parse", "HEAD^{tree}")
+	require.NoError(t, err)
+	return testConfig.DryRunOutputDir, strings.TrimSpace(tree)
+}
+
+// newPublishTestConfig returns the config of a run publishing the given bundle to the test remote
+func newPublishTestConfig(t *testing.T, remoteURL string, provider local.GitProvider, bundleDir string) (*config.GitXargsConfig, *github.Repository) {
+	manifest, err := bundle.Load(bundleDir)
+	require.NoError(t, err)
+
+	testConfig, repo := newCloneTestConfig(remoteURL, provider, manifest.BranchName)
+	testConfig.Bundle = manifest
+	testConfig.BundleDir = bundleDir
+	return testConfig, repo
+}
+
+// TestPublishBundle ensures publishing a bundle pushes the same changes the command made when it was generated,
+// including changes to binary files
+func TestPublishBundle(t *testing.T) {
+	t.Parallel()
+
+	forEachGitBackend(t, func(t *testing.T, provider local.GitProvider) {
+		remoteURL, _ := createTestRemote(t)
+		bundleDir, generatedTree := generateTestBundle(t, remoteURL, provider)
+
+		testConfig, repo := newPublishTestConfig(t, remoteURL, provider, bundleDir)
+		repositoryDir := cloneAndCheckoutTestBranch(t, testConfig, repo)
+		beforeCommand, err := getLocalRepoHeadRef(testConfig, repositoryDir, repo)
+		require.NoError(t, err)
+
+		require.NoError(t, applyBundlePatch(testConfig, repositoryDir, repo, beforeCommand))
+		require.NoError(t, updateRepo(testConfig, repositoryDir, repo, "refs/heads/published", beforeCommand, beforeCommand, false))
+
+		publishedTree, err := local.RunGitCommand(repositoryDir, "rev-parse", remoteBranchTip(t, remoteURL, "published")+"^{tree}")
+		require.NoError(t, err)
+		assert.Equal(t, generatedTree, strings.TrimSpace(publishedTree))
+
+		contents, err := local.RunGitCommand(repositoryDir, "show", "HEAD:modules/main.tf")
+		require.NoError(t, err)
+		assert.Equal(t, "false", strings.TrimSpace(contents))
+		binary, err := os.ReadFile(filepath.Join(repositoryDir, "modules", "plugin.bin"))
+		require.NoError(t, err)
+		assert.Equal(t, []byte{0, 1, 2}, binary)
+		assert.Empty(t, testConfig.Stats.GetRepos()[stats.BundleBaseMoved])
+		assert.Empty(t, testConfig.Stats.GetRepos()[stats.PatchDidNotApply])
+	})
+}
+
+// TestPublishBundleAfterBaseMoved ensures a patch is still published if the base branch moved on without touching the
+// files it changes, and that a repo whose patch no longer applies is reported and left as it was
+func TestPublishBundleAfterBaseMoved(t *testing.T) {
+	t.Parallel()
+
+	forEachGitBackend(t, func(t *testing.T, provider local.GitProvider) {
+		remoteURL, _ := createTestRemote(t)
+		bundleDir, _ := generateTestBundle(t, remoteURL, provider)
+
+		commitToTestRemote(t, remoteURL, "main", "", "docs/moved.md", "moved")
+		testConfig, repo := newPublishTestConfig(t, remoteURL, provider, bundleDir)
+		repositoryDir := cloneAndCheckoutTestBranch(t, testConfig, repo)
+		beforeCommand, err := getLocalRepoHeadRef(testConfig, repositoryDir, repo)
+		require.NoError(t, err)
+
+		require.NoError(t, applyBundlePatch(testConfig, repositoryDir, repo, beforeCommand))
+		assert.Contains(t, testConfig.Stats.GetDetail(stats.BundleBaseMoved, repo), "main moved from ")
+
+		commitToTestRemote(t, remoteURL, "main", "", "modules/main.tf", "conflict")
+		testConfig, repo = newPublishTestConfig(t, remoteURL, provider, bundleDir)
+		repositoryDir = cloneAndCheckoutTestBranch(t, testConfig, repo)
+		beforeCommand, err = getLocalRepoHeadRef(testConfig, repositoryDir, repo)
+		require.NoError(t, err)
+
+		applyErr := applyBundlePatch(testConfig, repositoryDir, repo, beforeCommand)
+		require.Error(t, applyErr)
+		assert.IsType(t, types.PatchDidNotApplyErr{}, errors.Unwrap(applyErr))
+		assert.Contains(t, testConfig.Stats.GetDetail(stats.PatchDidNotApply, repo), "main moved from ")
+		assert.Contains(t, testConfig.Stats.GetDetail(stats.PatchDidNotApply, repo), "rejected modules/main.tf hunk #1")
+
+		head, err := getLocalRepoHeadRef(testConfig, repositoryDir, repo)
+		require.NoError(t, err)
+		assert.Equal(t, beforeCommand.Hash(), head.Hash())
+		status, err := local.RunGitCommand(repositoryDir, "status", "--porcelain")
+		require.NoError(t, err)
+		assert.Empty(t, strings.TrimSpace(status))
+		assert.NoDirExists(t, filepath.Join(repositoryDir, ".git", "rebase-apply"))
+	})
+}
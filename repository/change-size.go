@@ -0,0 +1,239 @@
+package repository
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/utils/binary"
+	"github.com/go-git/go-git/v5/utils/diff"
+	"github.com/google/go-github/v43/github"
+	"github.com/gruntwork-io/git-xargs/config"
+	"github.com/gruntwork-io/git-xargs/stats"
+	"github.com/gruntwork-io/git-xargs/types"
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/gruntwork-io/go-commons/logging"
+	"github.com/sergi/go-diff/diffmatchpatch"
+	"github.com/sirupsen/logrus"
+)
+
+// changeSize is how large the supplied command's changes to a repo are
+type changeSize struct {
+	files           int
+	lines           int
+	largestFile     string
+	largestFileSize int64
+}
+
+// hasChangeSizeLimits returns true if any of --max-changed-files, --max-changed-lines or --max-file-size were passed
+func hasChangeSizeLimits(config *config.GitXargsConfig) bool {
+	return config.MaxChangedFiles > 0 || config.MaxChangedLines > 0 || config.MaxFileSize > 0
+}
+
+// checkChangeSize measures the supplied command's changes, both the ones about to be staged and any commits it made
+// itself, against --max-changed-files, --max-changed-lines and --max-file-size. A repo whose changes exceed any of them
+// is reported with types.ChangeTooLargeErr, before anything is committed or pushed
+func checkChangeSize(config *config.GitXargsConfig, repositoryDir string, remoteRepository *github.Repository, beforeCommand *plumbing.Reference, staged []string) error {
+	if !hasChangeSizeLimits(config) {
+		return nil
+	}
+
+	logger := logging.GetLogger("git-xargs")
+
+	size, err := measureChanges(repositoryDir, beforeCommand, staged)
+	if err != nil {
+		config.Stats.TrackSingle(stats.WorktreeStatusCheckFailedCommand, remoteRepository)
+		return err
+	}
+
+	var exceeded []string
+	if config.MaxChangedFiles > 0 && size.files > config.MaxChangedFiles {
+		exceeded = append(exceeded, fmt.Sprintf("%d files changed, limit %d", size.files, config.MaxChangedFiles))
+	}
+	if config.MaxChangedLines > 0 && size.lines > config.MaxChangedLines {
+		exceeded = append(exceeded, fmt.Sprintf("%d lines changed, limit %d", size.lines, config.MaxChangedLines))
+	}
+	if config.MaxFileSize > 0 && size.largestFileSize > config.MaxFileSize {
+		exceeded = append(exceeded, fmt.Sprintf("%s is %d bytes, limit %d", size.largestFile, size.largestFileSize, config.MaxFileSize))
+	}
+	if len(exceeded) == 0 {
+		return nil
+	}
+
+	detail := strings.Join(exceeded, "; ")
+	logger.WithFields(logrus.Fields{
+		"Repo":    remoteRepository.GetName(),
+		"Exceeds": detail,
+	}).Debug("Command's changes are too large, will not commit or push them")
+
+	config.Stats.TrackSingleWithDetail(stats.ChangeTooLarge, remoteRepository, detail)
+	return errors.WithStackTrace(types.ChangeTooLargeErr{Detail: detail})
+}
+
+// measureChanges compares the given files in the worktree, and the files changed by any commits the supplied command
+// made, to how they were before the command ran
+func measureChanges(repositoryDir string, beforeCommand *plumbing.Reference, staged []string) (changeSize, error) {
+	localRepository, err := git.PlainOpen(repositoryDir)
+	if err != nil {
+		return changeSize{}, errors.WithStackTrace(err)
+	}
+
+	beforeTree, err := commitTree(localRepository, beforeCommand.Hash())
+	if err != nil {
+		return changeSize{}, err
+	}
+
+	head, err := localRepository.Head()
+	if err != nil {
+		return changeSize{}, errors.WithStackTrace(err)
+	}
+	headTree, err := commitTree(localRepository, head.Hash())
+	if err != nil {
+		return changeSize{}, err
+	}
+
+	// Files the command committed are measured as committed, unless it changed them again afterwards
+	inWorktree := map[string]bool{}
+	for _, path := range staged {
+		inWorktree[path] = true
+	}
+	if head.Hash() != beforeCommand.Hash() {
+		changes, err := object.DiffTree(beforeTree, headTree)
+		if err != nil {
+			return changeSize{}, errors.WithStackTrace(err)
+		}
+		for _, change := range changes {
+			for _, path := range []string{change.From.Name, change.To.Name} {
+				if _, ok := inWorktree[path]; path != "" && !ok {
+					inWorktree[path] = false
+				}
+			}
+		}
+	}
+
+	paths := make([]string, 0, len(inWorktree))
+	for path := range inWorktree {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	size := changeSize{files: len(paths)}
+	for _, path := range paths {
+		before, err := treeFileContents(beforeTree, path)
+		if err != nil {
+			return changeSize{}, err
+		}
+
+		var after []byte
+		if inWorktree[path] {
+			after, err = worktreeFileContents(repositoryDir, path)
+		} else {
+			after, err = treeFileContents(headTree, path)
+		}
+		if err != nil {
+			return changeSize{}, err
+		}
+
+		if int64(len(after)) > size.largestFileSize {
+			size.largestFile = path
+			size.largestFileSize = int64(len(after))
+		}
+		size.lines += changedLines(before, after)
+	}
+	return size, nil
+}
+
+// commitTree returns the tree of the given commit
+func commitTree(localRepository *git.Repository, hash plumbing.Hash) (*object.Tree, error) {
+	commit, err := localRepository.CommitObject(hash)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	return tree, nil
+}
+
+// treeFileContents returns the contents of the given file in the tree, or nil if the tree doesn't contain it. Like
+// directories in the worktree, submodules have no contents of their own
+func treeFileContents(tree *object.Tree, path string) ([]byte, error) {
+	entry, err := tree.FindEntry(path)
+	if err == object.ErrEntryNotFound || err == object.ErrDirectoryNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	if !entry.Mode.IsFile() {
+		return nil, nil
+	}
+
+	file, err := tree.TreeEntryFile(entry)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+
+	contents, err := file.Contents()
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	return []byte(contents), nil
+}
+
+// worktreeFileContents returns the contents of the given file in the worktree, or nil if it was deleted. Submodules and
+// other directories have no contents of their own, and a symlink's contents are its target, as git stores them
+func worktreeFileContents(repositoryDir string, path string) ([]byte, error) {
+	fullPath := filepath.Join(repositoryDir, filepath.FromSlash(path))
+	info, err := os.Lstat(fullPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		target, err := os.Readlink(fullPath)
+		return []byte(target), errors.WithStackTrace(err)
+	case info.Mode().IsRegular():
+		contents, err := os.ReadFile(fullPath)
+		return contents, errors.WithStackTrace(err)
+	default:
+		return nil, nil
+	}
+}
+
+// changedLines returns the number of lines added and removed to turn before into after. Binary files have no lines,
+// so they only count towards the number of files changed and the largest file size
+func changedLines(before []byte, after []byte) int {
+	if isBinary(before) || isBinary(after) {
+		return 0
+	}
+
+	lines := 0
+	for _, d := range diff.Do(string(before), string(after)) {
+		if d.Type == diffmatchpatch.DiffEqual {
+			continue
+		}
+		lines += strings.Count(d.Text, "\n")
+		if !strings.HasSuffix(d.Text, "\n") {
+			lines++
+		}
+	}
+	return lines
+}
+
+// isBinary returns true if the given contents look binary, in the same way git decides whether to diff a file
+func isBinary(contents []byte) bool {
+	binaryContents, _ := binary.IsBinary(bytes.NewReader(contents))
+	return binaryContents
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gruntwork-io/git-xargs/local"
+	"github.com/gruntwork-io/git-xargs/stats"
+	"github.com/gruntwork-io/git-xargs/types"
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChangedLines(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, 0, changedLines([]byte("a\nb\n"), []byte("a\nb\n")))
+	assert.Equal(t, 2, changedLines([]byte("a\nb\n"), []byte("a\nc\n")))
+	assert.Equal(t, 3, changedLines(nil, []byte("a\nb\nc")))
+	assert.Equal(t, 2, changedLines([]byte("a\nb\n"), nil))
+	assert.Equal(t, 0, changedLines([]byte("a\x00b"), []byte("c\x00d")))
+}
+
+// TestChangeTooLarge ensures changes that exceed the change size limits, including the ones in commits the command
+// made itself, are neither committed nor pushed
+func TestChangeTooLarge(t *testing.T) {
+	t.Parallel()
+
+	forEachGitBackend(t, func(t *testing.T, provider local.GitProvider) {
+		remoteURL, _ := createTestRemote(t)
+		testConfig, repo := newCloneTestConfig(remoteURL, provider, "too-large")
+		testConfig.MaxChangedFiles = 2
+		testConfig.MaxChangedLines = 10
+		testConfig.MaxFileSize = 1000
+
+		repositoryDir := cloneAndCheckoutTestBranch(t, testConfig, repo)
+		beforeCommand, err := getLocalRepoHeadRef(testConfig, repositoryDir, repo)
+		require.NoError(t, err)
+
+		testConfig.Args = []string{"bash", "-c", `
+set -e
+seq 1 20 > modules/generated.tf && git add -A && git -c user.name=script -c user.email=script@example.com commit -q -m generated
+echo updated > modules/main.tf
+head -c 2000 /dev/zero > modules/blob.bin
+`}
+		require.NoError(t, executeCommand(testConfig, repositoryDir, repo))
+
+		err = updateRepo(testConfig, repositoryDir, repo, "refs/heads/too-large", beforeCommand, beforeCommand, false)
+		require.Error(t, err)
+		assert.IsType(t, types.ChangeTooLargeErr{}, errors.Unwrap(err))
+		assert.Equal(t, "3 files changed, limit 2; 22 lines changed, limit 10; modules/blob.bin is 2000 bytes, limit 1000", testConfig.Stats.GetDetail(stats.ChangeTooLarge, repo))
+
+		branches, err := local.RunGitCommand("", "ls-remote", remoteURL, "refs/heads/too-large")
+		require.NoError(t, err)
+		assert.Empty(t, strings.TrimSpace(branches))
+	})
+}
+
+// TestChangeWithinLimits ensures changes within the change size limits are committed as usual
+func TestChangeWithinLimits(t *testing.T) {
+	t.Parallel()
+
+	forEachGitBackend(t, func(t *testing.T, provider local.GitProvider) {
+		remoteURL, _ := createTestRemote(t)
+		testConfig, repo := newPartialCloneTestConfig(remoteURL, provider, "new-branch")
+		testConfig.MaxChangedFiles = 1
+		testConfig.MaxChangedLines = 2
+		testConfig.MaxFileSize = 100
+
+		repositoryDir := cloneAndCheckoutTestBranch(t, testConfig, repo)
+		beforeCommand, err := getLocalRepoHeadRef(testConfig, repositoryDir, repo)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(repositoryDir, "modules", "main.tf"), []byte("updated\n"), 0644))
+
+		require.NoError(t, checkChangeSize(testConfig, repositoryDir, repo, beforeCommand, []string{"modules/main.tf"}))
+		assert.Empty(t, testConfig.Stats.GetRepos()[stats.ChangeTooLarge])
+	})
+}
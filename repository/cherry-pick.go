@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/google/go-github/v43/github"
+	"github.com/gruntwork-io/git-xargs/config"
+	"github.com/gruntwork-io/git-xargs/local"
+	"github.com/gruntwork-io/git-xargs/stats"
+	"github.com/gruntwork-io/git-xargs/types"
+	"github.com/gruntwork-io/git-xargs/util"
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/gruntwork-io/go-commons/logging"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// cherryPickRemoteName is the remote the commit passed via --cherry-pick is fetched from, which is removed again once
+	// it has been fetched
+	cherryPickRemoteName = "git-xargs-cherry-pick"
+	// cherryPickRef is the ref the commit passed via --cherry-pick is fetched to, as go-git can only fetch to a ref
+	cherryPickRef = "refs/git-xargs/cherry-pick"
+	// cherryPickTrailer is the trailer that records which repo and commit a cherry-picked commit came from
+	cherryPickTrailer = "Cherry-picked-from"
+)
+
+// resolveCherryPickSource looks up the repo the commit passed via --cherry-pick is fetched from, before any repos are
+// cloned, so that a typo in it fails the run rather than every repo
+func resolveCherryPickSource(config *config.GitXargsConfig) error {
+	if config.CherryPick == "" {
+		return nil
+	}
+
+	logger := logging.GetLogger("git-xargs")
+
+	source := util.ConvertStringToCherryPickSource(config.CherryPick)
+	if source == nil {
+		return errors.WithStackTrace(types.InvalidCherryPickErr{CherryPick: config.CherryPick})
+	}
+
+	repo, resp, err := config.GithubClient.Repositories.Get(context.Background(), source.Organization, source.Name)
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"Error":        err,
+			"Organization": source.Organization,
+			"Name":         source.Name,
+		}).Debug("Error looking up the repo to cherry-pick from")
+
+		if resp != nil && resp.StatusCode == 404 {
+			return errors.WithStackTrace(types.CherryPickSourceNotFoundErr{Repo: source.Organization + "/" + source.Name})
+		}
+		return errors.WithStackTrace(err)
+	}
+
+	config.CherryPickSource = repo
+	config.CherryPickCommit = source.Commit
+	return nil
+}
+
+// cherryPickCommit fetches the commit passed via --cherry-pick into the repo and cherry-picks it, in place of running
+// a command. The commit keeps its author and message, and gets a trailer recording where it came from. A commit that
+// conflicts is reported with types.CherryPickConflictErr, along with the files it conflicted in, and the repo is left
+// as it was. A commit whose changes the repo already has makes no changes
+func cherryPickCommit(config *config.GitXargsConfig, repositoryDir string, remoteRepository *github.Repository) error {
+	logger := logging.GetLogger("git-xargs")
+
+	if err := fetchCherryPickCommit(config, repositoryDir); err != nil {
+		logger.WithFields(logrus.Fields{
+			"Error":  err,
+			"Repo":   remoteRepository.GetName(),
+			"Commit": config.CherryPick,
+		}).Debug("Error fetching the commit to cherry-pick")
+
+		config.Stats.TrackSingleWithDetail(stats.CherryPickFailed, remoteRepository, gitFailureSummary(err))
+		return errors.WithStackTrace(types.CherryPickFailedErr{Commit: config.CherryPick, Underlying: err})
+	}
+
+	// The commit keeps its author, but is committed by whoever git-xargs commits as
+	_, pickErr := local.RunGitCommand(repositoryDir, append(committerArgs(config), "cherry-pick", config.CherryPickCommit)...)
+	if pickErr != nil {
+		return handleCherryPickFailure(config, repositoryDir, remoteRepository, pickErr)
+	}
+
+	trailer := fmt.Sprintf("%s: %s@%s", cherryPickTrailer, cherryPickSourceName(config), config.CherryPickCommit)
+	if _, err := local.RunGitCommand(repositoryDir, append(committerArgs(config), "commit", "--quiet", "--amend", "--no-edit", "--trailer", trailer)...); err != nil {
+		config.Stats.TrackSingle(stats.CommitChangesFailed, remoteRepository)
+		return err
+	}
+	return nil
+}
+
+// fetchCherryPickCommit fetches the commit passed via --cherry-pick from the repo it belongs to, with the same
+// credentials, proxy and CA bundle the repo itself was cloned with
+func fetchCherryPickCommit(config *config.GitXargsConfig, repositoryDir string) error {
+	localRepository, err := git.PlainOpen(repositoryDir)
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+
+	gitAuth, err := getGitAuth(config, config.CherryPickSource)
+	if err != nil {
+		return err
+	}
+
+	if _, err := localRepository.CreateRemote(&gitconfig.RemoteConfig{
+		Name: cherryPickRemoteName,
+		URLs: []string{getCloneURL(config, config.CherryPickSource)},
+	}); err != nil {
+		return errors.WithStackTrace(err)
+	}
+	defer localRepository.DeleteRemote(cherryPickRemoteName)
+
+	err = config.GitClient.Fetch(repositoryDir, &git.FetchOptions{
+		RemoteName:   cherryPickRemoteName,
+		RefSpecs:     []gitconfig.RefSpec{gitconfig.RefSpec(config.CherryPickCommit + ":" + cherryPickRef)},
+		Auth:         gitAuth,
+		CABundle:     config.GithubServer.CABundle(),
+		ProxyOptions: getProxyOptions(config),
+	})
+	if err == git.NoErrAlreadyUpToDate {
+		return nil
+	}
+	return errors.WithStackTrace(err)
+}
+
+// handleCherryPickFailure works out why git cherry-pick failed, reports it, and puts the repo back as it was. The
+// commit either conflicted, turned out to make no changes to the repo, or couldn't be cherry-picked at all, such as
+// because it's a merge commit
+func handleCherryPickFailure(config *config.GitXargsConfig, repositoryDir string, remoteRepository *github.Repository, pickErr error) error {
+	logger := logging.GetLogger("git-xargs")
+
+	unmerged, err := local.RunGitCommand(repositoryDir, "diff", "--name-only", "--diff-filter=U")
+	if err != nil {
+		return err
+	}
+	var conflicts []string
+	for _, file := range strings.Split(unmerged, "\n") {
+		if file = strings.TrimSpace(file); file != "" {
+			conflicts = append(conflicts, file)
+		}
+	}
+
+	// git only stops part way through a cherry-pick, which must be aborted, if it conflicted or made no changes
+	_, inProgressErr := local.RunGitCommand(repositoryDir, "rev-parse", "--quiet", "--verify", "CHERRY_PICK_HEAD")
+	if inProgressErr == nil {
+		if _, err := local.RunGitCommand(repositoryDir, "cherry-pick", "--abort"); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case len(conflicts) > 0:
+		logger.WithFields(logrus.Fields{
+			"Repo":      remoteRepository.GetName(),
+			"Commit":    config.CherryPick,
+			"Conflicts": conflicts,
+		}).Debug("The commit to cherry-pick conflicted")
+
+		config.Stats.TrackSingleWithDetail(stats.CherryPickConflict, remoteRepository, "conflicted in "+strings.Join(conflicts, ", "))
+		return errors.WithStackTrace(types.CherryPickConflictErr{Commit: config.CherryPick, Files: conflicts})
+	case inProgressErr == nil:
+		logger.WithFields(logrus.Fields{
+			"Repo":   remoteRepository.GetName(),
+			"Commit": config.CherryPick,
+		}).Debug("The repo already has the changes of the commit to cherry-pick")
+		return nil
+	default:
+		logger.WithFields(logrus.Fields{
+			"Error":  pickErr,
+			"Repo":   remoteRepository.GetName(),
+			"Commit": config.CherryPick,
+		}).Debug("Error cherry-picking the commit")
+
+		config.Stats.TrackSingleWithDetail(stats.CherryPickFailed, remoteRepository, gitFailureSummary(pickErr))
+		return errors.WithStackTrace(types.CherryPickFailedErr{Commit: config.CherryPick, Underlying: pickErr})
+	}
+}
+
+// cherryPickSourceName returns the name of the repo the commit passed via --cherry-pick comes from, along with its
+// owner
+func cherryPickSourceName(config *config.GitXargsConfig) string {
+	return config.CherryPickSource.GetOwner().GetLogin() + "/" + config.CherryPickSource.GetName()
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v43/github"
+	"github.com/gruntwork-io/git-xargs/config"
+	"github.com/gruntwork-io/git-xargs/local"
+	"github.com/gruntwork-io/git-xargs/stats"
+	"github.com/gruntwork-io/git-xargs/types"
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// useTestCherryPick makes the run cherry-pick the given commit from the test remote at the given URL
+func useTestCherryPick(testConfig *config.GitXargsConfig, upstreamURL string, commit string) {
+	testConfig.CherryPick = "gruntwork-io/upstream@" + commit
+	testConfig.CherryPickCommit = commit
+	testConfig.CherryPickSource = &github.Repository{
+		Owner:    &github.User{Login: github.String("gruntwork-io")},
+		Name:     github.String("upstream"),
+		CloneURL: github.String(upstreamURL),
+	}
+}
+
+// TestCherryPickCommit ensures a commit from another repo is cherry-picked with its author and message, along with a
+// trailer recording where it came from, and pushed
+func TestCherryPickCommit(t *testing.T) {
+	t.Parallel()
+
+	forEachGitBackend(t, func(t *testing.T, provider local.GitProvider) {
+		remoteURL, _ := createTestRemote(t)
+		upstreamURL, _ := createTestRemote(t)
+		commit := commitToTestRemote(t, upstreamURL, "main", "Upstream Author <upstream@example.com>", "modules/main.tf", "fixed", "docs/later.md")
+
+		testConfig, repo, repositoryDir := newApplyPatchTestConfig(t, remoteURL, provider, "")
+		useTestCherryPick(testConfig, upstreamURL, commit)
+		beforeCommand, err := getLocalRepoHeadRef(testConfig, repositoryDir, repo)
+		require.NoError(t, err)
+
+		require.NoError(t, cherryPickCommit(testConfig, repositoryDir, repo))
+		require.NoError(t, updateRepo(testConfig, repositoryDir, repo, "refs/heads/patched", beforeCommand, beforeCommand, false))
+
+		tip := remoteBranchTip(t, remoteURL, "patched")
+		contents, err := local.RunGitCommand(repositoryDir, "show", tip+":modules/main.tf")
+		require.NoError(t, err)
+		assert.Equal(t, "fixed", strings.TrimSpace(contents))
+		_, err = local.RunGitCommand(repositoryDir, "show", tip+":docs/later.md")
+		assert.Error(t, err)
+
+		commits, err := local.RunGitCommand(repositoryDir, "log", "--format=%an <%ae> %cn", beforeCommand.Hash().String()+".."+tip)
+		require.NoError(t, err)
+		assert.Equal(t, "Upstream Author <upstream@example.com> git-xargs", strings.TrimSpace(commits))
+		message, err := local.RunGitCommand(repositoryDir, "log", "-1", "--format=%B", tip)
+		require.NoError(t, err)
+		assert.Equal(t, "update modules/main.tf\n\nChanged by the test.\n\nCherry-picked-from: gruntwork-io/upstream@"+commit, strings.TrimSpace(message))
+
+		remotes, err := local.RunGitCommand(repositoryDir, "remote")
+		require.NoError(t, err)
+		assert.Equal(t, "origin", strings.TrimSpace(remotes))
+	})
+}
+
+// TestCherryPickCommitConflict ensures a commit that conflicts with a repo is reported along with the files it
+// conflicted in, and that the repo is left as it was
+func TestCherryPickCommitConflict(t *testing.T) {
+	t.Parallel()
+
+	forEachGitBackend(t, func(t *testing.T, provider local.GitProvider) {
+		remoteURL, _ := createTestRemote(t)
+		commitToTestRemote(t, remoteURL, "main", "", "modules/main.tf", "diverged")
+		upstreamURL, _ := createTestRemote(t)
+		commit := commitToTestRemote(t, upstreamURL, "main", "Upstream Author <upstream@example.com>", "modules/main.tf", "fixed", "docs/later.md")
+
+		testConfig, repo, repositoryDir := newApplyPatchTestConfig(t, remoteURL, provider, "")
+		useTestCherryPick(testConfig, upstreamURL, commit)
+		beforeCommand, err := getLocalRepoHeadRef(testConfig, repositoryDir, repo)
+		require.NoError(t, err)
+
+		pickErr := cherryPickCommit(testConfig, repositoryDir, repo)
+		require.Error(t, pickErr)
+		assert.Equal(t, types.CherryPickConflictErr{Commit: testConfig.CherryPick, Files: []string{"modules/main.tf"}}, errors.Unwrap(pickErr))
+		assert.Equal(t, "conflicted in modules/main.tf", testConfig.Stats.GetDetail(stats.CherryPickConflict, repo))
+		assertTestRepoUnchanged(t, testConfig, repositoryDir, repo, beforeCommand.Hash().String())
+	})
+}
+
+// TestCherryPickCommitAlreadyApplied ensures a repo that already has a commit's changes is left unchanged, and that a
+// commit that can't be fetched is reported
+func TestCherryPickCommitAlreadyApplied(t *testing.T) {
+	t.Parallel()
+
+	forEachGitBackend(t, func(t *testing.T, provider local.GitProvider) {
+		remoteURL, _ := createTestRemote(t)
+		commitToTestRemote(t, remoteURL, "main", "", "modules/main.tf", "fixed")
+		upstreamURL, _ := createTestRemote(t)
+		commit := commitToTestRemote(t, upstreamURL, "main", "Upstream Author <upstream@example.com>", "modules/main.tf", "fixed", "docs/later.md")
+
+		testConfig, repo, repositoryDir := newApplyPatchTestConfig(t, remoteURL, provider, "")
+		useTestCherryPick(testConfig, upstreamURL, commit)
+		beforeCommand, err := getLocalRepoHeadRef(testConfig, repositoryDir, repo)
+		require.NoError(t, err)
+
+		require.NoError(t, cherryPickCommit(testConfig, repositoryDir, repo))
+		assertTestRepoUnchanged(t, testConfig, repositoryDir, repo, beforeCommand.Hash().String())
+
+		useTestCherryPick(testConfig, upstreamURL, strings.Repeat("0", 40))
+		pickErr := cherryPickCommit(testConfig, repositoryDir, repo)
+		require.Error(t, pickErr)
+		assert.IsType(t, types.CherryPickFailedErr{}, errors.Unwrap(pickErr))
+		assert.NotEmpty(t, testConfig.Stats.GetDetail(stats.CherryPickFailed, repo))
+		assertTestRepoUnchanged(t, testConfig, repositoryDir, repo, beforeCommand.Hash().String())
+	})
+}
@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/google/go-github/v43/github"
+	"github.com/gruntwork-io/git-xargs/cache"
+	"github.com/gruntwork-io/git-xargs/config"
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/gruntwork-io/go-commons/logging"
+	"github.com/sirupsen/logrus"
+)
+
+// mirrorRefSpecs are the refs each mirror in the cache tracks. Unlike `git clone --mirror`, they leave out the
+// refs/pull/* refs GitHub advertises, which can outnumber a repo's branches many times over
+var mirrorRefSpecs = []gitconfig.RefSpec{
+	"+refs/heads/*:refs/heads/*",
+	"+refs/tags/*:refs/tags/*",
+}
+
+// cloneFromCache creates the worktree for the given repo from its mirror in the --cache-dir, creating the mirror if no
+// earlier run has, and fetching whatever changed on GitHub since the last run if one has. The mirror is locked for the
+// duration, so that concurrent runs never fetch into a mirror while another is reading it. The supplied clone options
+// describe how to reach GitHub, and the shape of the worktree to create
+func cloneFromCache(config *config.GitXargsConfig, repo *github.Repository, repositoryDir string, cloneOptions *git.CloneOptions) error {
+	logger := logging.GetLogger("git-xargs")
+
+	repoCache := cache.NewCache(config.CacheDir)
+	mirrorPath := repoCache.MirrorPath(config.GithubServer.Hostname(), repo.GetOwner().GetLogin(), repo.GetName())
+
+	lock, err := repoCache.Lock(mirrorPath)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	logger.WithFields(logrus.Fields{
+		"Repo":   repo.GetName(),
+		"Mirror": mirrorPath,
+	}).Debug("Updating cached mirror of repository")
+
+	if err := updateMirror(config, mirrorPath, repo, cloneOptions); err != nil {
+		return err
+	}
+
+	// The mirror is local, so the worktree is cloned from it without any credentials or proxy
+	localCloneOptions := *cloneOptions
+	localCloneOptions.URL = mirrorPath
+	if cloneOptions.Depth > 0 {
+		// git ignores the depth of clones from a plain path, so shallow worktrees are cloned over file:// instead
+		localCloneOptions.URL = fileURL(mirrorPath)
+	}
+	localCloneOptions.Auth = nil
+	localCloneOptions.CABundle = nil
+	localCloneOptions.ProxyOptions = transport.ProxyOptions{}
+
+	if err := config.GitClient.Clone(repositoryDir, &localCloneOptions); err != nil {
+		return errors.WithStackTrace(err)
+	}
+
+	localRepository, err := git.PlainOpen(repositoryDir)
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+
+	// Point the worktree's origin at GitHub, so that pulls and pushes go straight there rather than via the cache
+	if err := setOriginURL(localRepository, cloneOptions.URL); err != nil {
+		return err
+	}
+
+	return cache.MarkUsed(mirrorPath)
+}
+
+// updateMirror fetches every branch and tag of the given repo into its mirror, pruning those that have since been
+// deleted. The mirror is created first if it doesn't exist yet
+func updateMirror(config *config.GitXargsConfig, mirrorPath string, repo *github.Repository, cloneOptions *git.CloneOptions) error {
+	mirror, err := git.PlainOpen(mirrorPath)
+	if err == git.ErrRepositoryNotExists {
+		mirror, err = git.PlainInit(mirrorPath, true)
+		if err != nil {
+			return errors.WithStackTrace(err)
+		}
+		_, err = mirror.CreateRemote(&gitconfig.RemoteConfig{
+			Name:  "origin",
+			URLs:  []string{cloneOptions.URL},
+			Fetch: mirrorRefSpecs,
+		})
+	} else if err == nil {
+		// Earlier runs may have cloned over a different --git-transport
+		err = setOriginURL(mirror, cloneOptions.URL)
+	}
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+
+	err = config.GitClient.Fetch(mirrorPath, &git.FetchOptions{
+		RemoteName:   "origin",
+		Auth:         cloneOptions.Auth,
+		Progress:     cloneOptions.Progress,
+		CABundle:     cloneOptions.CABundle,
+		ProxyOptions: cloneOptions.ProxyOptions,
+		Prune:        true,
+	})
+	if err != nil && err != git.NoErrAlreadyUpToDate {
+		return errors.WithStackTrace(err)
+	}
+
+	// Worktrees cloned from the mirror check out whichever branch its HEAD points at
+	defaultBranch, err := mirrorDefaultBranch(mirror, repo, cloneOptions)
+	if err != nil {
+		return err
+	}
+	head := plumbing.NewSymbolicReference(plumbing.HEAD, defaultBranch)
+	return errors.WithStackTrace(mirror.Storer.SetReference(head))
+}
+
+// mirrorDefaultBranch returns the default branch of the given repo, as reported by the API or, if the API didn't report
+// one, by the remote itself
+func mirrorDefaultBranch(mirror *git.Repository, repo *github.Repository, cloneOptions *git.CloneOptions) (plumbing.ReferenceName, error) {
+	if defaultBranch := repo.GetDefaultBranch(); defaultBranch != "" {
+		return plumbing.NewBranchReferenceName(defaultBranch), nil
+	}
+
+	remote, err := mirror.Remote("origin")
+	if err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+
+	refs, err := remote.List(&git.ListOptions{
+		Auth:         cloneOptions.Auth,
+		CABundle:     cloneOptions.CABundle,
+		ProxyOptions: cloneOptions.ProxyOptions,
+	})
+	if err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+
+	for _, ref := range refs {
+		if ref.Name() == plumbing.HEAD && ref.Type() == plumbing.SymbolicReference {
+			return ref.Target(), nil
+		}
+	}
+
+	return "", errors.WithStackTrace(plumbing.ErrReferenceNotFound)
+}
+
+// setOriginURL points the origin remote of the given repo at the supplied URL
+func setOriginURL(localRepository *git.Repository, url string) error {
+	repoConfig, err := localRepository.Config()
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+
+	origin, ok := repoConfig.Remotes["origin"]
+	if !ok {
+		return errors.WithStackTrace(git.ErrRemoteNotFound)
+	}
+	origin.URLs = []string{url}
+
+	return errors.WithStackTrace(localRepository.SetConfig(repoConfig))
+}
+
+// fileURL returns the file:// URL of the given local path
+func fileURL(path string) string {
+	slashPath := filepath.ToSlash(path)
+	if !strings.HasPrefix(slashPath, "/") {
+		// Windows paths start with a drive letter rather than a slash
+		slashPath = "/" + slashPath
+	}
+	return "file://" + slashPath
+}
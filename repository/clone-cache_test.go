@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gruntwork-io/git-xargs/cache"
+	"github.com/gruntwork-io/git-xargs/local"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCloneFromCache ensures a second run against the same --cache-dir fetches what changed on the remote into the
+// mirror, and that worktrees cloned from the mirror push straight to the remote
+func TestCloneFromCache(t *testing.T) {
+	t.Parallel()
+
+	forEachGitBackend(t, func(t *testing.T, provider local.GitProvider) {
+		remoteURL, _ := createTestRemote(t)
+		testConfig, repo := newCloneTestConfig(remoteURL, provider, "new-branch")
+		testConfig.CacheDir = t.TempDir()
+
+		mirrorPath := cache.NewCache(testConfig.CacheDir).MirrorPath("github.com", repo.GetOwner().GetLogin(), repo.GetName())
+
+		firstDir := cloneAndCheckoutTestBranch(t, testConfig, repo)
+		assert.DirExists(t, mirrorPath)
+
+		// Push a new commit to main from the first worktree, as another contributor would
+		_, err := local.RunGitCommand(firstDir, "checkout", "-q", "main")
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(firstDir, "docs", "README.md"), []byte("updated"), 0644))
+		_, err = local.RunGitCommand(firstDir, "commit", "-q", "-am", "update docs")
+		require.NoError(t, err)
+		_, err = local.RunGitCommand(firstDir, "push", "-q", "origin", "main")
+		require.NoError(t, err)
+		remoteMain, err := local.RunGitCommand(firstDir, "rev-parse", "HEAD")
+		require.NoError(t, err)
+
+		secondDir := cloneAndCheckoutTestBranch(t, testConfig, repo)
+
+		mirrorMain, err := local.RunGitCommand(mirrorPath, "rev-parse", "refs/heads/main")
+		require.NoError(t, err)
+		assert.Equal(t, remoteMain, mirrorMain)
+
+		origin, err := local.RunGitCommand(secondDir, "remote", "get-url", "origin")
+		require.NoError(t, err)
+		assert.Equal(t, remoteURL, strings.TrimSpace(origin))
+
+		require.NoError(t, os.WriteFile(filepath.Join(secondDir, "modules", "main.tf"), []byte("updated"), 0644))
+		commitTestChanges(t, testConfig, secondDir, repo)
+		require.NoError(t, pushLocalBranch(testConfig, repo, secondDir, false, nil))
+
+		remoteBranch, err := local.RunGitCommand(secondDir, "ls-remote", remoteURL, "refs/heads/"+testConfig.BranchName)
+		require.NoError(t, err)
+		assert.NotEmpty(t, remoteBranch)
+	})
+}
+
+// TestCloneFromCachePrunesDeletedBranches ensures branches deleted from the remote are removed from the mirror
+func TestCloneFromCachePrunesDeletedBranches(t *testing.T) {
+	t.Parallel()
+
+	forEachGitBackend(t, func(t *testing.T, provider local.GitProvider) {
+		remoteURL, _ := createTestRemote(t)
+		testConfig, repo := newPartialCloneTestConfig(remoteURL, provider, "new-branch")
+		testConfig.SparsePaths = nil
+		testConfig.CacheDir = t.TempDir()
+
+		mirrorPath := cache.NewCache(testConfig.CacheDir).MirrorPath("github.com", repo.GetOwner().GetLogin(), repo.GetName())
+
+		cloneAndCheckoutTestBranch(t, testConfig, repo)
+		_, err := local.RunGitCommand(mirrorPath, "rev-parse", "--verify", "refs/heads/existing-branch")
+		require.NoError(t, err)
+
+		_, err = local.RunGitCommand(strings.TrimPrefix(remoteURL, "file://"), "branch", "-D", "existing-branch")
+		require.NoError(t, err)
+
+		cloneAndCheckoutTestBranch(t, testConfig, repo)
+		_, err = local.RunGitCommand(mirrorPath, "rev-parse", "--verify", "refs/heads/existing-branch")
+		assert.Error(t, err)
+	})
+}
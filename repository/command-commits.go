@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+	"github.com/google/go-github/v43/github"
+	"github.com/gruntwork-io/git-xargs/config"
+	"github.com/gruntwork-io/git-xargs/stats"
+	"github.com/gruntwork-io/git-xargs/types"
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/gruntwork-io/go-commons/logging"
+	"github.com/sirupsen/logrus"
+)
+
+// countCommandCommits returns the number of commits the supplied command made itself, on top of the commit the branch
+// was at before it ran. Those commits are pushed along with any changes the command left uncommitted. A command that
+// checked out a different branch, or detached HEAD, is reported with types.CommandChangedBranchErr, because nothing it
+// did would be pushed. A command that reset or rebased the branch so that it no longer contains that commit is reported
+// with types.CommandRewroteHistoryErr, because its commits can't be told apart from the ones it threw away
+func countCommandCommits(config *config.GitXargsConfig, repositoryDir string, remoteRepository *github.Repository, branchName string, beforeCommand *plumbing.Reference) (int, error) {
+	logger := logging.GetLogger("git-xargs")
+
+	head, err := config.GitClient.Head(repositoryDir)
+	if err != nil {
+		config.Stats.TrackSingle(stats.GetHeadRefFailed, remoteRepository)
+		return 0, errors.WithStackTrace(err)
+	}
+
+	if head.Name().String() != branchName {
+		logger.WithFields(logrus.Fields{
+			"Repo":   remoteRepository.GetName(),
+			"Branch": branchName,
+			"HEAD":   head.Name().String(),
+		}).Debug("Command checked out a different branch")
+
+		config.Stats.TrackSingleWithDetail(stats.CommandChangedBranch, remoteRepository, head.Name().Short())
+		return 0, errors.WithStackTrace(types.CommandChangedBranchErr{Branch: plumbing.ReferenceName(branchName).Short(), Head: head.Name().Short()})
+	}
+
+	if head.Hash() == beforeCommand.Hash() {
+		return 0, nil
+	}
+
+	localRepository, err := git.PlainOpen(repositoryDir)
+	if err != nil {
+		return 0, errors.WithStackTrace(err)
+	}
+
+	headCommit, err := localRepository.CommitObject(head.Hash())
+	if err != nil {
+		return 0, errors.WithStackTrace(err)
+	}
+	beforeCommandCommit, err := localRepository.CommitObject(beforeCommand.Hash())
+	if err != nil {
+		return 0, errors.WithStackTrace(err)
+	}
+	isAncestor, err := beforeCommandCommit.IsAncestor(headCommit)
+	if err != nil {
+		return 0, errors.WithStackTrace(err)
+	}
+	if !isAncestor {
+		logger.WithFields(logrus.Fields{
+			"Repo":   remoteRepository.GetName(),
+			"Branch": branchName,
+			"Commit": beforeCommand.Hash().String(),
+		}).Debug("Command rewrote the branch so that it no longer contains the commit it started from")
+
+		shortHash := beforeCommand.Hash().String()[:7]
+		config.Stats.TrackSingleWithDetail(stats.CommandRewroteHistory, remoteRepository, "no longer contains "+shortHash)
+		return 0, errors.WithStackTrace(types.CommandRewroteHistoryErr{Branch: plumbing.ReferenceName(branchName).Short(), Commit: shortHash})
+	}
+
+	commits, err := localRepository.Log(&git.LogOptions{From: head.Hash()})
+	if err != nil {
+		return 0, errors.WithStackTrace(err)
+	}
+
+	count := 0
+	iterErr := commits.ForEach(func(commit *object.Commit) error {
+		if commit.Hash == beforeCommand.Hash() {
+			return storer.ErrStop
+		}
+		count++
+		return nil
+	})
+	if iterErr != nil {
+		return 0, errors.WithStackTrace(iterErr)
+	}
+
+	detail := fmt.Sprintf("%d commits", count)
+	if count == 1 {
+		detail = "1 commit"
+	}
+	config.Stats.TrackSingleWithDetail(stats.CommandCreatedCommits, remoteRepository, detail)
+	return count, nil
+}
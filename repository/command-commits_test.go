@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gruntwork-io/git-xargs/local"
+	"github.com/gruntwork-io/git-xargs/stats"
+	"github.com/gruntwork-io/git-xargs/types"
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPushCommandCommits ensures commits the command makes itself are pushed, along with the changes it left
+// uncommitted, which are committed on top of them
+func TestPushCommandCommits(t *testing.T) {
+	t.Parallel()
+
+	forEachGitBackend(t, func(t *testing.T, provider local.GitProvider) {
+		remoteURL, _ := createTestRemote(t)
+		testConfig, repo := newCloneTestConfig(remoteURL, provider, "command-commits")
+
+		repositoryDir := cloneAndCheckoutTestBranch(t, testConfig, repo)
+		beforeCommand, err := getLocalRepoHeadRef(testConfig, repositoryDir, repo)
+		require.NoError(t, err)
+
+		testConfig.Args = []string{"bash", "-c", `
+set -e
+echo first > modules/first.tf && git add -A && git -c user.name=script -c user.email=script@example.com commit -q -m first
+echo second > modules/second.tf && git add -A && git -c user.name=script -c user.email=script@example.com commit -q -m second
+echo uncommitted > modules/main.tf
+`}
+		require.NoError(t, executeCommand(testConfig, repositoryDir, repo))
+		require.NoError(t, updateRepo(testConfig, repositoryDir, repo, "refs/heads/command-commits", beforeCommand, beforeCommand, false))
+
+		assert.Equal(t, "2 commits", testConfig.Stats.GetDetail(stats.CommandCreatedCommits, repo))
+		assert.Empty(t, testConfig.Stats.GetRepos()[stats.WorktreeStatusClean])
+
+		log, err := local.RunGitCommand(repositoryDir, "log", "--format=%s", beforeCommand.Hash().String()+".."+remoteBranchTip(t, remoteURL, "command-commits"))
+		require.NoError(t, err)
+		assert.Equal(t, []string{testConfig.CommitMessage, "second", "first"}, strings.Split(strings.TrimSpace(log), "\n"))
+	})
+}
+
+// TestCommandChangedBranch ensures nothing is pushed if the command checks out a different branch
+func TestCommandChangedBranch(t *testing.T) {
+	t.Parallel()
+
+	forEachGitBackend(t, func(t *testing.T, provider local.GitProvider) {
+		remoteURL, _ := createTestRemote(t)
+		testConfig, repo := newCloneTestConfig(remoteURL, provider, "command-commits")
+
+		repositoryDir := cloneAndCheckoutTestBranch(t, testConfig, repo)
+		beforeCommand, err := getLocalRepoHeadRef(testConfig, repositoryDir, repo)
+		require.NoError(t, err)
+
+		_, err = local.RunGitCommand(repositoryDir, "checkout", "-q", "-b", "elsewhere")
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(repositoryDir, "modules", "main.tf"), []byte("elsewhere"), 0644))
+
+		err = updateRepo(testConfig, repositoryDir, repo, "refs/heads/command-commits", beforeCommand, beforeCommand, false)
+		require.Error(t, err)
+		assert.IsType(t, types.CommandChangedBranchErr{}, errors.Unwrap(err))
+		assert.Equal(t, "elsewhere", testConfig.Stats.GetDetail(stats.CommandChangedBranch, repo))
+
+		out, err := local.RunGitCommand("", "ls-remote", remoteURL, "refs/heads/command-commits")
+		require.NoError(t, err)
+		assert.Empty(t, strings.TrimSpace(out))
+	})
+}
+
+// TestCommandRewroteHistory ensures nothing is pushed if the command resets the branch past the commit it started from
+func TestCommandRewroteHistory(t *testing.T) {
+	t.Parallel()
+
+	forEachGitBackend(t, func(t *testing.T, provider local.GitProvider) {
+		remoteURL, _ := createTestRemote(t)
+		testConfig, repo := newCloneTestConfig(remoteURL, provider, "command-commits")
+
+		repositoryDir := cloneAndCheckoutTestBranch(t, testConfig, repo)
+		beforeCommand, err := getLocalRepoHeadRef(testConfig, repositoryDir, repo)
+		require.NoError(t, err)
+
+		testConfig.Args = []string{"bash", "-c", `
+set -e
+git reset -q --hard HEAD~1
+echo rewritten > modules/rewritten.tf && git add -A && git -c user.name=script -c user.email=script@example.com commit -q -m rewritten
+`}
+		require.NoError(t, executeCommand(testConfig, repositoryDir, repo))
+
+		err = updateRepo(testConfig, repositoryDir, repo, "refs/heads/command-commits", beforeCommand, beforeCommand, false)
+		require.Error(t, err)
+		assert.Equal(t, types.CommandRewroteHistoryErr{Branch: "command-commits", Commit: beforeCommand.Hash().String()[:7]}, errors.Unwrap(err))
+		assert.Equal(t, "no longer contains "+beforeCommand.Hash().String()[:7], testConfig.Stats.GetDetail(stats.CommandRewroteHistory, repo))
+		assert.Empty(t, testConfig.Stats.GetRepos()[stats.CommandCreatedCommits])
+
+		out, err := local.RunGitCommand("", "ls-remote", remoteURL, "refs/heads/command-commits")
+		require.NoError(t, err)
+		assert.Empty(t, strings.TrimSpace(out))
+	})
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/gruntwork-io/git-xargs/config"
+	"github.com/gruntwork-io/git-xargs/types"
+	"github.com/gruntwork-io/go-commons/errors"
+)
+
+// commitMessageWithTrailers returns the commit message followed by the trailers passed via --commit-trailer and, if
+// --signoff was passed, a Signed-off-by trailer for the commit author
+func commitMessageWithTrailers(config *config.GitXargsConfig, repositoryDir string) (string, error) {
+	trailers := append([]string{}, config.CommitTrailers...)
+
+	if config.Signoff {
+		name, email, err := commitIdentity(config, repositoryDir)
+		if err != nil {
+			return "", err
+		}
+
+		signoff := fmt.Sprintf("Signed-off-by: %s <%s>", name, email)
+		if !slices.Contains(trailers, signoff) {
+			trailers = append(trailers, signoff)
+		}
+	}
+
+	if len(trailers) == 0 {
+		return config.CommitMessage, nil
+	}
+
+	// git only recognizes trailers in the last paragraph of the message
+	return strings.TrimRight(config.CommitMessage, "\n") + "\n\n" + strings.Join(trailers, "\n") + "\n", nil
+}
+
+// commitAuthorSignature returns the author of the commits git-xargs makes. Commits are authored by the identity passed
+// via --commit-author-name and --commit-author-email, or when authenticating as a GitHub App, by the app's bot user,
+// rather than whatever identity the local git config holds. It returns nil if neither applies, so that the git config
+// is used
+func commitAuthorSignature(config *config.GitXargsConfig) *object.Signature {
+	if config.CommitAuthorName == "" || config.CommitAuthorEmail == "" {
+		return nil
+	}
+	return &object.Signature{
+		Name:  config.CommitAuthorName,
+		Email: config.CommitAuthorEmail,
+		When:  time.Now(),
+	}
+}
+
+// commitIdentity returns the name and email address commits are authored by: those passed via --commit-author-name and
+// --commit-author-email, or else those in the git config, as go-git would use
+func commitIdentity(config *config.GitXargsConfig, repositoryDir string) (string, string, error) {
+	if config.CommitAuthorName != "" && config.CommitAuthorEmail != "" {
+		return config.CommitAuthorName, config.CommitAuthorEmail, nil
+	}
+
+	localRepository, err := git.PlainOpen(repositoryDir)
+	if err != nil {
+		return "", "", errors.WithStackTrace(err)
+	}
+
+	gitConfig, err := localRepository.ConfigScoped(gitconfig.SystemScope)
+	if err != nil {
+		return "", "", errors.WithStackTrace(err)
+	}
+
+	name, email := gitConfig.Author.Name, gitConfig.Author.Email
+	if name == "" || email == "" {
+		name, email = gitConfig.User.Name, gitConfig.User.Email
+	}
+	if name == "" || email == "" {
+		return "", "", errors.WithStackTrace(types.CommitIdentityMissingErr{})
+	}
+	return name, email, nil
+}
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gruntwork-io/git-xargs/local"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommitMessageWithTrailers(t *testing.T) {
+	t.Parallel()
+
+	testConfig, _ := newPartialCloneTestConfig("", local.GitProductionProvider{}, "new-branch")
+	testConfig.CommitMessage = "Update CI\n"
+
+	message, err := commitMessageWithTrailers(testConfig, t.TempDir())
+	require.NoError(t, err)
+	assert.Equal(t, "Update CI\n", message)
+
+	testConfig.CommitTrailers = []string{"Co-authored-by: Jane Doe <jane@example.com>", "Signed-off-by: git-xargs <git-xargs@example.com>"}
+	testConfig.Signoff = true
+	testConfig.CommitAuthorName = "git-xargs"
+	testConfig.CommitAuthorEmail = "git-xargs@example.com"
+
+	message, err = commitMessageWithTrailers(testConfig, t.TempDir())
+	require.NoError(t, err)
+	assert.Equal(t, "Update CI\n\nCo-authored-by: Jane Doe <jane@example.com>\nSigned-off-by: git-xargs <git-xargs@example.com>\n", message)
+}
+
+// TestCommitAuthorAndTrailers ensures commits are authored by the chosen identity and carry the requested trailers
+func TestCommitAuthorAndTrailers(t *testing.T) {
+	t.Parallel()
+
+	forEachGitBackend(t, func(t *testing.T, provider local.GitProvider) {
+		remoteURL, _ := createTestRemote(t)
+		testConfig, repo := newPartialCloneTestConfig(remoteURL, provider, "new-branch")
+		testConfig.CommitAuthorName = "Jane Doe"
+		testConfig.CommitAuthorEmail = "jane@example.com"
+		testConfig.Signoff = true
+		testConfig.CommitTrailers = []string{"Co-authored-by: git-xargs <git-xargs@example.com>"}
+
+		repositoryDir := cloneAndCheckoutTestBranch(t, testConfig, repo)
+		require.NoError(t, os.WriteFile(filepath.Join(repositoryDir, "modules", "main.tf"), []byte("updated"), 0644))
+		commitTestChanges(t, testConfig, repositoryDir, repo)
+
+		commit, err := local.RunGitCommand(repositoryDir, "log", "-1", "--format=%an <%ae>|%cn <%ce>|%(trailers:only,unfold,separator=%x2C)")
+		require.NoError(t, err)
+		assert.Equal(t, "Jane Doe <jane@example.com>|Jane Doe <jane@example.com>|Co-authored-by: git-xargs <git-xargs@example.com>,Signed-off-by: Jane Doe <jane@example.com>", strings.TrimSpace(commit))
+	})
+}
@@ -0,0 +1,195 @@
+package repository
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+	"github.com/google/go-github/v43/github"
+	"github.com/gruntwork-io/git-xargs/config"
+	"github.com/gruntwork-io/git-xargs/local"
+	"github.com/gruntwork-io/git-xargs/stats"
+	"github.com/gruntwork-io/git-xargs/types"
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/gruntwork-io/go-commons/logging"
+	"github.com/sirupsen/logrus"
+)
+
+// dryRunReportFile is the name of the report --dry-run-output-dir gets, which summarizes every patch written to it
+const dryRunReportFile = "report.md"
+
+// writeDryRunPatch writes the changes the supplied command made to the repo, both in the commit git-xargs made and in
+// any commits the command made itself, to --dry-run-output-dir as a patch series that git am can apply, and records
+// how large it is for the run report
+func writeDryRunPatch(config *config.GitXargsConfig, repositoryDir string, remoteRepository *github.Repository, beforeCommand *plumbing.Reference) error {
+	if !config.DryRun || config.DryRunOutputDir == "" {
+		return nil
+	}
+
+	logger := logging.GetLogger("git-xargs")
+
+	commits, err := commitsSince(repositoryDir, beforeCommand)
+	if err != nil {
+		config.Stats.TrackSingle(stats.PatchWriteFailed, remoteRepository)
+		return err
+	}
+	if len(commits) == 0 {
+		return nil
+	}
+
+	series, err := formatPatchSeries(repositoryDir, beforeCommand)
+	if err != nil {
+		config.Stats.TrackSingle(stats.PatchWriteFailed, remoteRepository)
+		return err
+	}
+
+	patch, err := commandPatch(repositoryDir, beforeCommand)
+	if err != nil {
+		config.Stats.TrackSingle(stats.PatchWriteFailed, remoteRepository)
+		return err
+	}
+
+	path := dryRunPatchPath(config, remoteRepository)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		config.Stats.TrackSingle(stats.PatchWriteFailed, remoteRepository)
+		return errors.WithStackTrace(err)
+	}
+	if err := os.WriteFile(path, []byte(series), 0644); err != nil {
+		config.Stats.TrackSingle(stats.PatchWriteFailed, remoteRepository)
+		return errors.WithStackTrace(err)
+	}
+
+	summary := types.PatchSummary{
+		Repo:       remoteRepository.GetOwner().GetLogin() + "/" + remoteRepository.GetName(),
+		Path:       path,
+		Commits:    len(commits),
+		BaseBranch: baseBranchName(config, remoteRepository),
+		BaseCommit: beforeCommand.Hash().String(),
+	}
+	// go-git leaves binary files out of the stats, which only count lines, so every file changed is counted separately
+	summary.Files = len(patch.FilePatches())
+	for _, fileStat := range patch.Stats() {
+		summary.Additions += fileStat.Addition
+		summary.Deletions += fileStat.Deletion
+	}
+
+	logger.WithFields(logrus.Fields{
+		"Repo":  remoteRepository.GetName(),
+		"Patch": path,
+	}).Debug("Wrote the command's changes to a patch because --dry-run-output-dir is set")
+
+	config.Stats.TrackPatch(summary)
+	return nil
+}
+
+// dryRunPatchPath returns where in --dry-run-output-dir the patch of the given repo's changes is written
+func dryRunPatchPath(config *config.GitXargsConfig, remoteRepository *github.Repository) string {
+	return filepath.Join(config.DryRunOutputDir, remoteRepository.GetOwner().GetLogin(), remoteRepository.GetName()+".patch")
+}
+
+// commitsSince returns the commits made on top of the commit the branch was at before the supplied command ran, oldest
+// first. Like git format-patch, it leaves out merge commits
+func commitsSince(repositoryDir string, beforeCommand *plumbing.Reference) ([]*object.Commit, error) {
+	localRepository, err := git.PlainOpen(repositoryDir)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+
+	head, err := localRepository.Head()
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	if head.Hash() == beforeCommand.Hash() {
+		return nil, nil
+	}
+
+	log, err := localRepository.Log(&git.LogOptions{From: head.Hash()})
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+
+	var commits []*object.Commit
+	iterErr := log.ForEach(func(commit *object.Commit) error {
+		if commit.Hash == beforeCommand.Hash() {
+			return storer.ErrStop
+		}
+		if commit.NumParents() <= 1 {
+			commits = append([]*object.Commit{commit}, commits...)
+		}
+		return nil
+	})
+	return commits, errors.WithStackTrace(iterErr)
+}
+
+// formatPatchSeries renders the commits made on top of the commit the branch was at before the supplied command ran
+// with git format-patch, so that git am can apply them with their authors and messages intact, and with the contents
+// of any binary files they change
+func formatPatchSeries(repositoryDir string, beforeCommand *plumbing.Reference) (string, error) {
+	return local.RunGitCommand(repositoryDir, "format-patch", "--binary", "--stdout", "--signature=git-xargs", beforeCommand.Hash().String()+"..HEAD")
+}
+
+// WriteDryRunReport writes a report to --dry-run-output-dir that lists how many files and lines each patch written to
+// it changes, followed by every patch, so that the changes a run would make can be reviewed before the real run
+func WriteDryRunReport(config *config.GitXargsConfig) error {
+	if !config.DryRun || config.DryRunOutputDir == "" {
+		return nil
+	}
+
+	patches := config.Stats.GetPatches()
+
+	var report strings.Builder
+	report.WriteString("# git-xargs dry run\n\n")
+	if len(config.Args) > 0 {
+		report.WriteString(fmt.Sprintf("Command: `%s`\n\n", strings.Join(config.Args, " ")))
+	}
+	if config.CherryPick != "" {
+		report.WriteString(fmt.Sprintf("Cherry-pick: `%s`\n\n", config.CherryPick))
+	}
+	if config.PatchFile != "" {
+		report.WriteString(fmt.Sprintf("Patch: `%s`\n\n", config.PatchFile))
+	}
+	if len(patches) == 0 {
+		report.WriteString("The command made no changes to any repo.\n")
+	} else {
+		report.WriteString(fmt.Sprintf("The command changed %d repos.\n\n", len(patches)))
+		report.WriteString("| Repo | Commits | Files changed | Lines added | Lines removed | Patch |\n")
+		report.WriteString("|------|---------|---------------|-------------|---------------|-------|\n")
+		for _, patch := range patches {
+			report.WriteString(fmt.Sprintf("| %s | %d | %d | +%d | -%d | [%s](%s) |\n", patch.Repo, patch.Commits, patch.Files, patch.Additions, patch.Deletions, filepath.Base(patch.Path), dryRunReportLink(config, patch.Path)))
+		}
+	}
+
+	for _, patch := range patches {
+		contents, err := os.ReadFile(patch.Path)
+		if err != nil {
+			return errors.WithStackTrace(err)
+		}
+
+		// The fence must be longer than any run of backticks in the patch, such as in a changed markdown file
+		fence := "```"
+		for strings.Contains(string(contents), fence) {
+			fence += "`"
+		}
+		report.WriteString(fmt.Sprintf("\n## %s\n\n%sdiff\n%s%s\n", patch.Repo, fence, contents, fence))
+	}
+
+	if err := os.MkdirAll(config.DryRunOutputDir, 0755); err != nil {
+		return errors.WithStackTrace(err)
+	}
+	return errors.WithStackTrace(os.WriteFile(filepath.Join(config.DryRunOutputDir, dryRunReportFile), []byte(report.String()), 0644))
+}
+
+// dryRunReportLink returns the path of the given patch relative to the report, so that the links in it keep working
+// if --dry-run-output-dir is moved or shared
+func dryRunReportLink(config *config.GitXargsConfig, path string) string {
+	relative, err := filepath.Rel(config.DryRunOutputDir, path)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	return filepath.ToSlash(relative)
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gruntwork-io/git-xargs/local"
+	"github.com/gruntwork-io/git-xargs/stats"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDryRunOutputDir ensures a dry run writes a patch of each repo's changes, including the commits the command made
+// itself and changes to binary files, that git am can apply to reproduce the branch that would have been pushed, along
+// with a report of them
+func TestDryRunOutputDir(t *testing.T) {
+	t.Parallel()
+
+	forEachGitBackend(t, func(t *testing.T, provider local.GitProvider) {
+		remoteURL, _ := createTestRemote(t)
+		testConfig, repo := newCloneTestConfig(remoteURL, provider, "dry-run")
+		testConfig.DryRun = true
+		testConfig.DryRunOutputDir = t.TempDir()
+
+		repositoryDir := cloneAndCheckoutTestBranch(t, testConfig, repo)
+		beforeCommand, err := getLocalRepoHeadRef(testConfig, repositoryDir, repo)
+		require.NoError(t, err)
+
+		testConfig.Args = []string{"bash", "-c", `
+set -e
+echo new > modules/new.tf
+git add modules/new.tf
+git -c user.name=script -c user.email=script@example.com commit -q -m "Add new module" -m "Made by the script"
+echo updated > modules/main.tf
+printf '\x00\x01\x02' > modules/plugin.bin
+`}
+		require.NoError(t, executeCommand(testConfig, repositoryDir, repo))
+		require.NoError(t, updateRepo(testConfig, repositoryDir, repo, "refs/heads/dry-run", beforeCommand, beforeCommand, false))
+		require.NoError(t, WriteDryRunReport(testConfig))
+
+		assert.Contains(t, testConfig.Stats.GetRepos()[stats.PushBranchSkipped], repo)
+		patches := testConfig.Stats.GetPatches()
+		require.Len(t, patches, 1)
+		assert.Equal(t, "gruntwork-io/terragrunt", patches[0].Repo)
+		assert.Equal(t, 2, patches[0].Commits)
+		assert.Equal(t, 3, patches[0].Files)
+		assert.Equal(t, 2, patches[0].Additions)
+		assert.Equal(t, 1, patches[0].Deletions)
+
+		patchPath := filepath.Join(testConfig.DryRunOutputDir, "gruntwork-io", "terragrunt.patch")
+		assert.Equal(t, patchPath, patches[0].Path)
+
+		// Applying the patch to the base branch reproduces the branch that would have been pushed
+		reviewDir := t.TempDir()
+		_, err = local.RunGitCommand("", "clone", "-q", remoteURL, reviewDir)
+		require.NoError(t, err)
+		_, err = local.RunGitCommand(reviewDir, "-c", "user.name=reviewer", "-c", "user.email=reviewer@example.com", "am", "-q", patchPath)
+		require.NoError(t, err)
+
+		applied, err := local.RunGitCommand(reviewDir, "rev-parse", "HEAD^{tree}")
+		require.NoError(t, err)
+		expected, err := local.RunGitCommand(repositoryDir, "rev-parse", "HEAD^{tree}")
+		require.NoError(t, err)
+		assert.Equal(t, strings.TrimSpace(expected), strings.TrimSpace(applied))
+		binary, err := os.ReadFile(filepath.Join(reviewDir, "modules", "plugin.bin"))
+		require.NoError(t, err)
+		assert.Equal(t, []byte{0, 1, 2}, binary)
+
+		log, err := local.RunGitCommand(reviewDir, "log", "-2", "--format=%an|%s|%b")
+		require.NoError(t, err)
+		assert.Equal(t, "git-xargs|"+testConfig.CommitMessage+"|\nscript|Add new module|Made by the script", strings.TrimSpace(log))
+
+		report, err := os.ReadFile(filepath.Join(testConfig.DryRunOutputDir, "report.md"))
+		require.NoError(t, err)
+		assert.Contains(t, string(report), "| gruntwork-io/terragrunt | 2 | 3 | +2 | -1 | [terragrunt.patch](gruntwork-io/terragrunt.patch) |")
+		assert.Contains(t, string(report), "## gruntwork-io/terragrunt\n\n```diff\nFrom ")
+		assert.Contains(t, string(report), "+updated")
+	})
+}
@@ -15,8 +15,9 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
-// getFileDefinedRepos converts user-supplied repositories to GitHub API response objects that can be further processed
-func getFileDefinedRepos(GithubClient auth.GithubClient, allowedRepos []*types.AllowedRepo, tracker *stats.RunStats) ([]*github.Repository, error) {
+// getFileDefinedRepos converts user-supplied repositories to GitHub API response objects that can be further processed.
+// The base branch of each repo that was supplied with one is recorded in baseBranches
+func getFileDefinedRepos(GithubClient auth.GithubClient, allowedRepos []*types.AllowedRepo, tracker *stats.RunStats, baseBranches map[string]string) ([]*github.Repository, error) {
 	logger := logging.GetLogger("git-xargs")
 
 	var allRepos []*github.Repository
@@ -59,6 +60,11 @@ func getFileDefinedRepos(GithubClient auth.GithubClient, allowedRepos []*types.A
 				"Name":         allowedRepo.Name,
 			}).Debug("Successfully fetched repo")
 
+			// The repo is keyed by the name GitHub returned, which differs from the supplied one if the repo was renamed
+			if allowedRepo.BaseBranch != "" {
+				baseBranches[repoKey(repo)] = allowedRepo.BaseBranch
+			}
+
 			allRepos = append(allRepos, repo)
 		}
 	}
@@ -32,7 +32,7 @@ func TestGetFileDefinedRepos(t *testing.T) {
 		},
 	}
 
-	githubRepos, reposLookupErr := getFileDefinedRepos(config.GithubClient, allowedRepos, config.Stats)
+	githubRepos, reposLookupErr := getFileDefinedRepos(config.GithubClient, allowedRepos, config.Stats, config.RepoBaseBranches)
 
 	assert.Equal(t, len(githubRepos), len(allowedRepos))
 	assert.NoError(t, reposLookupErr)
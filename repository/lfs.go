@@ -0,0 +1,195 @@
+package repository
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/google/go-github/v43/github"
+	"github.com/gruntwork-io/git-xargs/config"
+	"github.com/gruntwork-io/git-xargs/stats"
+	"github.com/gruntwork-io/git-xargs/types"
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/gruntwork-io/go-commons/logging"
+	"github.com/sirupsen/logrus"
+)
+
+// repoUsesLFS returns true if any .gitattributes file tracked by the clone routes files through the Git LFS filter.
+// The files are read from the index rather than looked for in the worktree, so that large repos aren't walked in full
+func repoUsesLFS(repositoryDir string) (bool, error) {
+	localRepository, err := git.PlainOpen(repositoryDir)
+	if err != nil {
+		return false, errors.WithStackTrace(err)
+	}
+
+	index, err := localRepository.Storer.Index()
+	if err != nil {
+		return false, errors.WithStackTrace(err)
+	}
+
+	for _, entry := range index.Entries {
+		if path.Base(entry.Name) != ".gitattributes" {
+			continue
+		}
+
+		blob, err := localRepository.BlobObject(entry.Hash)
+		if err != nil {
+			return false, errors.WithStackTrace(err)
+		}
+		reader, err := blob.Reader()
+		if err != nil {
+			return false, errors.WithStackTrace(err)
+		}
+		contents, err := io.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			return false, errors.WithStackTrace(err)
+		}
+
+		if attributesUseLFS(contents) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// commandAddedLFS returns true if the commits made since the command ran add or change a .gitattributes file so that it
+// routes files through the Git LFS filter. Only the .gitattributes files those commits touch are read from the tree
+// that's about to be pushed, so that large repos aren't walked in full
+func commandAddedLFS(repositoryDir string, beforeCommand *plumbing.Reference) (bool, error) {
+	localRepository, err := git.PlainOpen(repositoryDir)
+	if err != nil {
+		return false, errors.WithStackTrace(err)
+	}
+
+	head, err := localRepository.Head()
+	if err != nil {
+		return false, errors.WithStackTrace(err)
+	}
+	if head.Hash() == beforeCommand.Hash() {
+		return false, nil
+	}
+
+	beforeTree, err := commitTree(localRepository, beforeCommand.Hash())
+	if err != nil {
+		return false, err
+	}
+	headTree, err := commitTree(localRepository, head.Hash())
+	if err != nil {
+		return false, err
+	}
+
+	changes, err := object.DiffTree(beforeTree, headTree)
+	if err != nil {
+		return false, errors.WithStackTrace(err)
+	}
+
+	for _, change := range changes {
+		if path.Base(change.To.Name) != ".gitattributes" {
+			continue
+		}
+
+		contents, err := treeFileContents(headTree, change.To.Name)
+		if err != nil {
+			return false, err
+		}
+		if attributesUseLFS(contents) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// attributesUseLFS returns true if any pattern in the given .gitattributes contents sets the LFS filter
+func attributesUseLFS(contents []byte) bool {
+	scanner := bufio.NewScanner(bytes.NewReader(contents))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		for _, attribute := range fields[1:] {
+			if attribute == "filter=lfs" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// fetchLFSObjects downloads the Git LFS objects of a repo that uses LFS, so that the supplied command sees file contents
+// rather than LFS pointers, and returns whether it does, so that its LFS objects are pushed along with the branch.
+// Repos the chosen git backend can't safely update are skipped rather than risk committing files tracked by LFS as
+// regular files, or pointers as file contents
+func fetchLFSObjects(config *config.GitXargsConfig, repositoryDir string, remoteRepository *github.Repository) (bool, error) {
+	logger := logging.GetLogger("git-xargs")
+
+	usesLFS, err := repoUsesLFS(repositoryDir)
+	if err != nil {
+		config.Stats.TrackSingle(stats.LFSFetchFailed, remoteRepository)
+		return false, err
+	}
+	if !usesLFS {
+		return false, nil
+	}
+
+	gitAuth, authErr := getGitAuth(config, remoteRepository)
+	if authErr != nil {
+		config.Stats.TrackSingle(stats.LFSFetchFailed, remoteRepository)
+		return false, errors.WithStackTrace(authErr)
+	}
+
+	fo := &git.FetchOptions{
+		RemoteName:   "origin",
+		Auth:         gitAuth,
+		CABundle:     config.GithubServer.CABundle(),
+		ProxyOptions: getProxyOptions(config),
+	}
+
+	if pullErr := config.GitClient.LFSPull(repositoryDir, fo); pullErr != nil {
+		logger.WithFields(logrus.Fields{
+			"Error": pullErr,
+			"Repo":  remoteRepository.GetName(),
+		}).Debug("Error fetching LFS objects")
+
+		if notSupported, ok := errors.Unwrap(pullErr).(types.LFSNotSupportedErr); ok {
+			config.Stats.TrackSingleWithDetail(stats.LFSRepoSkipped, remoteRepository, notSupported.Reason)
+		} else {
+			config.Stats.TrackSingle(stats.LFSFetchFailed, remoteRepository)
+		}
+		return false, errors.WithStackTrace(pullErr)
+	}
+
+	config.Stats.TrackSingle(stats.LFSObjectsFetched, remoteRepository)
+	return true, nil
+}
+
+// pushLFSObjects uploads the Git LFS objects of the given branch of a repo that uses LFS, so that the branch doesn't
+// refer to objects the remote doesn't have
+func pushLFSObjects(config *config.GitXargsConfig, repositoryDir string, remoteRepository *github.Repository, po *git.PushOptions) error {
+	logger := logging.GetLogger("git-xargs")
+
+	branchName := plumbing.NewBranchReferenceName(config.BranchName)
+	lfsPushOptions := *po
+	lfsPushOptions.RefSpecs = []gitconfig.RefSpec{gitconfig.RefSpec(branchName + ":" + branchName)}
+
+	if pushErr := config.GitClient.LFSPush(repositoryDir, &lfsPushOptions); pushErr != nil {
+		logger.WithFields(logrus.Fields{
+			"Error": pushErr,
+			"Repo":  remoteRepository.GetName(),
+		}).Debug("Error pushing LFS objects")
+
+		config.Stats.TrackSingle(stats.LFSPushFailed, remoteRepository)
+		return errors.WithStackTrace(pushErr)
+	}
+
+	return nil
+}
@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/gruntwork-io/git-xargs/config"
+	"github.com/gruntwork-io/git-xargs/local"
+	"github.com/gruntwork-io/git-xargs/mocks"
+	"github.com/gruntwork-io/git-xargs/stats"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAttributesUseLFS(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		contents string
+		expected bool
+	}{
+		{"*.psd filter=lfs diff=lfs merge=lfs -text\n", true},
+		{"*.sh text eol=lf\n\nassets/** filter=lfs\n", true},
+		{"# *.psd filter=lfs diff=lfs merge=lfs -text\n", false},
+		{"*.go text diff=golang\n", false},
+		{"filter=lfs\n", false},
+		{"", false},
+	}
+
+	for _, testCase := range testCases {
+		assert.Equal(t, testCase.expected, attributesUseLFS([]byte(testCase.contents)), testCase.contents)
+	}
+}
+
+// TestRepoUsesLFS ensures only the .gitattributes files tracked by the clone are checked for the LFS filter
+func TestRepoUsesLFS(t *testing.T) {
+	t.Parallel()
+
+	repositoryDir := t.TempDir()
+	_, err := local.RunGitCommand(repositoryDir, "init", "-q")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(repositoryDir, ".gitattributes"), []byte("*.sh text eol=lf\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(repositoryDir, ".git", "info", "attributes"), []byte("* filter=lfs\n"), 0644))
+	_, err = local.RunGitCommand(repositoryDir, "add", ".gitattributes")
+	require.NoError(t, err)
+
+	usesLFS, err := repoUsesLFS(repositoryDir)
+	require.NoError(t, err)
+	assert.False(t, usesLFS)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(repositoryDir, "assets"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(repositoryDir, "assets", ".gitattributes"), []byte("*.png filter=lfs diff=lfs merge=lfs -text\n"), 0644))
+
+	usesLFS, err = repoUsesLFS(repositoryDir)
+	require.NoError(t, err)
+	assert.False(t, usesLFS)
+
+	_, err = local.RunGitCommand(repositoryDir, "add", "assets/.gitattributes")
+	require.NoError(t, err)
+
+	usesLFS, err = repoUsesLFS(repositoryDir)
+	require.NoError(t, err)
+	assert.True(t, usesLFS)
+}
+
+// TestFetchLFSObjectsSkipsGoGitBackend ensures repos that use LFS are skipped, rather than updated, by go-git, which
+// would commit LFS pointers and file contents interchangeably
+func TestFetchLFSObjectsSkipsGoGitBackend(t *testing.T) {
+	t.Parallel()
+
+	testConfig := config.NewGitXargsTestConfig()
+	testConfig.GitClient = local.NewGitClient(local.GitProductionProvider{})
+	repo := mocks.GetMockGithubRepo()
+
+	repositoryDir := t.TempDir()
+	_, err := local.RunGitCommand(repositoryDir, "init", "-q")
+	require.NoError(t, err)
+	usesLFS, err := fetchLFSObjects(testConfig, repositoryDir, repo)
+	require.NoError(t, err)
+	assert.False(t, usesLFS)
+	assert.Empty(t, testConfig.Stats.GetRepos()[stats.LFSRepoSkipped])
+
+	require.NoError(t, os.WriteFile(filepath.Join(repositoryDir, ".gitattributes"), []byte("*.psd filter=lfs diff=lfs merge=lfs -text\n"), 0644))
+	_, err = local.RunGitCommand(repositoryDir, "add", ".gitattributes")
+	require.NoError(t, err)
+
+	_, err = fetchLFSObjects(testConfig, repositoryDir, repo)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--git-backend cli")
+
+	skipped := testConfig.Stats.GetRepos()[stats.LFSRepoSkipped]
+	require.Len(t, skipped, 1)
+	assert.Contains(t, testConfig.Stats.GetDetail(stats.LFSRepoSkipped, skipped[0]), "go-git")
+}
+
+// TestCommandAddedLFS ensures a .gitattributes file committed since the command ran is checked for the LFS filter
+func TestCommandAddedLFS(t *testing.T) {
+	t.Parallel()
+
+	repositoryDir := t.TempDir()
+	_, err := local.RunGitCommand(repositoryDir, "init", "-q")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(repositoryDir, ".gitattributes"), []byte("*.sh text eol=lf\n"), 0644))
+	_, err = local.RunGitCommand(repositoryDir, "add", ".gitattributes")
+	require.NoError(t, err)
+	_, err = local.RunGitCommand(repositoryDir, "-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "initial")
+	require.NoError(t, err)
+
+	localRepository, err := git.PlainOpen(repositoryDir)
+	require.NoError(t, err)
+	beforeCommand, err := localRepository.Head()
+	require.NoError(t, err)
+
+	addedLFS, err := commandAddedLFS(repositoryDir, beforeCommand)
+	require.NoError(t, err)
+	assert.False(t, addedLFS)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(repositoryDir, "assets"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(repositoryDir, "assets", ".gitattributes"), []byte("*.png filter=lfs diff=lfs merge=lfs -text\n"), 0644))
+	_, err = local.RunGitCommand(repositoryDir, "add", "assets/.gitattributes")
+	require.NoError(t, err)
+	_, err = local.RunGitCommand(repositoryDir, "-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "track images with LFS")
+	require.NoError(t, err)
+
+	addedLFS, err = commandAddedLFS(repositoryDir, beforeCommand)
+	require.NoError(t, err)
+	assert.True(t, addedLFS)
+}
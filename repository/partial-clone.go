@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/gruntwork-io/git-xargs/config"
+	"github.com/gruntwork-io/git-xargs/local"
+	"github.com/gruntwork-io/go-commons/errors"
+)
+
+// fullHistoryDepth is the depth git itself asks for when unshallowing a clone, which fetches all of a branch's history
+const fullHistoryDepth = 2147483647
+
+// isPartialClone returns true if the user asked for clones that don't contain every commit of every branch, in which
+// case pulling the remote branch may need to fetch history the clone doesn't have
+func isPartialClone(config *config.GitXargsConfig) bool {
+	return config.CloneDepth > 0 || config.SingleBranch
+}
+
+// isSparseCheckout returns true if the user asked for only some directories of each repo to be checked out. go-git
+// neither understands sparse checkouts nor preserves them, and would treat every file one leaves out as deleted, so
+// while one is in use, every operation that reads or updates the worktree, such as looking up its status or staging
+// changes, is delegated to git itself
+func isSparseCheckout(config *config.GitXargsConfig) bool {
+	return len(config.SparsePaths) > 0
+}
+
+// sparseCheckoutPatterns returns the sparse-checkout patterns that check out the given directories, along with the files
+// at the root of the repo. These are the same patterns `git sparse-checkout set` writes in cone mode
+func sparseCheckoutPatterns(paths []string) string {
+	patterns := []string{"/*", "!/*/"}
+	for _, path := range paths {
+		patterns = append(patterns, fmt.Sprintf("/%s/", strings.Trim(filepath.ToSlash(filepath.Clean(path)), "/")))
+	}
+	return strings.Join(patterns, "\n") + "\n"
+}
+
+// configureSparseCheckout populates the worktree of a clone that was made without a checkout, writing out only the
+// given directories and the files at the root of the repo. The sparse-checkout settings are written directly to the
+// repo's config rather than via `git sparse-checkout`, which stores them in a per-worktree config that go-git discards
+// the next time it saves the repo's config
+func configureSparseCheckout(repositoryDir string, paths []string) error {
+	if _, err := local.RunGitCommand(repositoryDir, "config", "core.sparseCheckout", "true"); err != nil {
+		return err
+	}
+
+	infoDir := filepath.Join(repositoryDir, ".git", "info")
+	if err := os.MkdirAll(infoDir, 0755); err != nil {
+		return errors.WithStackTrace(err)
+	}
+	if err := os.WriteFile(filepath.Join(infoDir, "sparse-checkout"), []byte(sparseCheckoutPatterns(paths)), 0644); err != nil {
+		return errors.WithStackTrace(err)
+	}
+
+	_, err := local.RunGitCommand(repositoryDir, "read-tree", "-mu", "HEAD")
+	return err
+}
+
+// stageSparseChanges stages every change in the sparse worktree. Files outside the sparse directories are staged too,
+// as the supplied command must have written them deliberately, but files that were never checked out are not treated
+// as deleted
+func stageSparseChanges(repositoryDir string) error {
+	_, err := local.RunGitCommand(repositoryDir, "add", "--all", "--sparse")
+	return err
+}
+
+// remoteBranchRefSpec returns the refspec that fetches the given branch into its remote-tracking branch
+func remoteBranchRefSpec(branchName plumbing.ReferenceName) gitconfig.RefSpec {
+	return gitconfig.RefSpec(fmt.Sprintf("+%s:refs/remotes/origin/%s", branchName, branchName.Short()))
+}
+
+// fetchRemoteBranch fetches the given branch from origin. If deepen is true, the branch's full history is fetched,
+// which fills in the commits a shallow clone is missing. It returns plumbing.ErrReferenceNotFound if the branch doesn't
+// exist on the remote
+func fetchRemoteBranch(config *config.GitXargsConfig, repositoryDir string, branchName plumbing.ReferenceName, gitAuth transport.AuthMethod, deepen bool) error {
+	fo := &git.FetchOptions{
+		RemoteName:   "origin",
+		RefSpecs:     []gitconfig.RefSpec{remoteBranchRefSpec(branchName)},
+		Auth:         gitAuth,
+		CABundle:     config.GithubServer.CABundle(),
+		ProxyOptions: getProxyOptions(config),
+	}
+	if deepen {
+		fo.Depth = fullHistoryDepth
+	}
+
+	err := config.GitClient.Fetch(repositoryDir, fo)
+	if err == git.NoErrAlreadyUpToDate {
+		return nil
+	}
+	if _, ok := err.(git.NoMatchingRefSpecError); ok {
+		return plumbing.ErrReferenceNotFound
+	}
+	return err
+}
+
+// pullRemoteBranch brings the local branch up to date with the branch of the same name on origin, if there is one.
+// Sparse checkouts are fetched with go-git but fast-forwarded with git, which leaves the directories outside the sparse
+// checkout alone
+func pullRemoteBranch(config *config.GitXargsConfig, repositoryDir string, po *git.PullOptions, deepen bool) error {
+	if !isSparseCheckout(config) {
+		if deepen {
+			// go-git's pull only fetches what the clone's refspecs cover, and only as deep as the clone, so fetch the
+			// branch with its full history first
+			if err := fetchRemoteBranch(config, repositoryDir, po.ReferenceName, po.Auth, true); err != nil {
+				return err
+			}
+		}
+		return config.GitClient.Pull(repositoryDir, po)
+	}
+
+	if err := fetchRemoteBranch(config, repositoryDir, po.ReferenceName, po.Auth, deepen); err != nil {
+		return err
+	}
+
+	_, err := local.RunGitCommand(repositoryDir, "merge", "--ff-only", "--quiet", fmt.Sprintf("refs/remotes/origin/%s", po.ReferenceName.Short()))
+	return err
+}
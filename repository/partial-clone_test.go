@@ -0,0 +1,192 @@
+package repository
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v43/github"
+	"github.com/gruntwork-io/git-xargs/common"
+	"github.com/gruntwork-io/git-xargs/config"
+	"github.com/gruntwork-io/git-xargs/local"
+	"github.com/gruntwork-io/git-xargs/stats"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createTestRemote creates a bare repo with a few commits touching two directories on main, and a branch named
+// existing-branch that is several commits ahead of main. It returns the repo's file:// URL and the tip of the branch
+func createTestRemote(t *testing.T) (string, string) {
+	remoteDir := filepath.Join(t.TempDir(), "remote.git")
+	workDir := t.TempDir()
+
+	script := `
+set -e
+git init -q --bare "$REMOTE"
+git -C "$REMOTE" symbolic-ref HEAD refs/heads/main
+# Like GitHub, let any commit be fetched by its SHA, which go-git only does if the server says it can
+git -C "$REMOTE" config uploadpack.allowReachableSHA1InWant true
+git init -q -b main .
+mkdir modules docs
+for i in 1 2 3; do
+  echo "$i" > modules/main.tf
+  echo "$i" > docs/README.md
+  git add -A
+  git commit -q -m "commit $i"
+done
+git push -q "$REMOTE" main
+git checkout -q -b existing-branch
+for i in 1 2; do
+  echo "$i" > docs/branch.md
+  git add -A
+  git commit -q -m "branch commit $i"
+done
+git push -q "$REMOTE" existing-branch
+git rev-parse HEAD
+`
+	cmd := exec.Command("bash", "-c", script)
+	cmd.Dir = workDir
+	cmd.Env = append(os.Environ(), "REMOTE="+remoteDir)
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	return "file://" + remoteDir, lines[len(lines)-1]
+}
+
+// forEachGitBackend runs the given test against each git backend
+func forEachGitBackend(t *testing.T, test func(t *testing.T, provider local.GitProvider)) {
+	providers := map[string]local.GitProvider{
+		common.GitBackendGoGit: local.GitProductionProvider{},
+		common.GitBackendCLI:   local.GitCLIProvider{},
+	}
+
+	for name, provider := range providers {
+		provider := provider
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			test(t, provider)
+		})
+	}
+}
+
+// newCloneTestConfig returns the config of a run that clones the test remote in full with the given git backend, and
+// commits to the given branch as git-xargs without opening a pull request, along with the repo to clone
+func newCloneTestConfig(remoteURL string, provider local.GitProvider, branchName string) (*config.GitXargsConfig, *github.Repository) {
+	testConfig := config.NewGitXargsTestConfig()
+	testConfig.GitClient = local.NewGitClient(provider)
+	testConfig.BranchName = branchName
+	testConfig.SkipPullRequests = true
+	testConfig.CommitAuthorName = "git-xargs"
+	testConfig.CommitAuthorEmail = "git-xargs@example.com"
+
+	repo := getMockGithubRepo()
+	repo.CloneURL = &remoteURL
+
+	return testConfig, repo
+}
+
+func newPartialCloneTestConfig(remoteURL string, provider local.GitProvider, branchName string) (*config.GitXargsConfig, *github.Repository) {
+	testConfig, repo := newCloneTestConfig(remoteURL, provider, branchName)
+	testConfig.CloneDepth = 1
+	testConfig.SingleBranch = true
+	testConfig.SparsePaths = []string{"modules"}
+	return testConfig, repo
+}
+
+func cloneAndCheckoutTestBranch(t *testing.T, testConfig *config.GitXargsConfig, repo *github.Repository) string {
+	repositoryDir, err := cloneLocalRepository(testConfig, repo)
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(repositoryDir) })
+
+	ref, err := getLocalRepoHeadRef(testConfig, repositoryDir, repo)
+	require.NoError(t, err)
+	_, err = checkoutLocalBranch(testConfig, ref, repositoryDir, repo)
+	require.NoError(t, err)
+
+	return repositoryDir
+}
+
+// TestPartialCloneOfExistingBranch ensures a shallow, single-branch, sparse clone only checks out the requested
+// directory, and that it deepens itself to pull a remote branch whose history it doesn't contain
+func TestPartialCloneOfExistingBranch(t *testing.T) {
+	t.Parallel()
+
+	forEachGitBackend(t, func(t *testing.T, provider local.GitProvider) {
+		remoteURL, branchTip := createTestRemote(t)
+		testConfig, repo := newPartialCloneTestConfig(remoteURL, provider, "existing-branch")
+
+		repositoryDir := cloneAndCheckoutTestBranch(t, testConfig, repo)
+
+		assert.DirExists(t, filepath.Join(repositoryDir, "modules"))
+		assert.NoDirExists(t, filepath.Join(repositoryDir, "docs"))
+
+		head, err := local.RunGitCommand(repositoryDir, "rev-parse", "HEAD")
+		require.NoError(t, err)
+		assert.Equal(t, branchTip, strings.TrimSpace(head))
+	})
+}
+
+// TestPartialCloneCommitsOnlyScriptChanges ensures that the files a sparse checkout leaves out aren't committed as
+// deletions, while everything the script changed is committed and can be pushed as a new branch
+func TestPartialCloneCommitsOnlyScriptChanges(t *testing.T) {
+	t.Parallel()
+
+	forEachGitBackend(t, func(t *testing.T, provider local.GitProvider) {
+		remoteURL, _ := createTestRemote(t)
+		testConfig, repo := newPartialCloneTestConfig(remoteURL, provider, "new-branch")
+
+		repositoryDir := cloneAndCheckoutTestBranch(t, testConfig, repo)
+		assert.Contains(t, testConfig.Stats.GetRepos()[stats.BranchRemoteDidntExistYet], repo)
+
+		require.NoError(t, os.WriteFile(filepath.Join(repositoryDir, "modules", "main.tf"), []byte("updated"), 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(repositoryDir, "CHANGELOG.md"), []byte("new"), 0644))
+
+		commitTestChanges(t, testConfig, repositoryDir, repo)
+
+		changed, err := local.RunGitCommand(repositoryDir, "show", "--name-status", "--format=", "HEAD")
+		require.NoError(t, err)
+		assert.Equal(t, "A\tCHANGELOG.md\nM\tmodules/main.tf", strings.TrimSpace(changed))
+
+		require.NoError(t, pushLocalBranch(testConfig, repo, repositoryDir, false, nil))
+		remoteHead, err := local.RunGitCommand(repositoryDir, "ls-remote", remoteURL, "refs/heads/"+testConfig.BranchName)
+		require.NoError(t, err)
+		assert.NotEmpty(t, remoteHead)
+	})
+}
+
+func TestSparseCheckoutPatterns(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "/*\n!/*/\n/modules/vpc/\n/docs/\n", sparseCheckoutPatterns([]string{"modules/vpc/", "./docs"}))
+}
+
+// TestShallowCloneCanBePushed ensures a branch committed in a shallow, single-branch clone can still be pushed, and
+// that the clone deepens itself to pull a remote branch without a sparse checkout too
+func TestShallowCloneCanBePushed(t *testing.T) {
+	t.Parallel()
+
+	forEachGitBackend(t, func(t *testing.T, provider local.GitProvider) {
+		remoteURL, branchTip := createTestRemote(t)
+		testConfig, repo := newPartialCloneTestConfig(remoteURL, provider, "existing-branch")
+		testConfig.SparsePaths = nil
+
+		repositoryDir := cloneAndCheckoutTestBranch(t, testConfig, repo)
+
+		head, err := local.RunGitCommand(repositoryDir, "rev-parse", "HEAD")
+		require.NoError(t, err)
+		assert.Equal(t, branchTip, strings.TrimSpace(head))
+
+		require.NoError(t, os.WriteFile(filepath.Join(repositoryDir, "docs", "README.md"), []byte("updated"), 0644))
+		commitTestChanges(t, testConfig, repositoryDir, repo)
+		require.NoError(t, pushLocalBranch(testConfig, repo, repositoryDir, false, nil))
+
+		localHead, err := local.RunGitCommand(repositoryDir, "rev-parse", "HEAD")
+		require.NoError(t, err)
+		remoteHead, err := local.RunGitCommand(repositoryDir, "ls-remote", remoteURL, "refs/heads/existing-branch")
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(remoteHead, strings.TrimSpace(localHead)))
+	})
+}
@@ -0,0 +1,238 @@
+package repository
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/google/go-github/v43/github"
+	"github.com/gruntwork-io/git-xargs/config"
+	"github.com/gruntwork-io/git-xargs/local"
+	"github.com/gruntwork-io/git-xargs/stats"
+	"github.com/gruntwork-io/git-xargs/types"
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/gruntwork-io/go-commons/logging"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// patchContextLines is how many lines of context diff tools write around each change by default, which --fuzz
+	// allows to not match
+	patchContextLines = 3
+	// maxReportedRejectedHunks is how many of the hunks of a patch that didn't apply to a repo are listed in the run report
+	maxReportedRejectedHunks = 20
+)
+
+var (
+	// patchSeriesStart matches the first line of each patch in a series written by git format-patch
+	patchSeriesStart = regexp.MustCompile(`^From [0-9a-f]{40} `)
+	// rejectedPatchFile and rejectedHunk match what git apply --reject prints about a file whose hunks didn't apply
+	rejectedPatchFile = regexp.MustCompile(`^(?:Applying|Checking) patch (.+?)(?: with \d+ rejects?)?\.\.\.$`)
+	rejectedHunk      = regexp.MustCompile(`^Rejected hunk #(\d+)\.$`)
+)
+
+// applyPatchFile applies the patch passed to `git-xargs apply-patch` to the repo, in place of running a command. A
+// patch that doesn't apply is reported with types.PatchDidNotApplyErr, along with the hunks that were rejected, and the
+// repo is left as it was
+func applyPatchFile(config *config.GitXargsConfig, repositoryDir string, remoteRepository *github.Repository) error {
+	logger := logging.GetLogger("git-xargs")
+
+	rejected, applyErr := applyPatch(config, repositoryDir, config.PatchFile)
+	if applyErr == nil {
+		return nil
+	}
+
+	logger.WithFields(logrus.Fields{
+		"Error": applyErr,
+		"Repo":  remoteRepository.GetName(),
+		"Patch": config.PatchFile,
+	}).Debug("Error applying the patch")
+
+	config.Stats.TrackSingleWithDetail(stats.PatchDidNotApply, remoteRepository, patchFailureSummary(applyErr, rejected))
+	return errors.WithStackTrace(types.PatchDidNotApplyErr{Patch: filepath.Base(config.PatchFile), Underlying: applyErr})
+}
+
+// applyPatch applies the patch at the given absolute path to the repo. A series written by git format-patch is applied
+// patch by patch, keeping their authors and messages, while the changes in a plain unified diff are left in the
+// worktree for git-xargs to commit. If the patch doesn't apply, the repo is left as it was, and the hunks that were
+// rejected, if git could tell, are returned as "<file> hunk #<n>"
+func applyPatch(config *config.GitXargsConfig, repositoryDir string, patchPath string) ([]string, error) {
+	series, err := isPatchSeries(patchPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// The commits keep the patches' authors, but are committed by whoever git-xargs commits as
+	args := committerArgs(config)
+	if series {
+		args = append(args, "am", "--quiet", "--keep-cr")
+	} else {
+		args = append(args, "apply")
+	}
+	args = append(args, patchApplyOptions(config)...)
+
+	_, applyErr := local.RunGitCommand(repositoryDir, append(args, patchPath)...)
+	if applyErr == nil {
+		return nil, nil
+	}
+
+	// Find out which hunks didn't apply to the patch git stopped at, then put everything back as it was
+	rejectPatch := patchPath
+	if series {
+		currentPatch, err := local.RunGitCommand(repositoryDir, "am", "--show-current-patch=diff")
+		if err != nil {
+			return nil, err
+		}
+		currentPatchFile, err := os.CreateTemp("", "git-xargs-current-patch")
+		if err != nil {
+			return nil, errors.WithStackTrace(err)
+		}
+		defer os.Remove(currentPatchFile.Name())
+		if _, err := currentPatchFile.WriteString(currentPatch); err != nil {
+			currentPatchFile.Close()
+			return nil, errors.WithStackTrace(err)
+		}
+		if err := currentPatchFile.Close(); err != nil {
+			return nil, errors.WithStackTrace(err)
+		}
+		rejectPatch = currentPatchFile.Name()
+	}
+
+	rejected, rejectErr := findRejectedHunks(config, repositoryDir, rejectPatch)
+	if rejectErr != nil {
+		return nil, rejectErr
+	}
+	if series {
+		if _, err := local.RunGitCommand(repositoryDir, "am", "--abort"); err != nil {
+			return nil, err
+		}
+	}
+	return rejected, applyErr
+}
+
+// committerArgs returns the config arguments that make git commit as whoever git-xargs commits as, for the git commands
+// that make commits of their own, such as git am and git cherry-pick
+func committerArgs(config *config.GitXargsConfig) []string {
+	if config.CommitAuthorName == "" || config.CommitAuthorEmail == "" {
+		return nil
+	}
+	return []string{"-c", "user.name=" + config.CommitAuthorName, "-c", "user.email=" + config.CommitAuthorEmail}
+}
+
+// patchApplyOptions returns the options git am and git apply are given for --fuzz and --3way
+func patchApplyOptions(config *config.GitXargsConfig) []string {
+	options := fuzzOptions(config)
+	if config.PatchThreeWay {
+		options = append(options, "--3way")
+	}
+	return options
+}
+
+// fuzzOptions returns the option that makes git apply require only as many lines of context around each hunk to match
+// as --fuzz leaves, out of the usual three
+func fuzzOptions(config *config.GitXargsConfig) []string {
+	if config.PatchFuzz <= 0 {
+		return nil
+	}
+	return []string{fmt.Sprintf("-C%d", max(patchContextLines-config.PatchFuzz, 0))}
+}
+
+// findRejectedHunks applies as much of the given unified diff as it can, to find out which of its hunks don't apply,
+// then resets the worktree. A three-way merge that failed leaves conflicts behind, so the worktree is reset first, too
+func findRejectedHunks(config *config.GitXargsConfig, repositoryDir string, patchPath string) ([]string, error) {
+	if _, err := local.RunGitCommand(repositoryDir, "reset", "--quiet", "--hard"); err != nil {
+		return nil, err
+	}
+
+	args := append([]string{"apply", "--reject", "--verbose"}, append(fuzzOptions(config), patchPath)...)
+
+	var output string
+	_, err := local.RunGitCommand(repositoryDir, args...)
+	if failure, ok := errors.Unwrap(err).(types.GitCommandFailedErr); ok {
+		output = failure.Stderr
+	}
+
+	var rejected, rejectFiles []string
+	var currentFile string
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if match := rejectedPatchFile.FindStringSubmatch(line); match != nil {
+			currentFile = match[1]
+			if strings.HasPrefix(line, "Applying") && strings.Contains(line, " with ") {
+				rejectFiles = append(rejectFiles, currentFile+".rej")
+			}
+		} else if match := rejectedHunk.FindStringSubmatch(line); match != nil {
+			rejected = append(rejected, fmt.Sprintf("%s hunk #%s", currentFile, match[1]))
+		}
+	}
+
+	// git apply --reject applies every hunk it can, and leaves the rest in .rej files next to the files they belong to
+	if _, err := local.RunGitCommand(repositoryDir, "reset", "--quiet", "--hard"); err != nil {
+		return nil, err
+	}
+	for _, rejectFile := range rejectFiles {
+		if err := os.Remove(filepath.Join(repositoryDir, filepath.FromSlash(rejectFile))); err != nil && !os.IsNotExist(err) {
+			return nil, errors.WithStackTrace(err)
+		}
+	}
+	if _, err := local.RunGitCommand(repositoryDir, "clean", "--quiet", "--force", "-d"); err != nil {
+		return nil, err
+	}
+	return rejected, nil
+}
+
+// isPatchSeries returns true if the patch at the given path is a series written by git format-patch, rather than a
+// plain unified diff
+func isPatchSeries(patchPath string) (bool, error) {
+	file, err := os.Open(patchPath)
+	if err != nil {
+		return false, errors.WithStackTrace(err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if strings.TrimSpace(scanner.Text()) != "" {
+			return patchSeriesStart.MatchString(scanner.Text()), nil
+		}
+	}
+	return false, errors.WithStackTrace(scanner.Err())
+}
+
+// patchFailureSummary describes why a patch didn't apply on one line, so that it fits in the run report: the hunks
+// that were rejected, if git could tell, or else what git said
+func patchFailureSummary(err error, rejected []string) string {
+	if len(rejected) == 0 {
+		return gitFailureSummary(err)
+	}
+
+	detail := "rejected " + strings.Join(rejected, ", ")
+	if len(rejected) > maxReportedRejectedHunks {
+		detail = fmt.Sprintf("rejected %s and %d more", strings.Join(rejected[:maxReportedRejectedHunks], ", "), len(rejected)-maxReportedRejectedHunks)
+	}
+	return detail
+}
+
+// gitFailureSummary returns the lines of a failed git command's output that say why it failed, on one line, so that
+// they fit in the run report
+func gitFailureSummary(err error) string {
+	failure, ok := errors.Unwrap(err).(types.GitCommandFailedErr)
+	if !ok {
+		return err.Error()
+	}
+
+	var reasons []string
+	for _, line := range strings.Split(failure.Stderr, "\n") {
+		if strings.HasPrefix(line, "error:") || strings.HasPrefix(line, "fatal:") {
+			reasons = append(reasons, strings.TrimSpace(line))
+		}
+	}
+	if len(reasons) == 0 {
+		return err.Error()
+	}
+	return strings.Join(reasons, " | ")
+}
@@ -0,0 +1,197 @@
+package repository
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v43/github"
+	"github.com/gruntwork-io/git-xargs/config"
+	"github.com/gruntwork-io/git-xargs/local"
+	"github.com/gruntwork-io/git-xargs/stats"
+	"github.com/gruntwork-io/git-xargs/types"
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testUnifiedDiff changes modules/main.tf in the test remote and adds a file, as diff -u or git diff would
+const testUnifiedDiff = `diff --git a/modules/main.tf b/modules/main.tf
+--- a/modules/main.tf
++++ b/modules/main.tf
+@@ -1 +1 @@
+-3
++patched
+diff --git a/docs/new.md b/docs/new.md
+new file mode 100644
+--- /dev/null
++++ b/docs/new.md
+@@ -0,0 +1 @@
++new
+`
+
+// writeTestPatch writes the given patch to a file and returns its path
+func writeTestPatch(t *testing.T, patch string) string {
+	path := filepath.Join(t.TempDir(), "changes.patch")
+	require.NoError(t, os.WriteFile(path, []byte(patch), 0644))
+	return path
+}
+
+// formatTestPatch commits the given contents to a file on top of the test remote's main branch, as someone other than
+// git-xargs, and returns the path of the commit written by git format-patch. The commit is pushed to a branch of its
+// own, so that main doesn't contain it
+func formatTestPatch(t *testing.T, remoteURL string, file string, contents string) string {
+	remoteDir := strings.TrimPrefix(remoteURL, "file://")
+	_, err := local.RunGitCommand(remoteDir, "branch", "--force", "patch-source", "main")
+	require.NoError(t, err)
+
+	commit := commitToTestRemote(t, remoteURL, "patch-source", "Patch Author <patch@example.com>", file, contents)
+	series, err := local.RunGitCommand(remoteDir, "format-patch", "-1", "--stdout", commit)
+	require.NoError(t, err)
+	return writeTestPatch(t, series)
+}
+
+// newApplyPatchTestConfig returns the config of a run applying the given patch to the test remote, along with a clone
+// of it to apply the patch to
+func newApplyPatchTestConfig(t *testing.T, remoteURL string, provider local.GitProvider, patchPath string) (*config.GitXargsConfig, *github.Repository, string) {
+	testConfig, repo := newCloneTestConfig(remoteURL, provider, "patched")
+	testConfig.PatchFile = patchPath
+	return testConfig, repo, cloneAndCheckoutTestBranch(t, testConfig, repo)
+}
+
+// assertTestRepoUnchanged ensures a patch that didn't apply left the repo just as it was before
+func assertTestRepoUnchanged(t *testing.T, testConfig *config.GitXargsConfig, repositoryDir string, repo *github.Repository, beforeCommand string) {
+	head, err := getLocalRepoHeadRef(testConfig, repositoryDir, repo)
+	require.NoError(t, err)
+	assert.Equal(t, beforeCommand, head.Hash().String())
+
+	status, err := local.RunGitCommand(repositoryDir, "status", "--porcelain", "--ignored")
+	require.NoError(t, err)
+	assert.Empty(t, strings.TrimSpace(status))
+	assert.NoDirExists(t, filepath.Join(repositoryDir, ".git", "rebase-apply"))
+}
+
+// TestApplyPatchFile ensures the changes in a unified diff are committed with git-xargs' commit message, and that a
+// format-patch series is pushed with its own authors and messages
+func TestApplyPatchFile(t *testing.T) {
+	t.Parallel()
+
+	forEachGitBackend(t, func(t *testing.T, provider local.GitProvider) {
+		remoteURL, _ := createTestRemote(t)
+
+		testConfig, repo, repositoryDir := newApplyPatchTestConfig(t, remoteURL, provider, writeTestPatch(t, testUnifiedDiff))
+		beforeCommand, err := getLocalRepoHeadRef(testConfig, repositoryDir, repo)
+		require.NoError(t, err)
+
+		require.NoError(t, applyPatchFile(testConfig, repositoryDir, repo))
+		require.NoError(t, updateRepo(testConfig, repositoryDir, repo, "refs/heads/patched", beforeCommand, beforeCommand, false))
+
+		tip := remoteBranchTip(t, remoteURL, "patched")
+		contents, err := local.RunGitCommand(repositoryDir, "show", tip+":modules/main.tf")
+		require.NoError(t, err)
+		assert.Equal(t, "patched", strings.TrimSpace(contents))
+		contents, err = local.RunGitCommand(repositoryDir, "show", tip+":docs/new.md")
+		require.NoError(t, err)
+		assert.Equal(t, "new", strings.TrimSpace(contents))
+		message, err := local.RunGitCommand(repositoryDir, "log", "-1", "--format=%an %s", tip)
+		require.NoError(t, err)
+		assert.Equal(t, "git-xargs "+testConfig.CommitMessage, strings.TrimSpace(message))
+
+		seriesRemoteURL, _ := createTestRemote(t)
+		testConfig, repo, repositoryDir = newApplyPatchTestConfig(t, seriesRemoteURL, provider, formatTestPatch(t, seriesRemoteURL, "modules/main.tf", "series"))
+		beforeCommand, err = getLocalRepoHeadRef(testConfig, repositoryDir, repo)
+		require.NoError(t, err)
+
+		require.NoError(t, applyPatchFile(testConfig, repositoryDir, repo))
+		require.NoError(t, updateRepo(testConfig, repositoryDir, repo, "refs/heads/patched", beforeCommand, beforeCommand, false))
+
+		tip = remoteBranchTip(t, seriesRemoteURL, "patched")
+		message, err = local.RunGitCommand(repositoryDir, "log", "--format=%an <%ae> %cn %s", beforeCommand.Hash().String()+".."+tip)
+		require.NoError(t, err)
+		assert.Equal(t, "Patch Author <patch@example.com> git-xargs update modules/main.tf", strings.TrimSpace(message))
+	})
+}
+
+// TestApplyPatchFileRejected ensures a patch that doesn't apply is reported along with the hunks that were rejected,
+// and that none of it is left behind in the repo
+func TestApplyPatchFileRejected(t *testing.T) {
+	t.Parallel()
+
+	forEachGitBackend(t, func(t *testing.T, provider local.GitProvider) {
+		remoteURL, _ := createTestRemote(t)
+		series := formatTestPatch(t, remoteURL, "modules/main.tf", "series")
+		commitToTestRemote(t, remoteURL, "main", "", "modules/main.tf", "moved on")
+
+		for _, patchPath := range []string{writeTestPatch(t, testUnifiedDiff), series} {
+			testConfig, repo, repositoryDir := newApplyPatchTestConfig(t, remoteURL, provider, patchPath)
+			beforeCommand, err := getLocalRepoHeadRef(testConfig, repositoryDir, repo)
+			require.NoError(t, err)
+
+			applyErr := applyPatchFile(testConfig, repositoryDir, repo)
+			require.Error(t, applyErr)
+			assert.IsType(t, types.PatchDidNotApplyErr{}, errors.Unwrap(applyErr))
+			assert.Equal(t, "rejected modules/main.tf hunk #1", testConfig.Stats.GetDetail(stats.PatchDidNotApply, repo))
+			assertTestRepoUnchanged(t, testConfig, repositoryDir, repo, beforeCommand.Hash().String())
+		}
+	})
+}
+
+// TestApplyPatchFileFuzzAndThreeWay ensures --fuzz lets hunks apply whose outer lines of context don't match, and that
+// --3way merges a patch whose context was changed since it was made
+func TestApplyPatchFileFuzzAndThreeWay(t *testing.T) {
+	t.Parallel()
+
+	forEachGitBackend(t, func(t *testing.T, provider local.GitProvider) {
+		remoteURL, _ := createTestRemote(t)
+		commitToTestRemote(t, remoteURL, "main", "", "modules/main.tf", "a\nb\nc\nd\ne\nf\ng")
+
+		fuzzyPatch := writeTestPatch(t, `--- a/modules/main.tf
++++ b/modules/main.tf
+@@ -1,7 +1,7 @@
+ x
+ b
+ c
+-d
++D
+ e
+ f
+ g
+`)
+		testConfig, repo, repositoryDir := newApplyPatchTestConfig(t, remoteURL, provider, fuzzyPatch)
+		require.Error(t, applyPatchFile(testConfig, repositoryDir, repo))
+
+		testConfig, repo, repositoryDir = newApplyPatchTestConfig(t, remoteURL, provider, fuzzyPatch)
+		testConfig.PatchFuzz = 1
+		require.NoError(t, applyPatchFile(testConfig, repositoryDir, repo))
+		contents, err := os.ReadFile(filepath.Join(repositoryDir, "modules", "main.tf"))
+		require.NoError(t, err)
+		assert.Equal(t, "a\nb\nc\nD\ne\nf\ng\n", string(contents))
+
+		series := formatTestPatch(t, remoteURL, "modules/main.tf", "a\nB\nc\nd\ne\nf\ng")
+		commitToTestRemote(t, remoteURL, "main", "", "modules/main.tf", "a\nb\nc\nd\nE\nf\ng")
+
+		testConfig, repo, repositoryDir = newApplyPatchTestConfig(t, remoteURL, provider, series)
+		require.Error(t, applyPatchFile(testConfig, repositoryDir, repo))
+
+		testConfig, repo, repositoryDir = newApplyPatchTestConfig(t, remoteURL, provider, series)
+		testConfig.PatchThreeWay = true
+		require.NoError(t, applyPatchFile(testConfig, repositoryDir, repo))
+		contents, err = os.ReadFile(filepath.Join(repositoryDir, "modules", "main.tf"))
+		require.NoError(t, err)
+		assert.Equal(t, "a\nB\nc\nd\nE\nf\ng\n", string(contents))
+	})
+}
+
+// TestIsPatchSeries ensures a series written by git format-patch is told apart from a plain unified diff
+func TestIsPatchSeries(t *testing.T) {
+	t.Parallel()
+
+	series, err := isPatchSeries(writeTestPatch(t, "\nFrom 0123456789abcdef0123456789abcdef01234567 Mon Sep 17 00:00:00 2001\nFrom: a <a@example.com>\n"))
+	require.NoError(t, err)
+	assert.True(t, series)
+
+	series, err = isPatchSeries(writeTestPatch(t, testUnifiedDiff))
+	require.NoError(t, err)
+	assert.False(t, series)
+}
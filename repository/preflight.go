@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/go-github/v43/github"
+	"github.com/gruntwork-io/git-xargs/common"
+	"github.com/gruntwork-io/git-xargs/config"
+	"github.com/gruntwork-io/git-xargs/stats"
+	"github.com/gruntwork-io/git-xargs/types"
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/gruntwork-io/go-commons/logging"
+	"github.com/sirupsen/logrus"
+)
+
+// preflightCheckRepos checks, before anything is cloned, that git-xargs will be able to push a branch to and open a
+// pull request against each of the supplied repos. When the check is set to skip, repos that fail it are tracked with
+// the reason and left out of the returned slice. When it is set to abort, an error listing every failing repo is
+// returned instead, so that the run stops before any repo has been changed. Repos whose pull requests won't be
+// mergeable because their commits won't be signed are reported too, but still updated
+func preflightCheckRepos(config *config.GitXargsConfig, repos []*github.Repository) ([]*github.Repository, error) {
+	logger := logging.GetLogger("git-xargs")
+
+	var passed []*github.Repository
+	var failures []string
+
+	for _, repo := range repos {
+		problems := preflightCheckRepo(repo)
+		if len(problems) == 0 {
+			reportSigningRequired(config, repo)
+			passed = append(passed, repo)
+			continue
+		}
+
+		detail := strings.Join(problems, "; ")
+
+		logger.WithFields(logrus.Fields{
+			"Repo":     repo.GetName(),
+			"Problems": detail,
+		}).Debug("Repo failed preflight check")
+
+		config.Stats.TrackSingleWithDetail(stats.PreflightCheckFailed, repo, detail)
+		failures = append(failures, fmt.Sprintf("%s/%s (%s)", repo.GetOwner().GetLogin(), repo.GetName(), detail))
+	}
+
+	if len(failures) > 0 && config.PreflightCheck == common.PreflightCheckAbort {
+		return nil, errors.WithStackTrace(types.PreflightCheckFailedErr{Failures: failures})
+	}
+
+	return passed, nil
+}
+
+// preflightCheckRepo returns a description of each problem that would prevent git-xargs from updating the supplied repo
+func preflightCheckRepo(repo *github.Repository) []string {
+	var problems []string
+
+	// The permissions map is only populated when the API knows who is asking, so a missing map tells us nothing
+	permissions := repo.Permissions
+	if permissions != nil {
+		if !permissions["push"] {
+			problems = append(problems, "token cannot push to this repo")
+		}
+		if !permissions["pull"] {
+			problems = append(problems, "token cannot open pull requests against this repo")
+		}
+	}
+
+	return problems
+}
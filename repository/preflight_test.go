@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/google/go-github/v43/github"
+	"github.com/gruntwork-io/git-xargs/common"
+	"github.com/gruntwork-io/git-xargs/config"
+	"github.com/gruntwork-io/git-xargs/mocks"
+	"github.com/gruntwork-io/git-xargs/stats"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func getMockPreflightRepos() []*github.Repository {
+	writable := mocks.GetMockGithubRepo()
+	writable.Permissions = map[string]bool{"pull": true, "push": true}
+
+	readOnly := mocks.GetMockGithubRepo()
+	readOnly.Name = github.String("fetch")
+	readOnly.Permissions = map[string]bool{"pull": true, "push": false}
+
+	archived := mocks.GetMockGithubRepo()
+	archived.Name = github.String("terratest")
+	archived.Archived = github.Bool(true)
+
+	return []*github.Repository{writable, readOnly, archived}
+}
+
+// TestPreflightCheckReposSkip ensures repos that would fail to update are left out of the run and tracked with the
+// reason they failed
+func TestPreflightCheckReposSkip(t *testing.T) {
+	t.Parallel()
+
+	testConfig := config.NewGitXargsTestConfig()
+	testConfig.GithubClient = mocks.ConfigureMockGithubClient()
+	testConfig.PreflightCheck = common.PreflightCheckSkip
+
+	repos, err := preflightCheckRepos(testConfig, getMockPreflightRepos())
+	require.NoError(t, err)
+	require.Len(t, repos, 2)
+	assert.Equal(t, "terragrunt", repos[0].GetName())
+	// Archived repos are only left out with --skip-archived-repos, before the preflight check
+	assert.Equal(t, "terratest", repos[1].GetName())
+
+	failed := testConfig.Stats.GetRepos()[stats.PreflightCheckFailed]
+	require.Len(t, failed, 1)
+	assert.Equal(t, "token cannot push to this repo", testConfig.Stats.GetDetail(stats.PreflightCheckFailed, failed[0]))
+
+	// Whether the base branch requires signed commits is checked for the repos that passed
+	unknown := testConfig.Stats.GetRepos()[stats.SigningRequirementUnknown]
+	require.Len(t, unknown, 1)
+	assert.Equal(t, "terragrunt", unknown[0].GetName())
+}
+
+// TestPreflightCheckReposAbort ensures the run is stopped if any repo fails the check
+func TestPreflightCheckReposAbort(t *testing.T) {
+	t.Parallel()
+
+	testConfig := config.NewGitXargsTestConfig()
+	testConfig.GithubClient = mocks.ConfigureMockGithubClient()
+	testConfig.PreflightCheck = common.PreflightCheckAbort
+
+	_, err := preflightCheckRepos(testConfig, getMockPreflightRepos())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "gruntwork-io/fetch")
+	assert.NotContains(t, err.Error(), "gruntwork-io/terratest")
+}
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"io"
 	"os"
 	"sync"
 	"time"
@@ -41,7 +42,13 @@ func openPullRequestsWithThrottling(gitxargsConfig *config.GitXargsConfig, pr ty
 func ProcessRepos(gitxargsConfig *config.GitXargsConfig, repos []*github.Repository) error {
 	logger := logging.GetLogger("git-xargs")
 
-	p, progressBarErr := pterm.DefaultProgressbar.WithTotal(len(repos)).WithTitle("Processing repos").Start()
+	// The progress bar would redraw over the prompts --interactive shows
+	progressBar := pterm.DefaultProgressbar.WithTotal(len(repos)).WithTitle("Processing repos")
+	if gitxargsConfig.Interactive {
+		progressBar = progressBar.WithWriter(io.Discard)
+	}
+
+	p, progressBarErr := progressBar.Start()
 	if progressBarErr != nil {
 		return progressBarErr
 	}
@@ -98,7 +105,7 @@ func processRepo(config *config.GitXargsConfig, repo *github.Repository) error {
 
 	// Create a new temporary directory in the default temp directory of the system, but append
 	// git-xargs-<repo-name> to it so that it's easier to find when you're looking for it
-	repositoryDir, localRepository, cloneErr := cloneLocalRepository(config, repo)
+	repositoryDir, cloneErr := cloneLocalRepository(config, repo)
 
 	// if user did not pass retention flag, defer cleanup of the repositoryDir
 	if config.RetainLocalRepos == false {
@@ -109,34 +116,55 @@ func processRepo(config *config.GitXargsConfig, repo *github.Repository) error {
 		return cloneErr
 	}
 
-	// Get HEAD ref from the repo
-	ref, headRefErr := getLocalRepoHeadRef(config, localRepository, repo)
+	// Get the ref of the base branch, which is HEAD unless a different base branch was requested
+	ref, headRefErr := resolveBaseRef(config, repositoryDir, repo)
 	if headRefErr != nil {
 		return headRefErr
 	}
 
-	// Get the worktree for the given local repository, so we can examine any changes made by script operations
-	worktree, worktreeErr := getLocalWorkTree(repositoryDir, localRepository, repo)
-
-	if worktreeErr != nil {
-		return worktreeErr
-	}
-
 	// Create a branch in the locally cloned copy of the repo to hold all the changes that may result from script execution
 	// Also, attempt to pull the latest from the remote branch if it exists
-	branchName, branchErr := checkoutLocalBranch(config, ref, worktree, repo, localRepository)
+	branchName, branchErr := checkoutLocalBranch(config, ref, repositoryDir, repo)
 	if branchErr != nil {
 		return branchErr
 	}
 
-	// Run the specified command
-	commandErr := executeCommand(config, repositoryDir, repo)
+	// Download the objects of any files tracked by Git LFS, so that the command sees their contents rather than pointers
+	usesLFS, lfsErr := fetchLFSObjects(config, repositoryDir, repo)
+	if lfsErr != nil {
+		return lfsErr
+	}
+
+	// Remember where the branch was, so that any commits the command makes itself can be told apart
+	beforeCommand, headErr := getLocalRepoHeadRef(config, repositoryDir, repo)
+	if headErr != nil {
+		return headErr
+	}
+
+	// Run the specified command, or apply the patch passed to apply-patch, or cherry-pick the commit passed via
+	// --cherry-pick, or when publishing a bundle, apply the patch the command made when it was generated
+	var commandErr error
+	switch {
+	case config.Bundle != nil:
+		commandErr = applyBundlePatch(config, repositoryDir, repo, beforeCommand)
+	case config.PatchFile != "":
+		commandErr = applyPatchFile(config, repositoryDir, repo)
+	case config.CherryPickSource != nil:
+		commandErr = cherryPickCommit(config, repositoryDir, repo)
+	default:
+		commandErr = executeCommand(config, repositoryDir, repo)
+	}
 	if commandErr != nil {
 		return commandErr
 	}
 
+	// Check the changes with the --validate-cmd command, if any, before committing them
+	if validateErr := validateChanges(config, repositoryDir, repo); validateErr != nil {
+		return validateErr
+	}
+
 	// Commit and push the changes to Git and open a PR
-	if err := updateRepo(config, repositoryDir, worktree, repo, localRepository, branchName.String()); err != nil {
+	if err := updateRepo(config, repositoryDir, repo, branchName.String(), ref, beforeCommand, usesLFS); err != nil {
 		return err
 	}
 
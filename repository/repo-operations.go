@@ -12,29 +12,101 @@ import (
 
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
 	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
 	"github.com/sirupsen/logrus"
 
 	"github.com/google/go-github/v43/github"
 
 	"github.com/gruntwork-io/git-xargs/common"
 	"github.com/gruntwork-io/git-xargs/config"
+	"github.com/gruntwork-io/git-xargs/local"
 	"github.com/gruntwork-io/git-xargs/stats"
 	"github.com/gruntwork-io/git-xargs/types"
 	"github.com/gruntwork-io/go-commons/errors"
 	"github.com/gruntwork-io/go-commons/logging"
 )
 
-// cloneLocalRepository clones a remote GitHub repo via SSH to a local temporary directory so that the supplied command
+// getGitAuth returns the credentials used to clone, pull and push the given repo. Over HTTPS, these are supplied by
+// either the user's personal access token or the GitHub App installation for the repo's owner. Over SSH, they are the
+// user's SSH key, so that the GitHub token is only ever used for API calls
+func getGitAuth(config *config.GitXargsConfig, repo *github.Repository) (transport.AuthMethod, error) {
+	if config.GitTransport == common.GitTransportSSH {
+		return getSSHAuth(config)
+	}
+
+	username, password, err := config.GitCredentials.BasicAuth(repo.GetOwner().GetLogin())
+	if err != nil {
+		return nil, err
+	}
+
+	return &http.BasicAuth{
+		Username: username,
+		Password: password,
+	}, nil
+}
+
+// getSSHAuth returns SSH credentials backed by either the key file passed via --ssh-key-path or, if none was passed,
+// the keys held by the running ssh-agent. GitHub's host key is always verified against the known_hosts file
+func getSSHAuth(config *config.GitXargsConfig) (transport.AuthMethod, error) {
+	var knownHostsFiles []string
+	if config.SSHKnownHostsPath != "" {
+		knownHostsFiles = append(knownHostsFiles, config.SSHKnownHostsPath)
+	}
+
+	hostKeyCallback, err := ssh.NewKnownHostsCallback(knownHostsFiles...)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+
+	if config.SSHKeyPath != "" {
+		publicKeys, err := ssh.NewPublicKeysFromFile(ssh.DefaultUsername, config.SSHKeyPath, os.Getenv(common.SSHKeyPassphraseEnvVar))
+		if err != nil {
+			return nil, errors.WithStackTrace(err)
+		}
+		publicKeys.HostKeyCallback = hostKeyCallback
+		return publicKeys, nil
+	}
+
+	agentAuth, err := ssh.NewSSHAgentAuth(ssh.DefaultUsername)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	agentAuth.HostKeyCallback = hostKeyCallback
+	return agentAuth, nil
+}
+
+// getProxyOptions returns the proxy git operations should connect through. The proxy only applies over HTTPS, because
+// go-git can't tunnel SSH through an HTTP proxy. When no proxy was configured, go-git falls back to the proxy
+// environment variables by itself
+func getProxyOptions(config *config.GitXargsConfig) transport.ProxyOptions {
+	if config.GitTransport == common.GitTransportSSH {
+		return transport.ProxyOptions{}
+	}
+	return transport.ProxyOptions{URL: config.GithubServer.ProxyURL()}
+}
+
+// getCloneURL returns the URL to clone the given repo from, which depends upon the --git-transport in use. URLs are
+// pointed at the configured GitHub Enterprise server's hostname, in case its API reports them with a different one
+func getCloneURL(config *config.GitXargsConfig, repo *github.Repository) string {
+	if config.GitTransport == common.GitTransportSSH {
+		return config.GithubServer.RewriteCloneURL(repo.GetSSHURL())
+	}
+	return config.GithubServer.RewriteCloneURL(repo.GetCloneURL())
+}
+
+// cloneLocalRepository clones a remote GitHub repo over HTTPS or SSH to a local temporary directory so that the supplied command
 // can be run against the repo locally and any git changes handled thereafter. The local directory has
-// git-xargs-<repo-name> appended to it to make it easier to find when you are looking for it while debugging
-func cloneLocalRepository(config *config.GitXargsConfig, repo *github.Repository) (string, *git.Repository, error) {
+// git-xargs-<repo-name> appended to it to make it easier to find when you are looking for it while debugging. If a
+// --cache-dir was supplied, the clone is made from the repo's mirror in the cache rather than from GitHub
+func cloneLocalRepository(config *config.GitXargsConfig, repo *github.Repository) (string, error) {
 	logger := logging.GetLogger("git-xargs")
 	config.CloneJobsLimiter <- struct{}{}
 
 	logger.WithFields(logrus.Fields{
 		"Repo": repo.GetName(),
-	}).Debug("Attempting to clone repository using GITHUB_OAUTH_TOKEN")
+	}).Debug("Attempting to clone repository")
 
 	repositoryDir, tmpDirErr := ioutil.TempDir("", fmt.Sprintf("git-xargs-%s", repo.GetName()))
 	if tmpDirErr != nil {
@@ -42,18 +114,50 @@ func cloneLocalRepository(config *config.GitXargsConfig, repo *github.Repository
 			"Error": tmpDirErr,
 			"Repo":  repo.GetName(),
 		}).Debug("Failed to create temporary directory to hold repo")
-		return repositoryDir, nil, errors.WithStackTrace(tmpDirErr)
+		return repositoryDir, errors.WithStackTrace(tmpDirErr)
+	}
+
+	gitAuth, authErr := getGitAuth(config, repo)
+	if authErr != nil {
+		<-config.CloneJobsLimiter
+
+		logger.WithFields(logrus.Fields{
+			"Error": authErr,
+			"Repo":  repo.GetName(),
+		}).Debug("Failed to obtain git credentials for repo")
+
+		config.Stats.TrackSingle(stats.RepoFailedToClone, repo)
+		return repositoryDir, errors.WithStackTrace(authErr)
 	}
 
 	gitProgressBuffer := bytes.NewBuffer(nil)
-	localRepository, err := config.GitClient.PlainClone(repositoryDir, false, &git.CloneOptions{
-		URL:      repo.GetCloneURL(),
-		Progress: gitProgressBuffer,
-		Auth: &http.BasicAuth{
-			Username: repo.GetOwner().GetLogin(),
-			Password: os.Getenv("GITHUB_OAUTH_TOKEN"),
-		},
-	})
+	cloneOptions := &git.CloneOptions{
+		URL:          getCloneURL(config, repo),
+		Progress:     gitProgressBuffer,
+		Auth:         gitAuth,
+		CABundle:     config.GithubServer.CABundle(),
+		ProxyOptions: getProxyOptions(config),
+		Depth:        config.CloneDepth,
+		SingleBranch: config.SingleBranch,
+		// Sparse checkouts are written out by configureSparseCheckout instead, so that only the requested
+		// directories ever touch the disk
+		NoCheckout: isSparseCheckout(config),
+	}
+
+	var err error
+	if config.CacheDir != "" {
+		err = cloneFromCache(config, repo, repositoryDir, cloneOptions)
+	} else {
+		err = config.GitClient.Clone(repositoryDir, cloneOptions)
+	}
+
+	if err == nil && isSparseCheckout(config) {
+		err = configureSparseCheckout(repositoryDir, config.SparsePaths)
+	}
+
+	if err == nil && config.RecurseSubmodules {
+		err = initSubmodules(config, repositoryDir, gitAuth)
+	}
 
 	logger.WithFields(logrus.Fields{
 		"Repo": repo.GetName(),
@@ -70,20 +174,20 @@ func cloneLocalRepository(config *config.GitXargsConfig, repo *github.Repository
 		// Track failure to clone for our final run report
 		config.Stats.TrackSingle(stats.RepoFailedToClone, repo)
 
-		return repositoryDir, nil, errors.WithStackTrace(err)
+		return repositoryDir, errors.WithStackTrace(err)
 	}
 
 	config.Stats.TrackSingle(stats.RepoSuccessfullyCloned, repo)
 
-	return repositoryDir, localRepository, nil
+	return repositoryDir, nil
 }
 
 // getLocalRepoHeadRef looks up the HEAD reference of the locally cloned git repository, which is required by
 // downstream operations such as branching
-func getLocalRepoHeadRef(config *config.GitXargsConfig, localRepository *git.Repository, repo *github.Repository) (*plumbing.Reference, error) {
+func getLocalRepoHeadRef(config *config.GitXargsConfig, repositoryDir string, repo *github.Repository) (*plumbing.Reference, error) {
 	logger := logging.GetLogger("git-xargs")
 
-	ref, headErr := localRepository.Head()
+	ref, headErr := config.GitClient.Head(repositoryDir)
 	if headErr != nil {
 		logger.WithFields(logrus.Fields{
 			"Error": headErr,
@@ -113,10 +217,7 @@ func executeCommandWithLogger(config *config.GitXargsConfig, repositoryDir strin
 
 	cmd := exec.Command(cmdArgs[0], cmdArgs[1:]...)
 	cmd.Dir = repositoryDir
-	cmd.Env = os.Environ()
-	cmd.Env = append(cmd.Env, fmt.Sprintf("XARGS_DRY_RUN=%t", config.DryRun))
-	cmd.Env = append(cmd.Env, fmt.Sprintf("XARGS_REPO_NAME=%s", repo.GetName()))
-	cmd.Env = append(cmd.Env, fmt.Sprintf("XARGS_REPO_OWNER=%s", repo.GetOwner().GetLogin()))
+	cmd.Env = commandEnv(config, repo)
 
 	logger.WithFields(logrus.Fields{
 		"Repo":      repo.GetName(),
@@ -140,26 +241,18 @@ func executeCommandWithLogger(config *config.GitXargsConfig, repositoryDir strin
 	return nil
 }
 
-// getLocalWorkTree looks up the working tree of the locally cloned repository and returns it if possible, or an error
-func getLocalWorkTree(repositoryDir string, localRepository *git.Repository, repo *github.Repository) (*git.Worktree, error) {
-	logger := logging.GetLogger("git-xargs")
-
-	worktree, worktreeErr := localRepository.Worktree()
-
-	if worktreeErr != nil {
-		logger.WithFields(logrus.Fields{
-			"Error": worktreeErr,
-			"Repo":  repo.GetName(),
-			"Dir":   repositoryDir,
-		}).Debug("Error looking up local repository's worktree")
-
-		return nil, errors.WithStackTrace(worktreeErr)
-	}
-	return worktree, nil
+// commandEnv returns the environment the user-supplied command, and the --validate-cmd command, run with
+func commandEnv(config *config.GitXargsConfig, repo *github.Repository) []string {
+	env := os.Environ()
+	// While generating a bundle, the command makes the changes that will be published later, so it isn't told it's a dry run
+	env = append(env, fmt.Sprintf("XARGS_DRY_RUN=%t", config.DryRun && !config.GeneratingBundle))
+	env = append(env, fmt.Sprintf("XARGS_REPO_NAME=%s", repo.GetName()))
+	env = append(env, fmt.Sprintf("XARGS_REPO_OWNER=%s", repo.GetOwner().GetLogin()))
+	return env
 }
 
 // checkoutLocalBranch creates a local branch specific to this tool in the locally checked out copy of the repo in the /tmp folder
-func checkoutLocalBranch(config *config.GitXargsConfig, ref *plumbing.Reference, worktree *git.Worktree, remoteRepository *github.Repository, localRepository *git.Repository) (plumbing.ReferenceName, error) {
+func checkoutLocalBranch(config *config.GitXargsConfig, ref *plumbing.Reference, repositoryDir string, remoteRepository *github.Repository) (plumbing.ReferenceName, error) {
 	logger := logging.GetLogger("git-xargs")
 
 	// BranchName is a global variable that is set in cmd/root.go. It is override-able by the operator via the --branch-name or -b flag. It defaults to "git-xargs"
@@ -170,15 +263,17 @@ func checkoutLocalBranch(config *config.GitXargsConfig, ref *plumbing.Reference,
 		"Repo":        remoteRepository.GetName(),
 	}).Debug("Created branch")
 
-	// Create a branch specific to the multi repo script runner
+	// Create a branch specific to the multi repo script runner. The branch starts at HEAD, so there's nothing to check
+	// out, and keeping the worktree as it is stops go-git from writing out the files a sparse checkout left out
 	co := &git.CheckoutOptions{
 		Hash:   ref.Hash(),
 		Branch: branchName,
 		Create: true,
+		Keep:   isSparseCheckout(config),
 	}
 
 	// Attempt to checkout the new tool-specific branch on which the supplied command will be executed
-	checkoutErr := worktree.Checkout(co)
+	checkoutErr := config.GitClient.Checkout(repositoryDir, co)
 
 	if checkoutErr != nil {
 		if config.SkipPullRequests &&
@@ -199,23 +294,44 @@ func checkoutLocalBranch(config *config.GitXargsConfig, ref *plumbing.Reference,
 		}
 	}
 
+	gitAuth, authErr := getGitAuth(config, remoteRepository)
+	if authErr != nil {
+		config.Stats.TrackSingle(stats.BranchRemotePullFailed, remoteRepository)
+		return branchName, errors.WithStackTrace(authErr)
+	}
+
+	// Rather than add to the remote branch, the rebase and recreate strategies rebuild it on the latest base branch
+	if rewritesBranch(config, remoteRepository) {
+		return branchName, rebuildLocalBranch(config, repositoryDir, remoteRepository, branchName, ref, gitAuth)
+	}
+
 	// Pull latest code from remote branch if it exists to avoid fast-forwarding errors
 	gitProgressBuffer := bytes.NewBuffer(nil)
 	po := &git.PullOptions{
 		RemoteName:    "origin",
 		ReferenceName: branchName,
-		Auth: &http.BasicAuth{
-			Username: remoteRepository.GetOwner().GetLogin(),
-			Password: os.Getenv("GITHUB_OAUTH_TOKEN"),
-		},
-		Progress: gitProgressBuffer,
+		Auth:          gitAuth,
+		Progress:      gitProgressBuffer,
+		CABundle:      config.GithubServer.CABundle(),
+		ProxyOptions:  getProxyOptions(config),
 	}
 
 	logger.WithFields(logrus.Fields{
 		"Repo": remoteRepository.GetName(),
 	}).Debug(gitProgressBuffer)
 
-	pullErr := worktree.Pull(po)
+	pullErr := pullRemoteBranch(config, repositoryDir, po, false)
+
+	// A shallow or single-branch clone may not contain the history needed to pull the remote branch, in which case
+	// we fetch the rest of it and try again
+	if pullErr != nil && pullErr != plumbing.ErrReferenceNotFound && pullErr != git.NoErrAlreadyUpToDate && isPartialClone(config) {
+		logger.WithFields(logrus.Fields{
+			"Error": pullErr,
+			"Repo":  remoteRepository.GetName(),
+		}).Debug("Failed to pull remote branch into partial clone, deepening clone and retrying")
+
+		pullErr = pullRemoteBranch(config, repositoryDir, po, true)
+	}
 
 	if pullErr != nil {
 
@@ -241,18 +357,29 @@ func checkoutLocalBranch(config *config.GitXargsConfig, ref *plumbing.Reference,
 }
 
 // updateRepo will check for any changes in worktree as a result of script execution, and if any are present,
-// add any untracked, deleted or modified files, create a commit using the supplied or default commit message,
-// push the code to the remote repo, and open a pull request.
+// add any untracked, deleted or modified files not left out by --stage-include and --stage-exclude, create a commit
+// using the supplied or default commit message, push the code to the remote repo, and open a pull request. A branch
+// that was rebased onto the base ref is pushed even if there were no changes. The LFS objects of a repo that uses LFS
+// are pushed along with the branch
 func updateRepo(config *config.GitXargsConfig,
 	repositoryDir string,
-	worktree *git.Worktree,
 	remoteRepository *github.Repository,
-	localRepository *git.Repository,
 	branchName string,
+	base *plumbing.Reference,
+	beforeCommand *plumbing.Reference,
+	usesLFS bool,
 ) error {
 	logger := logging.GetLogger("git-xargs")
 
-	status, statusErr := worktree.Status()
+	commandCommits, commitsErr := countCommandCommits(config, repositoryDir, remoteRepository, branchName, beforeCommand)
+	if commitsErr != nil {
+		return commitsErr
+	}
+
+	status, statusErr := worktreeStatus(config, repositoryDir)
+
+	// Changes inside submodules belong to their own repos, so make sure they don't go unnoticed
+	warnAboutSubmoduleChanges(config, repositoryDir, remoteRepository)
 
 	if statusErr != nil {
 		logger.WithFields(logrus.Fields{
@@ -266,29 +393,108 @@ func updateRepo(config *config.GitXargsConfig,
 		return errors.WithStackTrace(statusErr)
 	}
 
-	// If there are no changes, we log it, track it, and return
-	if status.IsClean() {
+	// Files left out by --stage-include and --stage-exclude are listed in the run report, and if they are the only
+	// changes, the repo is treated as unchanged
+	staged, leftOut, selectErr := selectChangesToStage(config, repositoryDir, status)
+	if selectErr != nil {
+		config.Stats.TrackSingle(stats.WorktreeStatusCheckFailedCommand, remoteRepository)
+		return selectErr
+	}
+	reportFilesLeftUnstaged(config, remoteRepository, leftOut)
+	isClean := len(staged) == 0
+
+	// Changes that exceed --max-changed-files, --max-changed-lines or --max-file-size are neither committed nor pushed
+	if !isClean || commandCommits > 0 {
+		if sizeErr := checkChangeSize(config, repositoryDir, remoteRepository, beforeCommand, staged); sizeErr != nil {
+			return sizeErr
+		}
+	}
+
+	// The commits the command made are signed like the ones git-xargs makes, replacing any signature they had
+	if commandCommits > 0 && config.CommitSigner != nil {
+		if signErr := local.SignCommits(repositoryDir, beforeCommand.Hash(), config.CommitSigner); signErr != nil {
+			config.Stats.TrackSingle(stats.CommitChangesFailed, remoteRepository)
+			return signErr
+		}
+	}
+
+	// If there are no changes, we log it, track it, and return, unless there are commits to push, or a recreated branch
+	// must replace the one an earlier run pushed
+	var resetToBase bool
+	if isClean && commandCommits == 0 {
 		logger.WithFields(logrus.Fields{
 			"Repo": remoteRepository.GetName(),
 		}).Debug("Local repository status is clean - nothing to stage or commit")
 
 		// Track the fact that repo had no file changes post command execution
 		config.Stats.TrackSingle(stats.WorktreeStatusClean, remoteRepository)
+
+		needsPush, rewriteErr := rewrittenBranchNeedsPush(config, repositoryDir, remoteRepository, base)
+		if rewriteErr != nil {
+			config.Stats.TrackSingle(stats.PushBranchFailed, remoteRepository)
+			return rewriteErr
+		}
+		if !needsPush {
+			return nil
+		}
+		resetToBase = config.BranchStrategy == common.BranchStrategyRecreate
+	} else if !isClean {
+		// Commit any untracked files, modified or deleted files that resulted from script execution
+		commitErr := commitLocalChanges(status, staged, leftOut, config, repositoryDir, remoteRepository)
+		if commitErr != nil {
+			return commitErr
+		}
+	}
+
+	// Never push secrets the command may have written, such as a token templated into a config file
+	if scanErr := scanForSecrets(config, repositoryDir, remoteRepository, beforeCommand); scanErr != nil {
+		return scanErr
+	}
+
+	// With --interactive, only push the changes the user approves
+	approved, reviewErr := reviewChanges(config, repositoryDir, remoteRepository, beforeCommand, !isClean)
+	if reviewErr != nil {
+		return reviewErr
+	}
+	if !approved {
 		return nil
 	}
 
-	// Commit any untracked files, modified or deleted files that resulted from script execution
-	commitErr := commitLocalChanges(status, config, repositoryDir, worktree, remoteRepository, localRepository)
-	if commitErr != nil {
-		return commitErr
+	// With --dry-run-output-dir, the changes that would have been pushed are written to a patch for review
+	if patchErr := writeDryRunPatch(config, repositoryDir, remoteRepository, beforeCommand); patchErr != nil {
+		return patchErr
+	}
+
+	// GitHub rejects pushes that change workflows unless the token pushing them has the workflow scope, which is
+	// checked once pushLocalBranch knows which token that is
+	workflows, workflowsErr := changedWorkflows(config, repositoryDir, base.Hash())
+	if workflowsErr != nil {
+		config.Stats.TrackSingle(stats.PushBranchFailed, remoteRepository)
+		return workflowsErr
+	}
+
+	// The command may have started tracking files with Git LFS, in which case their objects must be pushed too
+	if !usesLFS {
+		addedLFS, lfsErr := commandAddedLFS(repositoryDir, beforeCommand)
+		if lfsErr != nil {
+			config.Stats.TrackSingle(stats.LFSPushFailed, remoteRepository)
+			return lfsErr
+		}
+		usesLFS = addedLFS
 	}
 
 	// Push the local branch containing all of our changes from executing the supplied command
-	pushBranchErr := pushLocalBranch(config, remoteRepository, localRepository)
+	pushBranchErr := pushLocalBranch(config, remoteRepository, repositoryDir, usesLFS, workflows)
 	if pushBranchErr != nil {
 		return pushBranchErr
 	}
 
+	// A branch recreated without any changes is the base branch, so there's no pull request to open for it
+	if resetToBase {
+		config.Stats.TrackSingle(stats.BranchResetToBase, remoteRepository)
+		return nil
+	}
+
 	// Create an OpenPrRequest that tracks retries
 	opr := types.OpenPrRequest{
 		Repo:    remoteRepository,
@@ -300,8 +506,10 @@ func updateRepo(config *config.GitXargsConfig,
 }
 
 // commitLocalChanges will check for any changes in worktree as a result of script execution, and if any are present,
-// add any untracked, deleted or modified files and create a commit using the supplied or default commit message.
-func commitLocalChanges(status git.Status, config *config.GitXargsConfig, repositoryDir string, worktree *git.Worktree, remoteRepository *github.Repository, localRepository *git.Repository) error {
+// add any untracked, deleted or modified files and create a commit using the supplied or default commit message. Only
+// the staged files are committed, and the ones left out by --stage-include and --stage-exclude are not, as chosen by
+// selectChangesToStage.
+func commitLocalChanges(status git.Status, staged []string, leftOut []string, config *config.GitXargsConfig, repositoryDir string, remoteRepository *github.Repository) error {
 	logger := logging.GetLogger("git-xargs")
 
 	// If there are changes, we need to stage, add and commit them
@@ -312,33 +520,76 @@ func commitLocalChanges(status git.Status, config *config.GitXargsConfig, reposi
 	// Track the fact that worktree changes were made following execution
 	config.Stats.TrackSingle(stats.WorktreeStatusDirty, remoteRepository)
 
-	for filepath := range status {
-		if status.IsUntracked(filepath) {
+	// When --stage-include or --stage-exclude leave some files out, only the rest are staged, one by one
+	partial := len(leftOut) > 0
+	if partial {
+		if addErr := stageSelectedChanges(config, repositoryDir, staged); addErr != nil {
 			logger.WithFields(logrus.Fields{
-				"Filepath": filepath,
-			}).Debug("Found untracked file. Adding to stage")
+				"Error": addErr,
+				"Repo":  remoteRepository.GetName(),
+			}).Debug("Error staging selected changes")
+			config.Stats.TrackSingle(stats.WorktreeAddFileFailed, remoteRepository)
+			return addErr
+		}
+	} else if isSparseCheckout(config) {
+		if addErr := stageSparseChanges(repositoryDir); addErr != nil {
+			logger.WithFields(logrus.Fields{
+				"Error": addErr,
+				"Repo":  remoteRepository.GetName(),
+			}).Debug("Error staging changes in sparse checkout")
+			config.Stats.TrackSingle(stats.WorktreeAddFileFailed, remoteRepository)
+			return errors.WithStackTrace(addErr)
+		}
+	}
 
-			_, addErr := worktree.Add(filepath)
-			if addErr != nil {
+	if addErr := stageSubmoduleUpdates(stagedStatus(status, staged), repositoryDir); addErr != nil {
+		logger.WithFields(logrus.Fields{
+			"Error": addErr,
+			"Repo":  remoteRepository.GetName(),
+		}).Debug("Error staging submodule updates")
+		config.Stats.TrackSingle(stats.WorktreeAddFileFailed, remoteRepository)
+		return errors.WithStackTrace(addErr)
+	}
+
+	// When every change is committed, untracked files are staged one by one, and the commit picks up every modified and
+	// deleted file
+	if !partial && !isSparseCheckout(config) {
+		for _, filepath := range staged {
+			if status.IsUntracked(filepath) {
 				logger.WithFields(logrus.Fields{
-					"Error":    addErr,
 					"Filepath": filepath,
-				}).Debug("Error adding file to git stage")
-				// Track the file staging failure
-				config.Stats.TrackSingle(stats.WorktreeAddFileFailed, remoteRepository)
-				return errors.WithStackTrace(addErr)
+				}).Debug("Found untracked file. Adding to stage")
+
+				addErr := config.GitClient.Add(repositoryDir, filepath)
+				if addErr != nil {
+					logger.WithFields(logrus.Fields{
+						"Error":    addErr,
+						"Filepath": filepath,
+					}).Debug("Error adding file to git stage")
+					// Track the file staging failure
+					config.Stats.TrackSingle(stats.WorktreeAddFileFailed, remoteRepository)
+					return errors.WithStackTrace(addErr)
+				}
 			}
 		}
 	}
 
 	// With all our untracked files staged, we can now create a commit, passing the All
 	// option when configuring our commit option so that all modified and deleted files
-	// will have their changes committed
+	// will have their changes committed, unless some of them are to be left out
 	commitOps := &git.CommitOptions{
-		All: true,
+		All:    !isSparseCheckout(config) && !partial,
+		Author: commitAuthorSignature(config),
+		Signer: config.CommitSigner,
+	}
+
+	commitMessage, messageErr := commitMessageWithTrailers(config, repositoryDir)
+	if messageErr != nil {
+		config.Stats.TrackSingle(stats.CommitChangesFailed, remoteRepository)
+		return messageErr
 	}
 
-	_, commitErr := worktree.Commit(config.CommitMessage, commitOps)
+	_, commitErr := config.GitClient.Commit(repositoryDir, commitMessage, commitOps)
 
 	if commitErr != nil {
 		logger.WithFields(logrus.Fields{
@@ -361,8 +612,9 @@ func commitLocalChanges(status git.Status, config *config.GitXargsConfig, reposi
 }
 
 // pushLocalBranch pushes the branch in the local clone of the /tmp/ directory repository to the GitHub remote origin
-// so that a pull request can be opened against it via the GitHub API
-func pushLocalBranch(config *config.GitXargsConfig, remoteRepository *github.Repository, localRepository *git.Repository) error {
+// so that a pull request can be opened against it via the GitHub API, along with its LFS objects if the repo uses LFS.
+// The push is refused if it changes any of the supplied workflows and the token it would be pushed with can't do so
+func pushLocalBranch(config *config.GitXargsConfig, remoteRepository *github.Repository, repositoryDir string, usesLFS bool, workflows []string) error {
 	logger := logging.GetLogger("git-xargs")
 
 	if config.DryRun {
@@ -373,15 +625,38 @@ func pushLocalBranch(config *config.GitXargsConfig, remoteRepository *github.Rep
 		config.Stats.TrackSingle(stats.PushBranchSkipped, remoteRepository)
 		return nil
 	}
+	gitAuth, authErr := getGitAuth(config, remoteRepository)
+	if authErr != nil {
+		config.Stats.TrackSingle(stats.PushBranchFailed, remoteRepository)
+		return errors.WithStackTrace(authErr)
+	}
+	if scopeErr := checkWorkflowScope(config, remoteRepository, gitAuth, workflows); scopeErr != nil {
+		return scopeErr
+	}
+
 	// Push the changes to the remote repo
 	po := &git.PushOptions{
-		RemoteName: "origin",
-		Auth: &http.BasicAuth{
-			Username: remoteRepository.GetOwner().GetLogin(),
-			Password: os.Getenv("GITHUB_OAUTH_TOKEN"),
-		},
+		RemoteName:   "origin",
+		Auth:         gitAuth,
+		CABundle:     config.GithubServer.CABundle(),
+		ProxyOptions: getProxyOptions(config),
+	}
+
+	if rewritesBranch(config, remoteRepository) {
+		if leaseErr := configureBranchReplacement(repositoryDir, plumbing.NewBranchReferenceName(config.BranchName), po); leaseErr != nil {
+			config.Stats.TrackSingle(stats.PushBranchFailed, remoteRepository)
+			return leaseErr
+		}
 	}
-	pushErr := localRepository.Push(po)
+
+	// The remote must have the LFS objects the branch refers to before the branch is pushed
+	if usesLFS {
+		if lfsErr := pushLFSObjects(config, repositoryDir, remoteRepository, po); lfsErr != nil {
+			return lfsErr
+		}
+	}
+
+	pushErr := config.GitClient.Push(repositoryDir, po)
 
 	if pushErr != nil {
 		logger.WithFields(logrus.Fields{
@@ -426,10 +701,7 @@ func openPullRequest(config *config.GitXargsConfig, pr types.OpenPrRequest) erro
 
 	logger.Debugf("openPullRequest received job with retries: %d. Config max retries for this run: %d", pr.Retries, config.PullRequestRetries)
 
-	repoDefaultBranch := config.BaseBranchName
-	if repoDefaultBranch == "" {
-		repoDefaultBranch = pr.Repo.GetDefaultBranch()
-	}
+	repoDefaultBranch := baseBranchName(config, pr.Repo)
 
 	pullRequestAlreadyExists, err := pullRequestAlreadyExistsForBranch(config, pr.Repo, pr.Branch, repoDefaultBranch)
 	if err != nil {
@@ -557,7 +829,7 @@ func openPullRequest(config *config.GitXargsConfig, pr types.OpenPrRequest) erro
 				config.Stats.TrackSingle(stats.RepoDoesntSupportDraftPullRequestsErr, pr.Repo)
 
 			case strings.Contains(err.Error(), "Field:base Code:invalid"):
-				prErrorMessage = fmt.Sprintf("Error opening pull request: Base branch name: %s is invalid", config.BaseBranchName)
+				prErrorMessage = fmt.Sprintf("Error opening pull request: Base branch name: %s is invalid", repoDefaultBranch)
 				config.Stats.TrackSingle(stats.BaseBranchTargetInvalidErr, pr.Repo)
 
 			default:
@@ -2,12 +2,23 @@ package repository
 
 import (
 	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
 	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/gruntwork-io/git-xargs/auth"
+	"github.com/gruntwork-io/git-xargs/common"
 	"github.com/gruntwork-io/git-xargs/config"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/google/go-github/v43/github"
 )
@@ -92,3 +103,49 @@ func TestExecuteCommandWithLoggerWithEnvVars(t *testing.T) {
 	assert.Contains(t, buffer.String(), fmt.Sprintf("XARGS_REPO_NAME=%s", *repo.Name))
 	assert.Contains(t, buffer.String(), fmt.Sprintf("XARGS_REPO_OWNER=%s", *repo.Owner.Login))
 }
+
+// Test that the clone URL and git credentials follow the --git-transport flag
+func TestGetCloneURLAndGitAuth(t *testing.T) {
+	t.Parallel()
+
+	repo := getMockGithubRepo()
+	repo.SSHURL = github.String("git@github.com:gruntwork-io/terragrunt.git")
+
+	t.Run("https uses the clone URL and token", func(t *testing.T) {
+		cfg := config.NewGitXargsConfig()
+		cfg.GitCredentials = auth.TokenCredentials{Token: "test-token"}
+
+		assert.Equal(t, repo.GetCloneURL(), getCloneURL(cfg, repo))
+
+		gitAuth, err := getGitAuth(cfg, repo)
+		require.NoError(t, err)
+		assert.Equal(t, &http.BasicAuth{Username: "gruntwork-io", Password: "test-token"}, gitAuth)
+	})
+
+	t.Run("ssh uses the SSH URL and key file", func(t *testing.T) {
+		tmpDir := t.TempDir()
+
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		require.NoError(t, err)
+		keyPath := filepath.Join(tmpDir, "id_rsa")
+		keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+		require.NoError(t, os.WriteFile(keyPath, keyPEM, 0600))
+
+		knownHostsPath := filepath.Join(tmpDir, "known_hosts")
+		require.NoError(t, os.WriteFile(knownHostsPath, []byte{}, 0600))
+
+		cfg := config.NewGitXargsConfig()
+		cfg.GitTransport = common.GitTransportSSH
+		cfg.SSHKeyPath = keyPath
+		cfg.SSHKnownHostsPath = knownHostsPath
+
+		assert.Equal(t, repo.GetSSHURL(), getCloneURL(cfg, repo))
+
+		gitAuth, err := getGitAuth(cfg, repo)
+		require.NoError(t, err)
+		publicKeys, ok := gitAuth.(*ssh.PublicKeys)
+		require.True(t, ok)
+		assert.Equal(t, "git", publicKeys.User)
+		assert.NotNil(t, publicKeys.HostKeyCallback)
+	})
+}
@@ -0,0 +1,234 @@
+package repository
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/google/go-github/v43/github"
+	"github.com/gruntwork-io/git-xargs/config"
+	"github.com/gruntwork-io/git-xargs/stats"
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/gruntwork-io/go-commons/logging"
+	"github.com/pterm/pterm"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	reviewApprove = "Approve and push"
+	reviewSkip    = "Skip this repo"
+	reviewEdit    = "Edit the commit message"
+)
+
+// reviewMutex serializes --interactive reviews. Repos are processed concurrently, but only one can be reviewed at a time
+var reviewMutex sync.Mutex
+
+// promptForReview asks the user to choose one of the given options, and returns the one they chose
+var promptForReview = func(options []string) (string, error) {
+	return pterm.DefaultInteractiveSelect.WithOptions(options).WithDefaultOption(reviewApprove).Show("Push these changes?")
+}
+
+// editCommitMessage opens the given commit message in the user's editor, and returns it once they've edited it
+var editCommitMessage = editInEditor
+
+// reviewChanges shows the changes the supplied command made to the repo, both in the commit git-xargs made and in any
+// commits the command made itself, and asks the user whether to push them, if --interactive was passed. The user can
+// also edit the message of the commit git-xargs made, if it made one, before deciding. It returns false if the user
+// chose to skip the repo
+func reviewChanges(config *config.GitXargsConfig, repositoryDir string, remoteRepository *github.Repository, beforeCommand *plumbing.Reference, committed bool) (bool, error) {
+	if !config.Interactive {
+		return true, nil
+	}
+
+	logger := logging.GetLogger("git-xargs")
+
+	reviewMutex.Lock()
+	defer reviewMutex.Unlock()
+
+	options := []string{reviewApprove, reviewSkip}
+	if committed {
+		options = append(options, reviewEdit)
+	}
+
+	for {
+		patch, err := commandPatch(repositoryDir, beforeCommand)
+		if err != nil {
+			return false, err
+		}
+		pterm.DefaultSection.Println(fmt.Sprintf("Changes to %s", remoteRepository.GetFullName()))
+		pterm.Println(renderPatch(patch))
+
+		choice, err := promptForReview(options)
+		if err != nil {
+			return false, errors.WithStackTrace(err)
+		}
+
+		switch choice {
+		case reviewApprove:
+			return true, nil
+		case reviewSkip:
+			logger.WithFields(logrus.Fields{
+				"Repo": remoteRepository.GetName(),
+			}).Debug("Changes were not approved, will not push them")
+
+			config.Stats.TrackSingle(stats.ReviewSkipped, remoteRepository)
+			return false, nil
+		case reviewEdit:
+			if err := amendCommitMessage(config, repositoryDir, remoteRepository); err != nil {
+				return false, err
+			}
+		}
+	}
+}
+
+// commandPatch returns the changes between the commit the branch was at before the supplied command ran and HEAD
+func commandPatch(repositoryDir string, beforeCommand *plumbing.Reference) (*object.Patch, error) {
+	localRepository, err := git.PlainOpen(repositoryDir)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+
+	beforeCommit, err := localRepository.CommitObject(beforeCommand.Hash())
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+
+	head, err := localRepository.Head()
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	headCommit, err := localRepository.CommitObject(head.Hash())
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+
+	patch, err := beforeCommit.Patch(headCommit)
+	return patch, errors.WithStackTrace(err)
+}
+
+// renderPatch returns a colored summary of the lines added and removed in each file, followed by the full patch
+func renderPatch(patch *object.Patch) string {
+	fileStats := patch.Stats()
+	if len(fileStats) == 0 {
+		return "The command made no changes"
+	}
+
+	var rendered strings.Builder
+	additions, deletions := 0, 0
+	for _, fileStat := range fileStats {
+		rendered.WriteString(fmt.Sprintf("%s %s %s\n", fileStat.Name, pterm.Green(fmt.Sprintf("+%d", fileStat.Addition)), pterm.Red(fmt.Sprintf("-%d", fileStat.Deletion))))
+		additions += fileStat.Addition
+		deletions += fileStat.Deletion
+	}
+	rendered.WriteString(fmt.Sprintf("%d files changed, %s, %s\n\n", len(fileStats), pterm.Green(fmt.Sprintf("%d insertions(+)", additions)), pterm.Red(fmt.Sprintf("%d deletions(-)", deletions))))
+
+	for _, line := range strings.Split(strings.TrimRight(patch.String(), "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"), strings.HasPrefix(line, "diff --git"):
+			line = pterm.Bold.Sprint(line)
+		case strings.HasPrefix(line, "@@"):
+			line = pterm.Cyan(line)
+		case strings.HasPrefix(line, "+"):
+			line = pterm.Green(line)
+		case strings.HasPrefix(line, "-"):
+			line = pterm.Red(line)
+		}
+		rendered.WriteString(line + "\n")
+	}
+	return rendered.String()
+}
+
+// amendCommitMessage lets the user edit the message of the commit git-xargs made, and amends the commit with it
+func amendCommitMessage(config *config.GitXargsConfig, repositoryDir string, remoteRepository *github.Repository) error {
+	localRepository, err := git.PlainOpen(repositoryDir)
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+	head, err := localRepository.Head()
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+	headCommit, err := localRepository.CommitObject(head.Hash())
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+
+	message, err := editCommitMessage(headCommit.Message)
+	if err != nil {
+		return err
+	}
+	if message == "" || message == headCommit.Message {
+		return nil
+	}
+
+	commitOps := &git.CommitOptions{
+		Amend:  true,
+		Author: commitAuthorSignature(config),
+		Signer: config.CommitSigner,
+	}
+	if _, err := config.GitClient.Commit(repositoryDir, message, commitOps); err != nil {
+		config.Stats.TrackSingle(stats.CommitChangesFailed, remoteRepository)
+		return errors.WithStackTrace(err)
+	}
+	return nil
+}
+
+// editInEditor opens the given text in the editor git would use, and returns it once the user has edited it, with any
+// lines starting with # removed. An empty result means the user cleared the message, and the commit is left as it is
+func editInEditor(text string) (string, error) {
+	file, err := os.CreateTemp("", "git-xargs-COMMIT_EDITMSG")
+	if err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+	defer os.Remove(file.Name())
+
+	contents := text + "\n# Edit the commit message above. Lines starting with '#' are ignored, and an empty message\n# leaves the commit as it is.\n"
+	if _, err := file.WriteString(contents); err != nil {
+		file.Close()
+		return "", errors.WithStackTrace(err)
+	}
+	if err := file.Close(); err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+
+	// The editor command may include arguments, such as "code --wait", so the shell runs it
+	cmd := exec.Command("sh", "-c", editorCommand()+` "$1"`, "sh", file.Name())
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+
+	edited, err := os.ReadFile(file.Name())
+	if err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+
+	var lines []string
+	for _, line := range strings.Split(string(edited), "\n") {
+		if !strings.HasPrefix(line, "#") {
+			lines = append(lines, line)
+		}
+	}
+	message := strings.TrimSpace(strings.Join(lines, "\n"))
+	if message == "" {
+		return "", nil
+	}
+	return message + "\n", nil
+}
+
+// editorCommand returns the editor git would use: $GIT_EDITOR, $VISUAL or $EDITOR, or else vi
+func editorCommand() string {
+	for _, variable := range []string{"GIT_EDITOR", "VISUAL", "EDITOR"} {
+		if editor := os.Getenv(variable); editor != "" {
+			return editor
+		}
+	}
+	return "vi"
+}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gruntwork-io/git-xargs/local"
+	"github.com/gruntwork-io/git-xargs/stats"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestReviewEditAndApprove ensures the commit message can be edited during an --interactive review, and that the
+// approved changes are pushed. It replaces the prompt and the editor, so it can't run in parallel with other tests
+func TestReviewEditAndApprove(t *testing.T) {
+	prompts := 0
+	promptForReview = func(options []string) (string, error) {
+		assert.Equal(t, []string{reviewApprove, reviewSkip, reviewEdit}, options)
+		prompts++
+		if prompts%2 == 1 {
+			return reviewEdit, nil
+		}
+		return reviewApprove, nil
+	}
+	editCommitMessage = func(message string) (string, error) {
+		return "Reviewed message\n\n" + message, nil
+	}
+	t.Cleanup(func() {
+		promptForReview = nil
+		editCommitMessage = editInEditor
+	})
+
+	forEachGitBackend(t, func(t *testing.T, provider local.GitProvider) {
+		remoteURL, _ := createTestRemote(t)
+		testConfig, repo := newCloneTestConfig(remoteURL, provider, "reviewed")
+		testConfig.Interactive = true
+
+		repositoryDir := cloneAndCheckoutTestBranch(t, testConfig, repo)
+		beforeCommand, err := getLocalRepoHeadRef(testConfig, repositoryDir, repo)
+		require.NoError(t, err)
+
+		testConfig.Args = []string{"bash", "-c", "echo updated > modules/main.tf"}
+		require.NoError(t, executeCommand(testConfig, repositoryDir, repo))
+		require.NoError(t, updateRepo(testConfig, repositoryDir, repo, "refs/heads/reviewed", beforeCommand, beforeCommand, false))
+
+		commit, err := local.RunGitCommand(repositoryDir, "log", "-1", "--format=%an|%s|%b", remoteBranchTip(t, remoteURL, "reviewed"))
+		require.NoError(t, err)
+		assert.Equal(t, "git-xargs|Reviewed message|"+testConfig.CommitMessage, strings.TrimSpace(commit))
+		assert.Empty(t, testConfig.Stats.GetRepos()[stats.ReviewSkipped])
+	})
+}
+
+// TestReviewSkip ensures changes skipped during an --interactive review are not pushed. It replaces the prompt, so it
+// can't run in parallel with other tests
+func TestReviewSkip(t *testing.T) {
+	promptForReview = func(options []string) (string, error) {
+		return reviewSkip, nil
+	}
+	t.Cleanup(func() { promptForReview = nil })
+
+	forEachGitBackend(t, func(t *testing.T, provider local.GitProvider) {
+		remoteURL, _ := createTestRemote(t)
+		testConfig, repo := newCloneTestConfig(remoteURL, provider, "skipped")
+		testConfig.Interactive = true
+
+		repositoryDir := cloneAndCheckoutTestBranch(t, testConfig, repo)
+		beforeCommand, err := getLocalRepoHeadRef(testConfig, repositoryDir, repo)
+		require.NoError(t, err)
+
+		testConfig.Args = []string{"bash", "-c", "echo updated > modules/main.tf"}
+		require.NoError(t, executeCommand(testConfig, repositoryDir, repo))
+		require.NoError(t, updateRepo(testConfig, repositoryDir, repo, "refs/heads/skipped", beforeCommand, beforeCommand, false))
+
+		assert.Contains(t, testConfig.Stats.GetRepos()[stats.ReviewSkipped], repo)
+		branches, err := local.RunGitCommand("", "ls-remote", remoteURL, "refs/heads/skipped")
+		require.NoError(t, err)
+		assert.Empty(t, strings.TrimSpace(branches))
+	})
+}
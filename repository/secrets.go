@@ -0,0 +1,244 @@
+package repository
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/utils/diff"
+	"github.com/google/go-github/v43/github"
+	"github.com/gruntwork-io/git-xargs/config"
+	"github.com/gruntwork-io/git-xargs/stats"
+	"github.com/gruntwork-io/git-xargs/types"
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/gruntwork-io/go-commons/logging"
+	"github.com/sergi/go-diff/diffmatchpatch"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// maxReportedSecretFindings is how many of the possible secrets found in a repo are listed in the run report
+	maxReportedSecretFindings = 20
+	// minSecretEntropy is the Shannon entropy, in bits per character, above which a value assigned to a secret-looking
+	// name is treated as a secret rather than a placeholder such as "changeme"
+	minSecretEntropy = 3.5
+)
+
+// secretRule finds one kind of secret in a line of text. If entropyGroup is set, the text the regex captures in that
+// group must also look random enough to be a secret
+type secretRule struct {
+	name         string
+	regex        *regexp.Regexp
+	entropyGroup int
+}
+
+// builtInSecretRules are the kinds of secrets every change is scanned for, unless --skip-secret-scan is passed
+var builtInSecretRules = []secretRule{
+	{name: "AWS access key ID", regex: regexp.MustCompile(`\b(?:AKIA|ASIA|ABIA|ACCA)[0-9A-Z]{16}\b`)},
+	{name: "AWS secret access key", regex: regexp.MustCompile(`(?i)aws_?secret_?access_?key["']?\s*[:=]\s*["']?[A-Za-z0-9/+=]{40}\b`)},
+	{name: "Google API key", regex: regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`)},
+	{name: "Azure storage account key", regex: regexp.MustCompile(`(?i)AccountKey=[A-Za-z0-9+/]{86}==`)},
+	{name: "GitHub token", regex: regexp.MustCompile(`\b(?:ghp|gho|ghu|ghs|ghr)_[A-Za-z0-9]{36}\b`)},
+	{name: "GitHub fine-grained token", regex: regexp.MustCompile(`\bgithub_pat_[A-Za-z0-9_]{82}\b`)},
+	{name: "Slack token", regex: regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`)},
+	{name: "Private key", regex: regexp.MustCompile(`-----BEGIN (?:[A-Z]+ )*PRIVATE KEY(?: BLOCK)?-----`)},
+	{
+		name:         "High-entropy secret",
+		regex:        regexp.MustCompile(`(?i)(?:secret|token|passw(?:or)?d|api_?key|access_?key|credential|auth)[\w.-]*["']?\s*[:=]+\s*["']?([A-Za-z0-9+/_.=-]{20,})`),
+		entropyGroup: 1,
+	},
+}
+
+// secretFinding is a possible secret found on a line the supplied command added. The secret itself is never recorded,
+// so that it doesn't leak into logs or the run report
+type secretFinding struct {
+	path string
+	line int
+	rule string
+}
+
+func (finding secretFinding) String() string {
+	return fmt.Sprintf("%s:%d (%s)", finding.path, finding.line, finding.rule)
+}
+
+// secretRules returns the built-in rules along with one for each regex passed via --secret-pattern
+func secretRules(config *config.GitXargsConfig) ([]secretRule, error) {
+	rules := append([]secretRule{}, builtInSecretRules...)
+	for _, pattern := range config.SecretPatterns {
+		regex, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, errors.WithStackTrace(types.InvalidSecretPatternErr{Pattern: pattern, Underlying: err})
+		}
+		rules = append(rules, secretRule{name: "--secret-pattern " + pattern, regex: regex})
+	}
+	return rules, nil
+}
+
+// scanForSecrets scans the lines the supplied command added, both in the commit git-xargs made and in any commits the
+// command made itself, for secrets. A repo in which any are found is reported with types.SecretsFoundErr, so that its
+// branch is never pushed
+func scanForSecrets(config *config.GitXargsConfig, repositoryDir string, remoteRepository *github.Repository, beforeCommand *plumbing.Reference) error {
+	if config.SkipSecretScan {
+		return nil
+	}
+
+	logger := logging.GetLogger("git-xargs")
+
+	rules, err := secretRules(config)
+	if err != nil {
+		return err
+	}
+
+	findings, err := findSecrets(repositoryDir, beforeCommand, rules)
+	if err != nil {
+		config.Stats.TrackSingle(stats.SecretScanFailed, remoteRepository)
+		return err
+	}
+	if len(findings) == 0 {
+		return nil
+	}
+
+	var reported []string
+	for _, finding := range findings {
+		reported = append(reported, finding.String())
+	}
+	detail := strings.Join(reported, ", ")
+	if len(reported) > maxReportedSecretFindings {
+		detail = fmt.Sprintf("%s and %d more", strings.Join(reported[:maxReportedSecretFindings], ", "), len(reported)-maxReportedSecretFindings)
+	}
+
+	logger.WithFields(logrus.Fields{
+		"Repo":     remoteRepository.GetName(),
+		"Findings": detail,
+	}).Warn("Found possible secrets in the command's changes, will not push them")
+
+	config.Stats.TrackSingleWithDetail(stats.SecretsFound, remoteRepository, detail)
+	return errors.WithStackTrace(types.SecretsFoundErr{Findings: len(findings)})
+}
+
+// findSecrets returns the possible secrets on the lines added between the commit the branch was at before the supplied
+// command ran and HEAD, ordered by file and line
+func findSecrets(repositoryDir string, beforeCommand *plumbing.Reference, rules []secretRule) ([]secretFinding, error) {
+	localRepository, err := git.PlainOpen(repositoryDir)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+
+	head, err := localRepository.Head()
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	if head.Hash() == beforeCommand.Hash() {
+		return nil, nil
+	}
+
+	beforeTree, err := commitTree(localRepository, beforeCommand.Hash())
+	if err != nil {
+		return nil, err
+	}
+	headTree, err := commitTree(localRepository, head.Hash())
+	if err != nil {
+		return nil, err
+	}
+
+	changes, err := object.DiffTree(beforeTree, headTree)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+
+	var findings []secretFinding
+	for _, change := range changes {
+		if change.To.Name == "" {
+			continue
+		}
+
+		before, err := treeFileContents(beforeTree, change.From.Name)
+		if err != nil {
+			return nil, err
+		}
+		after, err := treeFileContents(headTree, change.To.Name)
+		if err != nil {
+			return nil, err
+		}
+		if isBinary(before) || isBinary(after) {
+			continue
+		}
+
+		for lineNumber, line := range addedLines(before, after) {
+			// Only the first rule that matches is reported, so the more specific built-in rules win over the generic one
+			for _, rule := range rules {
+				if rule.matches(line) {
+					findings = append(findings, secretFinding{path: change.To.Name, line: lineNumber, rule: rule.name})
+					break
+				}
+			}
+		}
+	}
+
+	sortSecretFindings(findings)
+	return findings, nil
+}
+
+// matches returns true if the rule finds a secret in the given line
+func (rule secretRule) matches(line string) bool {
+	for _, match := range rule.regex.FindAllStringSubmatch(line, -1) {
+		if rule.entropyGroup == 0 || shannonEntropy(match[rule.entropyGroup]) >= minSecretEntropy {
+			return true
+		}
+	}
+	return false
+}
+
+// addedLines returns the lines in after that aren't in before, keyed by their line number in after
+func addedLines(before []byte, after []byte) map[int]string {
+	added := map[int]string{}
+	lineNumber := 1
+	for _, d := range diff.Do(string(before), string(after)) {
+		if d.Type == diffmatchpatch.DiffDelete {
+			continue
+		}
+
+		lines := strings.SplitAfter(d.Text, "\n")
+		if lines[len(lines)-1] == "" {
+			lines = lines[:len(lines)-1]
+		}
+		for _, line := range lines {
+			if d.Type == diffmatchpatch.DiffInsert {
+				added[lineNumber] = strings.TrimSuffix(line, "\n")
+			}
+			lineNumber++
+		}
+	}
+	return added
+}
+
+// shannonEntropy returns the Shannon entropy of the given string, in bits per character
+func shannonEntropy(s string) float64 {
+	counts := map[rune]int{}
+	for _, r := range s {
+		counts[r]++
+	}
+
+	length := float64(len([]rune(s)))
+	entropy := 0.0
+	for _, count := range counts {
+		frequency := float64(count) / length
+		entropy -= frequency * math.Log2(frequency)
+	}
+	return entropy
+}
+
+// sortSecretFindings orders the given findings by file, then line
+func sortSecretFindings(findings []secretFinding) {
+	sort.Slice(findings, func(i, j int) bool {
+		if findings[i].path != findings[j].path {
+			return findings[i].path < findings[j].path
+		}
+		return findings[i].line < findings[j].line
+	})
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gruntwork-io/git-xargs/config"
+	"github.com/gruntwork-io/git-xargs/local"
+	"github.com/gruntwork-io/git-xargs/stats"
+	"github.com/gruntwork-io/git-xargs/types"
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The fake secrets are assembled at runtime, so that this file doesn't trip secret scanners itself
+var (
+	fakeGithubToken   = "ghp_" + strings.Repeat("a1B2", 9)
+	fakeAWSAccessKey  = "AKIA" + "IOSFODNN7EXAMPLE"
+	fakePrivateKey    = "-----BEGIN " + "OPENSSH PRIVATE KEY-----"
+	fakeRandomSecret  = "q8Zr2LmX7vKp4TnWb9Ye" + "3HcJ6sDf"
+	fakeTemplateToken = "REPLACE_ME_" + "REPLACE_ME_REPLACE"
+)
+
+func TestSecretRules(t *testing.T) {
+	t.Parallel()
+
+	testConfig := config.NewGitXargsTestConfig()
+	testConfig.SecretPatterns = []string{`internal-[0-9a-f]{8}`}
+	rules, err := secretRules(testConfig)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		line     string
+		expected string
+	}{
+		{"token: " + fakeGithubToken, "GitHub token"},
+		{"aws_access_key_id = " + fakeAWSAccessKey, "AWS access key ID"},
+		{fakePrivateKey, "Private key"},
+		{`client_secret = "` + fakeRandomSecret + `"`, "High-entropy secret"},
+		{`api_token = "` + fakeTemplateToken + `"`, ""},
+		{`password = "${var.password}"`, ""},
+		{"sha256 = 3f786850e387550fdab836ed7e6dc881de23001b", ""},
+		{"key: internal-0123abcd", "--secret-pattern internal-[0-9a-f]{8}"},
+	}
+
+	for _, testCase := range testCases {
+		matched := ""
+		for _, rule := range rules {
+			if rule.matches(testCase.line) {
+				matched = rule.name
+				break
+			}
+		}
+		assert.Equal(t, testCase.expected, matched, testCase.line)
+	}
+}
+
+func TestAddedLines(t *testing.T) {
+	t.Parallel()
+
+	added := addedLines([]byte("a\nb\nc\n"), []byte("a\nnew\nb\nd\n"))
+	assert.Equal(t, map[int]string{2: "new", 4: "d"}, added)
+}
+
+// TestSecretsBlockPush ensures a branch whose changes contain possible secrets, including in commits the command made
+// itself, is not pushed, and that the findings are reported by file and line without the secrets themselves
+func TestSecretsBlockPush(t *testing.T) {
+	t.Parallel()
+
+	forEachGitBackend(t, func(t *testing.T, provider local.GitProvider) {
+		remoteURL, _ := createTestRemote(t)
+		testConfig, repo := newCloneTestConfig(remoteURL, provider, "secrets")
+
+		repositoryDir := cloneAndCheckoutTestBranch(t, testConfig, repo)
+		beforeCommand, err := getLocalRepoHeadRef(testConfig, repositoryDir, repo)
+		require.NoError(t, err)
+
+		testConfig.Args = []string{"bash", "-c", `
+set -e
+printf 'provider "aws" {\n  access_key = "%s"\n}\n' "$1" > modules/provider.tf
+git add -A && git -c user.name=script -c user.email=script@example.com commit -q -m provider
+printf '1\ntoken = "%s"\n' "$2" > modules/main.tf
+`, "bash", fakeAWSAccessKey, fakeGithubToken}
+		require.NoError(t, executeCommand(testConfig, repositoryDir, repo))
+
+		err = updateRepo(testConfig, repositoryDir, repo, "refs/heads/secrets", beforeCommand, beforeCommand, false)
+		require.Error(t, err)
+		assert.IsType(t, types.SecretsFoundErr{}, errors.Unwrap(err))
+
+		detail := testConfig.Stats.GetDetail(stats.SecretsFound, repo)
+		assert.Equal(t, "modules/main.tf:2 (GitHub token), modules/provider.tf:2 (AWS access key ID)", detail)
+		assert.NotContains(t, detail, fakeGithubToken)
+
+		branches, err := local.RunGitCommand("", "ls-remote", remoteURL, "refs/heads/secrets")
+		require.NoError(t, err)
+		assert.Empty(t, strings.TrimSpace(branches))
+	})
+}
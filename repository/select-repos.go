@@ -167,9 +167,9 @@ func selectReposViaRepoFlag(inputRepos []string) ([]*types.AllowedRepo, []string
 }
 
 // fetchUserProvidedReposViaGithub converts repos provided as strings, already validated as being well-formed, into GitHub API repo objects that can be further processed
-func fetchUserProvidedReposViaGithubAPI(githubClient auth.GithubClient, rs RepoSelection, stats *stats.RunStats) ([]*github.Repository, error) {
+func fetchUserProvidedReposViaGithubAPI(githubClient auth.GithubClient, rs RepoSelection, stats *stats.RunStats, baseBranches map[string]string) ([]*github.Repository, error) {
 	ar := rs.GetAllowedRepos()
-	return getFileDefinedRepos(githubClient, ar, stats)
+	return getFileDefinedRepos(githubClient, ar, stats, baseBranches)
 
 }
 
@@ -191,6 +191,11 @@ func OperateOnRepos(config *config.GitXargsConfig) error {
 	// The set of GitHub repositories the tool will actually process
 	var reposToIterate []*github.Repository
 
+	// Look up the repo to cherry-pick from, if any, before looking up the repos to cherry-pick into
+	if err := resolveCherryPickSource(config); err != nil {
+		return err
+	}
+
 	// repoSelection is a representations of the user-supplied input, containing the repo organization and name
 	repoSelection, err := selectReposViaInput(config)
 
@@ -217,7 +222,7 @@ func OperateOnRepos(config *config.GitXargsConfig) error {
 		logger.Debugf("Using Github org: %s as source of repositories. Paging through Github API for repos.", config.GithubOrg)
 
 	case ReposFilePath:
-		githubRepos, err := fetchUserProvidedReposViaGithubAPI(config.GithubClient, *repoSelection, config.Stats)
+		githubRepos, err := fetchUserProvidedReposViaGithubAPI(config.GithubClient, *repoSelection, config.Stats, config.RepoBaseBranches)
 		if err != nil {
 			return err
 		}
@@ -228,7 +233,7 @@ func OperateOnRepos(config *config.GitXargsConfig) error {
 		config.Stats.SetFileProvidedRepos(repoSelection.GetAllowedRepos())
 
 	case ExplicitReposOnCommandLine, ReposViaStdIn:
-		githubRepos, err := fetchUserProvidedReposViaGithubAPI(config.GithubClient, *repoSelection, config.Stats)
+		githubRepos, err := fetchUserProvidedReposViaGithubAPI(config.GithubClient, *repoSelection, config.Stats, config.RepoBaseBranches)
 		if err != nil {
 			return err
 		}
@@ -244,6 +249,14 @@ func OperateOnRepos(config *config.GitXargsConfig) error {
 	// Track the repos selected for processing
 	config.Stats.TrackMultiple(stats.ReposSelected, reposToIterate)
 
+	// Check up front that we'll be able to update each repo, rather than discovering it after running the scripts
+	if config.PreflightCheck != "" {
+		reposToIterate, err = preflightCheckRepos(config, reposToIterate)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Print out the repos that we've filtered for processing in debug mode
 	for _, repo := range reposToIterate {
 		logger.WithFields(logrus.Fields{
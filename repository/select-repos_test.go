@@ -89,3 +89,18 @@ func TestGetPreferredOrderOfRepoSelections(t *testing.T) {
 
 	assert.Equal(t, ReposViaStdIn, getPreferredOrderOfRepoSelections(testConfig))
 }
+
+// TestSelectReposViaRepoFlagParsesBaseBranch ensures a base branch can be supplied alongside a repo
+func TestSelectReposViaRepoFlagParsesBaseBranch(t *testing.T) {
+	t.Parallel()
+
+	allowedRepos, malformedRepos, err := selectReposViaRepoFlag([]string{"gruntwork-io/fetch@release/v1", "gruntwork-io/cloud-nuke"})
+	require.NoError(t, err)
+	assert.Empty(t, malformedRepos)
+	require.Len(t, allowedRepos, 2)
+
+	assert.Equal(t, "fetch", allowedRepos[0].Name)
+	assert.Equal(t, "release/v1", allowedRepos[0].BaseBranch)
+	assert.Equal(t, "cloud-nuke", allowedRepos[1].Name)
+	assert.Empty(t, allowedRepos[1].BaseBranch)
+}
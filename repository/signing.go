@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/go-github/v43/github"
+	"github.com/gruntwork-io/git-xargs/config"
+	"github.com/gruntwork-io/git-xargs/stats"
+	"github.com/gruntwork-io/go-commons/logging"
+	"github.com/sirupsen/logrus"
+)
+
+// signingRequirementUnknown is recorded for repos whose base branch protection the token isn't allowed to read
+const signingRequirementUnknown = "unknown (insufficient permissions)"
+
+// reportSigningRequired tracks the repo if git-xargs isn't signing commits, but the branch protection of the repo's
+// base branch requires signed commits, in which case its pull request can't be merged. Reading branch protection
+// requires admin access to the repo, so repos the token isn't an admin of are tracked as unknown rather than looked up,
+// as are repos whose branch protection GitHub refuses to return. This is checked once per repo, as part of
+// --preflight-check, rather than on every run
+func reportSigningRequired(config *config.GitXargsConfig, repo *github.Repository) {
+	logger := logging.GetLogger("git-xargs")
+
+	if config.CommitSigner != nil {
+		return
+	}
+
+	baseBranch := baseBranchName(config, repo)
+
+	// The permissions map is only populated when the API knows who is asking, so a missing map tells us nothing
+	if repo.Permissions != nil && !repo.Permissions["admin"] {
+		config.Stats.TrackSingleWithDetail(stats.SigningRequirementUnknown, repo, signingRequirementUnknown)
+		return
+	}
+
+	signatures, resp, err := config.GithubClient.Repositories.GetSignaturesProtectedBranch(context.Background(), repo.GetOwner().GetLogin(), repo.GetName(), baseBranch)
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"Error":  err,
+			"Repo":   repo.GetName(),
+			"Branch": baseBranch,
+		}).Debug("Could not look up whether the base branch requires signed commits")
+
+		// GitHub returns a 404 for branches that aren't protected, and a 403 if the token isn't allowed to read them
+		if resp != nil && resp.Response != nil && resp.StatusCode == http.StatusForbidden {
+			config.Stats.TrackSingleWithDetail(stats.SigningRequirementUnknown, repo, signingRequirementUnknown)
+		}
+		return
+	}
+
+	if signatures.GetEnabled() {
+		logger.WithFields(logrus.Fields{
+			"Repo":   repo.GetName(),
+			"Branch": baseBranch,
+		}).Warn("Base branch requires signed commits, but --sign-commits was not passed")
+
+		config.Stats.TrackSingleWithDetail(stats.SigningRequiredNotConfigured, repo, baseBranch)
+	}
+}
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"io"
+	"testing"
+
+	"github.com/gruntwork-io/git-xargs/config"
+	"github.com/gruntwork-io/git-xargs/mocks"
+	"github.com/gruntwork-io/git-xargs/stats"
+	"github.com/stretchr/testify/assert"
+)
+
+// unusedSigner stands in for a signing key in tests that don't commit anything
+type unusedSigner struct{}
+
+func (unusedSigner) Sign(message io.Reader) ([]byte, error) {
+	return nil, nil
+}
+
+// TestReportSigningRequired ensures repos whose base branch requires signed commits are reported, unless git-xargs is
+// signing its commits
+func TestReportSigningRequired(t *testing.T) {
+	t.Parallel()
+
+	repo := mocks.GetMockGithubRepo()
+
+	testConfig := config.NewGitXargsTestConfig()
+	testConfig.GithubClient = mocks.ConfigureMockGithubClient()
+	reportSigningRequired(testConfig, repo)
+	assert.Empty(t, testConfig.Stats.GetRepos()[stats.SigningRequiredNotConfigured])
+	assert.Empty(t, testConfig.Stats.GetRepos()[stats.SigningRequirementUnknown])
+
+	testConfig.GithubClient = mocks.ConfigureMockGithubClientRequiringSignedCommits()
+	reportSigningRequired(testConfig, repo)
+	assert.Equal(t, repo.GetDefaultBranch(), testConfig.Stats.GetDetail(stats.SigningRequiredNotConfigured, repo))
+
+	forbiddenConfig := config.NewGitXargsTestConfig()
+	forbiddenConfig.GithubClient = mocks.ConfigureMockGithubClientForbiddingBranchProtection()
+	reportSigningRequired(forbiddenConfig, repo)
+	assert.Empty(t, forbiddenConfig.Stats.GetRepos()[stats.SigningRequiredNotConfigured])
+	assert.Equal(t, "unknown (insufficient permissions)", forbiddenConfig.Stats.GetDetail(stats.SigningRequirementUnknown, repo))
+
+	// Only admins can read branch protection, so it isn't looked up for repos the token can merely push to
+	nonAdminConfig := config.NewGitXargsTestConfig()
+	nonAdminConfig.GithubClient = mocks.ConfigureMockGithubClientRequiringSignedCommits()
+	nonAdminRepo := mocks.GetMockGithubRepo()
+	nonAdminRepo.Permissions = map[string]bool{"pull": true, "push": true}
+	reportSigningRequired(nonAdminConfig, nonAdminRepo)
+	assert.Empty(t, nonAdminConfig.Stats.GetRepos()[stats.SigningRequiredNotConfigured])
+	assert.Equal(t, "unknown (insufficient permissions)", nonAdminConfig.Stats.GetDetail(stats.SigningRequirementUnknown, nonAdminRepo))
+
+	signingConfig := config.NewGitXargsTestConfig()
+	signingConfig.GithubClient = mocks.ConfigureMockGithubClientRequiringSignedCommits()
+	signingConfig.CommitSigner = unusedSigner{}
+	reportSigningRequired(signingConfig, repo)
+	assert.Empty(t, signingConfig.Stats.GetRepos()[stats.SigningRequiredNotConfigured])
+}
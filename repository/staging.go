@@ -0,0 +1,198 @@
+package repository
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+	"github.com/google/go-github/v43/github"
+	"github.com/gruntwork-io/git-xargs/config"
+	"github.com/gruntwork-io/git-xargs/local"
+	"github.com/gruntwork-io/git-xargs/stats"
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/gruntwork-io/go-commons/logging"
+	"github.com/sirupsen/logrus"
+)
+
+// maxReportedUnstagedFiles is how many of the files left unstaged in a repo are listed in the run report
+const maxReportedUnstagedFiles = 20
+
+// worktreeStatus returns the changes the supplied command made to the worktree
+func worktreeStatus(config *config.GitXargsConfig, repositoryDir string) (git.Status, error) {
+	if isSparseCheckout(config) {
+		return local.GitCLIProvider{}.Status(repositoryDir)
+	}
+	return config.GitClient.Status(repositoryDir)
+}
+
+// selectChangesToStage splits the files the supplied command changed into the ones that should be committed, and the
+// ones that --stage-include and --stage-exclude leave out. Untracked files ignored by the repo's .gitignore files or
+// the global ignore file are in neither, as git would not stage them either. If status is nil, it's looked up
+func selectChangesToStage(config *config.GitXargsConfig, repositoryDir string, status git.Status) ([]string, []string, error) {
+	if status == nil {
+		var err error
+		if status, err = worktreeStatus(config, repositoryDir); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// git already leaves out untracked files ignored by the repo's .gitignore files, but go-git doesn't read the global
+	// ignore file
+	ignorePatterns, err := globalIgnorePatterns()
+	if err != nil {
+		return nil, nil, err
+	}
+	ignored := gitignore.NewMatcher(ignorePatterns)
+	include := gitignore.NewMatcher(parseStagingPatterns(config.StageInclude))
+	exclude := gitignore.NewMatcher(parseStagingPatterns(config.StageExclude))
+
+	var staged, leftOut []string
+	for path, fileStatus := range status {
+		if fileStatus.Staging == git.Unmodified && fileStatus.Worktree == git.Unmodified {
+			continue
+		}
+
+		pathParts := strings.Split(path, "/")
+		if status.IsUntracked(path) && ignored.Match(pathParts, false) {
+			continue
+		}
+
+		if (len(config.StageInclude) > 0 && !include.Match(pathParts, false)) || exclude.Match(pathParts, false) {
+			leftOut = append(leftOut, path)
+		} else {
+			staged = append(staged, path)
+		}
+	}
+
+	sort.Strings(staged)
+	sort.Strings(leftOut)
+	return staged, leftOut, nil
+}
+
+// stagedStatus returns the part of the given status that covers the files to be staged
+func stagedStatus(status git.Status, staged []string) git.Status {
+	filtered := git.Status{}
+	for _, path := range staged {
+		if fileStatus, ok := status[path]; ok {
+			filtered[path] = fileStatus
+		}
+	}
+	return filtered
+}
+
+// parseStagingPatterns parses the patterns passed via --stage-include or --stage-exclude, which are written as in a
+// .gitignore file at the root of the repo
+func parseStagingPatterns(patterns []string) []gitignore.Pattern {
+	var parsed []gitignore.Pattern
+	for _, pattern := range patterns {
+		parsed = append(parsed, gitignore.ParsePattern(pattern, nil))
+	}
+	return parsed
+}
+
+// globalIgnorePatterns returns the patterns in the global ignore file, which is the file core.excludesFile in the
+// global git config points to, or git's default of $XDG_CONFIG_HOME/git/ignore
+func globalIgnorePatterns() ([]gitignore.Pattern, error) {
+	path, err := globalIgnoreFile()
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	defer file.Close()
+
+	var patterns []gitignore.Pattern
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, gitignore.ParsePattern(line, nil))
+	}
+	return patterns, errors.WithStackTrace(scanner.Err())
+}
+
+// globalIgnoreFile returns the path of the global ignore file, which may not exist
+func globalIgnoreFile() (string, error) {
+	globalConfig, err := gitconfig.LoadConfig(gitconfig.GlobalScope)
+	if err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+
+	if excludesFile := globalConfig.Raw.Section("core").Options.Get("excludesfile"); excludesFile != "" {
+		if strings.HasPrefix(excludesFile, "~/") {
+			excludesFile = filepath.Join(home, excludesFile[2:])
+		}
+		return excludesFile, nil
+	}
+
+	if xdgConfigHome := os.Getenv("XDG_CONFIG_HOME"); xdgConfigHome != "" {
+		return filepath.Join(xdgConfigHome, "git", "ignore"), nil
+	}
+	return filepath.Join(home, ".config", "git", "ignore"), nil
+}
+
+// reportFilesLeftUnstaged lists the files --stage-include and --stage-exclude left out of the repo's commit in the run
+// report, so that files the command left behind by accident don't go unnoticed
+func reportFilesLeftUnstaged(config *config.GitXargsConfig, remoteRepository *github.Repository, leftOut []string) {
+	if len(leftOut) == 0 {
+		return
+	}
+
+	logger := logging.GetLogger("git-xargs")
+	logger.WithFields(logrus.Fields{
+		"Repo":  remoteRepository.GetName(),
+		"Files": len(leftOut),
+	}).Debug("Leaving files that don't match --stage-include or that match --stage-exclude unstaged")
+
+	detail := strings.Join(leftOut, ", ")
+	if len(leftOut) > maxReportedUnstagedFiles {
+		detail = fmt.Sprintf("%s and %d more", strings.Join(leftOut[:maxReportedUnstagedFiles], ", "), len(leftOut)-maxReportedUnstagedFiles)
+	}
+	config.Stats.TrackSingleWithDetail(stats.FilesLeftUnstaged, remoteRepository, detail)
+}
+
+// stageSelectedChanges stages only the given files, for when some of the files the supplied command changed are left
+// out of the commit. Submodules are staged separately, as go-git can't stage them
+func stageSelectedChanges(config *config.GitXargsConfig, repositoryDir string, staged []string) error {
+	submodules, err := submodulePaths(repositoryDir)
+	if err != nil {
+		return err
+	}
+
+	for _, path := range staged {
+		if slices.Contains(submodules, path) {
+			continue
+		}
+
+		var addErr error
+		if isSparseCheckout(config) {
+			_, addErr = local.RunGitCommand(repositoryDir, "add", "--sparse", "--", path)
+		} else {
+			addErr = config.GitClient.Add(repositoryDir, path)
+		}
+		if addErr != nil {
+			return errors.WithStackTrace(addErr)
+		}
+	}
+	return nil
+}
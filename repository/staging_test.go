@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/google/go-github/v43/github"
+	"github.com/gruntwork-io/git-xargs/config"
+	"github.com/gruntwork-io/git-xargs/local"
+	"github.com/gruntwork-io/git-xargs/stats"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// commitTestChanges commits the changes in the given clone, just as updateRepo would after running a command
+func commitTestChanges(t *testing.T, testConfig *config.GitXargsConfig, repositoryDir string, repo *github.Repository) {
+	status, err := worktreeStatus(testConfig, repositoryDir)
+	require.NoError(t, err)
+	staged, leftOut, err := selectChangesToStage(testConfig, repositoryDir, status)
+	require.NoError(t, err)
+	require.NoError(t, commitLocalChanges(status, staged, leftOut, testConfig, repositoryDir, repo))
+}
+
+// TestStageIncludeAndExclude ensures only the files matching --stage-include and not --stage-exclude are committed,
+// and that the rest are listed in the run report
+func TestStageIncludeAndExclude(t *testing.T) {
+	t.Parallel()
+
+	forEachGitBackend(t, func(t *testing.T, provider local.GitProvider) {
+		remoteURL, _ := createTestRemote(t)
+		testConfig, repo := newCloneTestConfig(remoteURL, provider, "staged-changes")
+		testConfig.StageInclude = []string{"modules/"}
+		testConfig.StageExclude = []string{"node_modules/"}
+
+		repositoryDir := cloneAndCheckoutTestBranch(t, testConfig, repo)
+		beforeCommand, err := getLocalRepoHeadRef(testConfig, repositoryDir, repo)
+		require.NoError(t, err)
+
+		testConfig.Args = []string{"bash", "-c", `
+set -e
+echo updated > modules/main.tf
+echo new > modules/new.tf
+mkdir -p modules/node_modules/left-pad
+echo artifact > modules/node_modules/left-pad/index.js
+echo updated > docs/README.md
+`}
+		require.NoError(t, executeCommand(testConfig, repositoryDir, repo))
+		require.NoError(t, updateRepo(testConfig, repositoryDir, repo, "refs/heads/staged-changes", beforeCommand, beforeCommand, false))
+
+		committed, err := local.RunGitCommand(repositoryDir, "diff-tree", "--no-commit-id", "--name-only", "-r", remoteBranchTip(t, remoteURL, "staged-changes"))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"modules/main.tf", "modules/new.tf"}, strings.Fields(committed))
+		assert.Equal(t, "docs/README.md, modules/node_modules/left-pad/index.js", testConfig.Stats.GetDetail(stats.FilesLeftUnstaged, repo))
+	})
+}
+
+// TestStageExcludeInSparseCheckout ensures excluded files are left out of sparse checkouts, without the files outside
+// the sparse directories being treated as deleted
+func TestStageExcludeInSparseCheckout(t *testing.T) {
+	t.Parallel()
+
+	forEachGitBackend(t, func(t *testing.T, provider local.GitProvider) {
+		remoteURL, _ := createTestRemote(t)
+		testConfig, repo := newPartialCloneTestConfig(remoteURL, provider, "new-branch")
+		testConfig.StageExclude = []string{".terraform/"}
+
+		repositoryDir := cloneAndCheckoutTestBranch(t, testConfig, repo)
+		require.NoError(t, os.WriteFile(filepath.Join(repositoryDir, "modules", "main.tf"), []byte("updated"), 0644))
+		require.NoError(t, os.MkdirAll(filepath.Join(repositoryDir, "modules", ".terraform"), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(repositoryDir, "modules", ".terraform", "plugin"), []byte("binary"), 0644))
+		commitTestChanges(t, testConfig, repositoryDir, repo)
+
+		committed, err := local.RunGitCommand(repositoryDir, "diff-tree", "--no-commit-id", "--name-status", "-r", "HEAD")
+		require.NoError(t, err)
+		assert.Equal(t, "M\tmodules/main.tf", strings.TrimSpace(committed))
+
+		status, err := local.RunGitCommand(repositoryDir, "status", "--porcelain", "--untracked-files=all")
+		require.NoError(t, err)
+		assert.Equal(t, "?? modules/.terraform/plugin", strings.TrimSpace(status))
+	})
+}
+
+// TestSelectChangesToStageRespectsGlobalIgnoreFile ensures untracked files matching the global ignore file are neither
+// committed nor reported, while tracked files matching it still are. It sets environment variables, so it can't run in
+// parallel
+func TestSelectChangesToStageRespectsGlobalIgnoreFile(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	require.NoError(t, os.MkdirAll(filepath.Join(home, ".config", "git"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(home, ".config", "git", "ignore"), []byte("# Editor files\n*.swp\n"), 0644))
+
+	testConfig := config.NewGitXargsTestConfig()
+	testConfig.StageExclude = []string{"*.log"}
+	status := git.Status{
+		"main.tf":       &git.FileStatus{Staging: git.Unmodified, Worktree: git.Modified},
+		"main.tf.swp":   &git.FileStatus{Staging: git.Untracked, Worktree: git.Untracked},
+		"tracked.swp":   &git.FileStatus{Staging: git.Unmodified, Worktree: git.Modified},
+		"terraform.log": &git.FileStatus{Staging: git.Untracked, Worktree: git.Untracked},
+	}
+
+	staged, leftOut, err := selectChangesToStage(testConfig, "", status)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"main.tf", "tracked.swp"}, staged)
+	assert.Equal(t, []string{"terraform.log"}, leftOut)
+}
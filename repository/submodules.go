@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/google/go-github/v43/github"
+	"github.com/gruntwork-io/git-xargs/config"
+	"github.com/gruntwork-io/git-xargs/local"
+	"github.com/gruntwork-io/git-xargs/stats"
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/gruntwork-io/go-commons/logging"
+	"github.com/sirupsen/logrus"
+)
+
+// initSubmodules checks out every submodule of the clone, and every submodule of those, at the commit the clone
+// records for it
+func initSubmodules(config *config.GitXargsConfig, repositoryDir string, gitAuth transport.AuthMethod) error {
+	return config.GitClient.UpdateSubmodules(repositoryDir, &git.SubmoduleUpdateOptions{
+		Init:              true,
+		RecurseSubmodules: git.DefaultSubmoduleRecursionDepth,
+		Auth:              gitAuth,
+	})
+}
+
+// submodulePaths returns the paths of the submodules listed in the clone's .gitmodules, relative to the root of the repo
+func submodulePaths(repositoryDir string) ([]string, error) {
+	contents, err := os.ReadFile(filepath.Join(repositoryDir, ".gitmodules"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+
+	modules := gitconfig.NewModules()
+	if err := modules.Unmarshal(contents); err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+
+	var paths []string
+	for _, submodule := range modules.Submodules {
+		paths = append(paths, submodule.Path)
+	}
+	return paths, nil
+}
+
+// changedSubmodules returns the paths of the submodules the supplied command changed files inside of. Those changes
+// belong to the submodule's own repo, so they can't be committed to this one. Files written into a submodule that was
+// never initialized aren't reported by git status at all
+func changedSubmodules(config *config.GitXargsConfig, repositoryDir string) ([]string, error) {
+	paths, err := submodulePaths(repositoryDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var changed []string
+	for _, path := range paths {
+		submoduleDir := filepath.Join(repositoryDir, path)
+
+		entries, err := os.ReadDir(submoduleDir)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, errors.WithStackTrace(err)
+		}
+
+		if _, err := os.Stat(filepath.Join(submoduleDir, ".git")); os.IsNotExist(err) {
+			if len(entries) > 0 {
+				changed = append(changed, path)
+			}
+			continue
+		}
+
+		status, err := config.GitClient.Status(submoduleDir)
+		if err != nil {
+			return nil, err
+		}
+		if !status.IsClean() {
+			changed = append(changed, path)
+		}
+	}
+
+	return changed, nil
+}
+
+// warnAboutSubmoduleChanges warns the operator about changes the supplied command made inside submodules, which
+// git-xargs doesn't commit
+func warnAboutSubmoduleChanges(config *config.GitXargsConfig, repositoryDir string, remoteRepository *github.Repository) {
+	logger := logging.GetLogger("git-xargs")
+
+	changed, err := changedSubmodules(config, repositoryDir)
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"Error": err,
+			"Repo":  remoteRepository.GetName(),
+		}).Debug("Error checking submodules for changes")
+		return
+	}
+	if len(changed) == 0 {
+		return
+	}
+
+	fields := logrus.Fields{
+		"Repo":       remoteRepository.GetName(),
+		"Submodules": strings.Join(changed, ", "),
+	}
+	if config.RecurseSubmodules {
+		logger.WithFields(fields).Warn("The command changed files inside submodules, which are not committed. Only changes to which commit a submodule is checked out at are committed")
+	} else {
+		logger.WithFields(fields).Warn("The command wrote files into submodules that were not checked out, which are not committed. Pass --recurse-submodules to check out submodules before running the command")
+	}
+
+	config.Stats.TrackSingleWithDetail(stats.SubmoduleChangesNotCommitted, remoteRepository, strings.Join(changed, ", "))
+}
+
+// stageSubmoduleUpdates stages the commits the supplied command checked out in any submodules. go-git can't stage
+// submodules, so git does instead
+func stageSubmoduleUpdates(status git.Status, repositoryDir string) error {
+	paths, err := submodulePaths(repositoryDir)
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		if fileStatus, ok := status[path]; ok && fileStatus.Worktree == git.Modified {
+			if _, err := local.RunGitCommand(repositoryDir, "add", "--", path); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
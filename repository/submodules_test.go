@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gruntwork-io/git-xargs/local"
+	"github.com/gruntwork-io/git-xargs/stats"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createTestRemoteWithSubmodule creates a bare repo with a single commit on main, which adds a submodule at lib that
+// refers to a second bare repo by a relative URL. The submodule's repo has two commits, the second of which is checked
+// out. It returns the superproject's file:// URL
+func createTestRemoteWithSubmodule(t *testing.T) string {
+	remotesDir := t.TempDir()
+
+	script := `
+set -e
+git init -q --bare "$REMOTES/lib.git"
+git -C "$REMOTES/lib.git" symbolic-ref HEAD refs/heads/main
+git init -q --bare "$REMOTES/app.git"
+git -C "$REMOTES/app.git" symbolic-ref HEAD refs/heads/main
+git init -q -b main lib
+echo 1 > lib/version.txt
+git -C lib add -A
+git -C lib commit -q -m "lib 1"
+echo 2 > lib/version.txt
+git -C lib commit -q -am "lib 2"
+git -C lib push -q "$REMOTES/lib.git" main
+git init -q -b main app
+git -C app remote add origin "$REMOTES/app.git"
+git -C app -c protocol.file.allow=always submodule -q add ../lib.git lib
+git -C app commit -q -m "add lib"
+git -C app push -q origin main
+`
+	cmd := exec.Command("bash", "-c", script)
+	cmd.Dir = t.TempDir()
+	cmd.Env = append(os.Environ(), "REMOTES="+remotesDir)
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	return "file://" + filepath.Join(remotesDir, "app.git")
+}
+
+// allowFileSubmodules lets git clone submodules from the local filesystem, which it refuses to by default
+func allowFileSubmodules(t *testing.T) {
+	t.Setenv("GIT_CONFIG_COUNT", "1")
+	t.Setenv("GIT_CONFIG_KEY_0", "protocol.file.allow")
+	t.Setenv("GIT_CONFIG_VALUE_0", "always")
+}
+
+// TestRecurseSubmodules ensures submodules are checked out, that checking out a different commit in a submodule is
+// committed, and that other changes inside the submodule are reported rather than committed
+func TestRecurseSubmodules(t *testing.T) {
+	allowFileSubmodules(t)
+
+	forEachGitBackend(t, func(t *testing.T, provider local.GitProvider) {
+		remoteURL := createTestRemoteWithSubmodule(t)
+		testConfig, repo := newCloneTestConfig(remoteURL, provider, "new-branch")
+		testConfig.RecurseSubmodules = true
+
+		repositoryDir := cloneAndCheckoutTestBranch(t, testConfig, repo)
+		libDir := filepath.Join(repositoryDir, "lib")
+
+		version, err := os.ReadFile(filepath.Join(libDir, "version.txt"))
+		require.NoError(t, err)
+		assert.Equal(t, "2\n", string(version))
+
+		_, err = local.RunGitCommand(libDir, "checkout", "-q", "HEAD~1")
+		require.NoError(t, err)
+		libCommit, err := local.RunGitCommand(libDir, "rev-parse", "HEAD")
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(libDir, "untracked.txt"), []byte("untracked"), 0644))
+
+		warnAboutSubmoduleChanges(testConfig, repositoryDir, repo)
+		assert.Equal(t, "lib", testConfig.Stats.GetDetail(stats.SubmoduleChangesNotCommitted, repo))
+
+		commitTestChanges(t, testConfig, repositoryDir, repo)
+
+		committed, err := local.RunGitCommand(repositoryDir, "rev-parse", "HEAD:lib")
+		require.NoError(t, err)
+		assert.Equal(t, libCommit, committed)
+	})
+}
+
+// TestSubmoduleChangesWithoutRecursion ensures files written into submodules that were never checked out are reported
+func TestSubmoduleChangesWithoutRecursion(t *testing.T) {
+	t.Parallel()
+
+	forEachGitBackend(t, func(t *testing.T, provider local.GitProvider) {
+		remoteURL := createTestRemoteWithSubmodule(t)
+		testConfig, repo := newCloneTestConfig(remoteURL, provider, "new-branch")
+
+		repositoryDir := cloneAndCheckoutTestBranch(t, testConfig, repo)
+		assert.NoFileExists(t, filepath.Join(repositoryDir, "lib", "version.txt"))
+
+		warnAboutSubmoduleChanges(testConfig, repositoryDir, repo)
+		assert.Empty(t, testConfig.Stats.GetRepos()[stats.SubmoduleChangesNotCommitted])
+
+		require.NoError(t, os.MkdirAll(filepath.Join(repositoryDir, "lib"), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(repositoryDir, "lib", "version.txt"), []byte("3\n"), 0644))
+
+		status, err := testConfig.GitClient.Status(repositoryDir)
+		require.NoError(t, err)
+		assert.True(t, status.IsClean())
+
+		warnAboutSubmoduleChanges(testConfig, repositoryDir, repo)
+		assert.Equal(t, "lib", strings.TrimSpace(testConfig.Stats.GetDetail(stats.SubmoduleChangesNotCommitted, repo)))
+	})
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v43/github"
+	"github.com/gruntwork-io/git-xargs/config"
+	"github.com/gruntwork-io/git-xargs/stats"
+	"github.com/gruntwork-io/git-xargs/types"
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/gruntwork-io/go-commons/logging"
+	"github.com/sirupsen/logrus"
+)
+
+// maxReportedValidationLines is how many of the last lines of a failed --validate-cmd command's output are listed in
+// the run report. The full output is logged
+const maxReportedValidationLines = 5
+
+// validationWaitDelay is how long to wait for the output of a timed out --validate-cmd command, which processes it
+// started may still be holding on to, once it has been stopped
+const validationWaitDelay = 5 * time.Second
+
+// validateChanges runs the --validate-cmd command, if any, in the repo once the supplied command has changed it. It runs
+// through the shell, with the same environment as the supplied command, and is stopped if it runs for longer than
+// --validate-timeout. If it fails or times out, the repo is reported with types.ValidationFailedErr, separately from
+// failures of the supplied command, and nothing is committed or pushed
+func validateChanges(config *config.GitXargsConfig, repositoryDir string, repo *github.Repository) error {
+	if config.ValidateCmd == "" {
+		return nil
+	}
+
+	logger := logging.GetLogger("git-xargs")
+
+	ctx, cancel := context.WithTimeout(context.Background(), config.ValidateTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", config.ValidateCmd)
+	cmd.Dir = repositoryDir
+	cmd.Env = commandEnv(config, repo)
+	cmd.WaitDelay = validationWaitDelay
+
+	logger.WithFields(logrus.Fields{
+		"Repo":      repo.GetName(),
+		"Directory": repositoryDir,
+		"Command":   config.ValidateCmd,
+	}).Debug("Validating changes made by command...")
+
+	output, err := cmd.CombinedOutput()
+	if ctx.Err() == context.DeadlineExceeded {
+		err = types.ValidationTimedOutErr{Timeout: config.ValidateTimeout}
+	}
+
+	logger.Debugf("Output of validation command %q for repo %s in directory %s:\n%s", config.ValidateCmd, repo.GetName(), repositoryDir, string(output))
+
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"Error": err,
+			"Repo":  repo.GetName(),
+		}).Debug("Validation command failed")
+
+		config.Stats.TrackSingleWithDetail(stats.ValidationFailed, repo, validationOutputSummary(err, string(output)))
+		return errors.WithStackTrace(types.ValidationFailedErr{Command: config.ValidateCmd, Underlying: err})
+	}
+
+	return nil
+}
+
+// validationOutputSummary returns the reason the validation command failed, followed by the last lines of its output,
+// on a single line so that it fits in the run report
+func validationOutputSummary(err error, output string) string {
+	var lines []string
+	for _, line := range strings.Split(output, "\n") {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, strings.TrimSpace(line))
+		}
+	}
+	if len(lines) > maxReportedValidationLines {
+		lines = lines[len(lines)-maxReportedValidationLines:]
+	}
+	return strings.Join(append([]string{err.Error()}, lines...), " | ")
+}
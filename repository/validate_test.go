@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/git-xargs/config"
+	"github.com/gruntwork-io/git-xargs/stats"
+	"github.com/gruntwork-io/git-xargs/types"
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateChanges(t *testing.T) {
+	t.Parallel()
+
+	repositoryDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(repositoryDir, "main.tf"), []byte("updated"), 0644))
+
+	testConfig := config.NewGitXargsTestConfig()
+	testConfig.ValidateCmd = `test -f main.tf && [ "$XARGS_DRY_RUN" = false ]`
+	repo := getMockGithubRepo()
+
+	require.NoError(t, validateChanges(testConfig, repositoryDir, repo))
+	assert.Empty(t, testConfig.Stats.GetRepos()[stats.ValidationFailed])
+}
+
+// TestValidateChangesFailure ensures a failed validation is reported with the end of its output, separately from
+// failures of the supplied command
+func TestValidateChangesFailure(t *testing.T) {
+	t.Parallel()
+
+	testConfig := config.NewGitXargsTestConfig()
+	testConfig.ValidateCmd = `for i in 1 2 3 4 5 6; do echo "line $i"; done; echo "$XARGS_REPO_NAME does not build" >&2; exit 3`
+	repo := getMockGithubRepo()
+
+	err := validateChanges(testConfig, t.TempDir(), repo)
+	require.Error(t, err)
+	assert.IsType(t, types.ValidationFailedErr{}, errors.Unwrap(err))
+	assert.Equal(t, "exit status 3 | line 3 | line 4 | line 5 | line 6 | terragrunt does not build", testConfig.Stats.GetDetail(stats.ValidationFailed, repo))
+	assert.Empty(t, testConfig.Stats.GetRepos()[stats.CommandErrorOccurredDuringExecution])
+}
+
+// TestValidateChangesTimeout ensures a validation command that runs for longer than --validate-timeout is stopped, and
+// reported as a failed validation along with the reason
+func TestValidateChangesTimeout(t *testing.T) {
+	t.Parallel()
+
+	testConfig := config.NewGitXargsTestConfig()
+	testConfig.ValidateCmd = `echo "starting"; exec sleep 30`
+	testConfig.ValidateTimeout = 100 * time.Millisecond
+	repo := getMockGithubRepo()
+
+	start := time.Now()
+	err := validateChanges(testConfig, t.TempDir(), repo)
+	require.Error(t, err)
+	assert.Less(t, time.Since(start), 20*time.Second)
+	assert.Equal(t, types.ValidationFailedErr{Command: testConfig.ValidateCmd, Underlying: types.ValidationTimedOutErr{Timeout: 100 * time.Millisecond}}, errors.Unwrap(err))
+	assert.Equal(t, "timed out after 100ms | starting", testConfig.Stats.GetDetail(stats.ValidationFailed, repo))
+}
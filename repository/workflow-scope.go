@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/google/go-github/v43/github"
+	"github.com/gruntwork-io/git-xargs/config"
+	"github.com/gruntwork-io/git-xargs/stats"
+	"github.com/gruntwork-io/git-xargs/types"
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/gruntwork-io/go-commons/logging"
+	"github.com/sirupsen/logrus"
+)
+
+// workflowsDir is where GitHub Actions workflows live. GitHub rejects pushes that change files under it unless the
+// token has the workflow scope
+const workflowsDir = ".github/workflows/"
+
+// checkWorkflowScope returns types.WorkflowScopeMissingErr if the branch about to be pushed changes any of the supplied
+// GitHub Actions workflows, but the token it's pushed with lacks the workflow scope GitHub requires to push such
+// changes. The repo is reported along with the workflows its changes touch, rather than failing to push with a less
+// helpful error. Only tokens pushed over HTTPS are checked: SSH keys, credential helpers and GitHub App installation
+// tokens don't have scopes, so for them it's left to GitHub to decide
+func checkWorkflowScope(config *config.GitXargsConfig, remoteRepository *github.Repository, gitAuth transport.AuthMethod, workflows []string) error {
+	basicAuth, ok := gitAuth.(*http.BasicAuth)
+	if len(workflows) == 0 || config.TokenScopes == nil || !ok {
+		return nil
+	}
+
+	logger := logging.GetLogger("git-xargs")
+
+	scopes, err := config.TokenScopes.Get(basicAuth.Password)
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"Repo":  remoteRepository.GetName(),
+			"Error": err,
+		}).Debug("Could not look up the scopes granted to the GitHub token")
+		return nil
+	}
+	if scopes == nil || scopes["workflow"] {
+		return nil
+	}
+
+	logger.WithFields(logrus.Fields{
+		"Repo":      remoteRepository.GetName(),
+		"Workflows": workflows,
+	}).Debug("Not pushing changes to workflows because the token lacks the workflow scope")
+
+	config.Stats.TrackSingleWithDetail(stats.WorkflowScopeMissing, remoteRepository, "changes "+strings.Join(workflows, ", "))
+	return errors.WithStackTrace(types.WorkflowScopeMissingErr{Files: workflows})
+}
+
+// changedWorkflows returns the workflows the branch changes, compared to the given base commit. The changes are only
+// worked out if the scopes of the token they'll be pushed with are going to be checked
+func changedWorkflows(config *config.GitXargsConfig, repositoryDir string, base plumbing.Hash) ([]string, error) {
+	if config.TokenScopes == nil {
+		return nil, nil
+	}
+
+	localRepository, err := git.PlainOpen(repositoryDir)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+
+	head, err := localRepository.Head()
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	baseTree, err := commitTree(localRepository, base)
+	if err != nil {
+		return nil, err
+	}
+	headTree, err := commitTree(localRepository, head.Hash())
+	if err != nil {
+		return nil, err
+	}
+
+	changes, err := object.DiffTree(baseTree, headTree)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+
+	var workflows []string
+	for _, change := range changes {
+		for _, name := range []string{change.From.Name, change.To.Name} {
+			if strings.HasPrefix(name, workflowsDir) {
+				workflows = append(workflows, name)
+				break
+			}
+		}
+	}
+	return workflows, nil
+}
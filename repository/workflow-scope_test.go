@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
+
+	"github.com/gruntwork-io/git-xargs/auth"
+	"github.com/gruntwork-io/git-xargs/config"
+	"github.com/gruntwork-io/git-xargs/local"
+	"github.com/gruntwork-io/git-xargs/mocks"
+	"github.com/gruntwork-io/git-xargs/stats"
+	"github.com/gruntwork-io/git-xargs/types"
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testWorkflowDiff adds a GitHub Actions workflow
+const testWorkflowDiff = `diff --git a/.github/workflows/ci.yml b/.github/workflows/ci.yml
+new file mode 100644
+--- /dev/null
++++ b/.github/workflows/ci.yml
+@@ -0,0 +1 @@
++on: push
+`
+
+// newTestTokenScopes returns a TokenScopes that looks up tokens on a test server, which reports the given scopes for
+// each token
+func newTestTokenScopes(t *testing.T, scopes map[string]string) *auth.TokenScopes {
+	apiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if tokenScopes := scopes[strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")]; tokenScopes != "" {
+			w.Header().Set("X-OAuth-Scopes", tokenScopes)
+		}
+		w.Write([]byte(`{"login": "git-xargs"}`))
+	}))
+	t.Cleanup(apiServer.Close)
+
+	server, err := auth.NewGithubServer(auth.ServerOptions{APIURL: apiServer.URL})
+	require.NoError(t, err)
+	return auth.NewTokenScopes(server)
+}
+
+// TestCheckWorkflowScope ensures changes to workflows aren't pushed if the token pushing them lacks the workflow scope,
+// and that they're pushed if it has it, or if its scopes aren't known
+func TestCheckWorkflowScope(t *testing.T) {
+	t.Parallel()
+
+	tokenScopes := newTestTokenScopes(t, map[string]string{"repo-token": "repo", "workflow-token": "repo, workflow"})
+
+	forEachGitBackend(t, func(t *testing.T, provider local.GitProvider) {
+		remoteURL, _ := createTestRemote(t)
+
+		testConfig, repo, repositoryDir := newApplyPatchTestConfig(t, remoteURL, provider, writeTestPatch(t, testWorkflowDiff))
+		testConfig.TokenScopes = tokenScopes
+		testConfig.GitCredentials = auth.TokenCredentials{Token: "repo-token"}
+		beforeCommand, err := getLocalRepoHeadRef(testConfig, repositoryDir, repo)
+		require.NoError(t, err)
+
+		require.NoError(t, applyPatchFile(testConfig, repositoryDir, repo))
+		updateErr := updateRepo(testConfig, repositoryDir, repo, "refs/heads/patched", beforeCommand, beforeCommand, false)
+		require.Error(t, updateErr)
+		assert.Equal(t, types.WorkflowScopeMissingErr{Files: []string{".github/workflows/ci.yml"}}, errors.Unwrap(updateErr))
+		assert.Equal(t, "changes .github/workflows/ci.yml", testConfig.Stats.GetDetail(stats.WorkflowScopeMissing, repo))
+		_, err = local.RunGitCommand("", "ls-remote", "--exit-code", remoteURL, "refs/heads/patched")
+		assert.Error(t, err)
+
+		for _, token := range []string{"workflow-token", "fine-grained-token"} {
+			remoteURL, _ := createTestRemote(t)
+			testConfig, repo, repositoryDir = newApplyPatchTestConfig(t, remoteURL, provider, writeTestPatch(t, testWorkflowDiff))
+			testConfig.TokenScopes = tokenScopes
+			testConfig.GitCredentials = auth.TokenCredentials{Token: token}
+			beforeCommand, err = getLocalRepoHeadRef(testConfig, repositoryDir, repo)
+			require.NoError(t, err)
+
+			require.NoError(t, applyPatchFile(testConfig, repositoryDir, repo))
+			require.NoError(t, updateRepo(testConfig, repositoryDir, repo, "refs/heads/patched", beforeCommand, beforeCommand, false))
+			assert.Empty(t, testConfig.Stats.GetRepos()[stats.WorkflowScopeMissing])
+			assert.NotEmpty(t, remoteBranchTip(t, remoteURL, "patched"))
+		}
+	})
+}
+
+// TestCheckWorkflowScopeOnlyChecksTokens ensures pushes that aren't authenticated with a token aren't checked
+func TestCheckWorkflowScopeOnlyChecksTokens(t *testing.T) {
+	t.Parallel()
+
+	testConfig := config.NewGitXargsTestConfig()
+	testConfig.TokenScopes = newTestTokenScopes(t, map[string]string{"repo-token": "repo"})
+	repo := mocks.GetMockGithubRepo()
+	workflows := []string{".github/workflows/ci.yml"}
+
+	require.Error(t, checkWorkflowScope(testConfig, repo, &githttp.BasicAuth{Username: "owner", Password: "repo-token"}, workflows))
+	require.NoError(t, checkWorkflowScope(testConfig, repo, &ssh.PublicKeysCallback{User: "git"}, workflows))
+}
@@ -1,6 +1,7 @@
 package stats
 
 import (
+	"sort"
 	"sync"
 	"time"
 
@@ -70,7 +71,8 @@ const (
 	RepoFlagSuppliedRepoMalformed types.Event = "repo-flag-supplied-repo-malformed"
 	// RepoDoesntSupportDraftPullRequestsErr denotes a repo that is incompatible with the submitted pull request configuration
 	RepoDoesntSupportDraftPullRequestsErr types.Event = "repo-not-compatible-with-pull-config"
-	// BaseBranchTargetInvalidErr denotes a repo that does not have the base branch specified by the user
+	// BaseBranchTargetInvalidErr denotes a repo that does not have the base branch specified by the user, either via
+	// --base-branch-name or alongside the repo itself
 	BaseBranchTargetInvalidErr types.Event = "base-branch-target-invalid"
 	// PRFailedDueToRateLimits denotes a repo whose initial pull request failed as a result of being rate limited by GitHub
 	PRFailedDueToRateLimitsErr types.Event = "pr-failed-due-to-rate-limits"
@@ -78,6 +80,60 @@ const (
 	PRFailedAfterMaximumRetriesErr types.Event = "pr-failed-after-maximum-retries"
 	// RequestReviewersErr denotes a repo whose follow up request to add reviewers to the opened pull request failed
 	RequestReviewersErr types.Event = "request-reviewers-error"
+	// PreflightCheckFailed denotes a repo that was skipped because the preflight check found git-xargs lacks the permissions needed to push to it or open a pull request against it
+	PreflightCheckFailed types.Event = "preflight-check-failed"
+	// WorkflowScopeMissing denotes a repo whose changes touch GitHub Actions workflows, which were not pushed because the token lacks the workflow scope
+	WorkflowScopeMissing types.Event = "workflow-scope-missing"
+	// BranchRebased denotes a repo whose existing branch had its commits replayed on top of the latest base branch because --branch-strategy rebase was passed
+	BranchRebased types.Event = "branch-rebased"
+	// BranchRecreated denotes a repo whose existing branch was started over from the latest base branch because --branch-strategy recreate was passed
+	BranchRecreated types.Event = "branch-recreated"
+	// BranchResetToBase denotes a repo whose existing branch was reset to the latest base branch because --branch-strategy recreate was passed and the supplied command made no changes
+	BranchResetToBase types.Event = "branch-reset-to-base"
+	// BranchRebaseConflict denotes a repo whose existing branch could not be rebased because its commits conflict with the latest base branch
+	BranchRebaseConflict types.Event = "branch-rebase-conflict"
+	// SubmoduleChangesNotCommitted denotes a repo in which the supplied command changed files inside submodules, which were not committed
+	SubmoduleChangesNotCommitted types.Event = "submodule-changes-not-committed"
+	// LFSObjectsFetched denotes a repo that uses Git LFS, whose LFS objects were downloaded into the worktree before the supplied command ran
+	LFSObjectsFetched types.Event = "lfs-objects-fetched"
+	// LFSRepoSkipped denotes a repo that uses Git LFS, which was skipped because git-xargs can't safely update it with the chosen git backend
+	LFSRepoSkipped types.Event = "lfs-repo-skipped"
+	// LFSFetchFailed denotes a repo that uses Git LFS, whose LFS objects could not be downloaded
+	LFSFetchFailed types.Event = "lfs-fetch-failed"
+	// LFSPushFailed denotes a repo that uses Git LFS, whose LFS objects could not be uploaded before pushing the branch
+	LFSPushFailed types.Event = "lfs-push-failed"
+	// SigningRequiredNotConfigured denotes a repo whose base branch requires signed commits, which git-xargs was not configured to sign
+	SigningRequiredNotConfigured types.Event = "signing-required-not-configured"
+	// SigningRequirementUnknown denotes a repo whose base branch protection the token isn't allowed to read, so whether it requires signed commits is unknown
+	SigningRequirementUnknown types.Event = "signing-requirement-unknown"
+	// CommandCreatedCommits denotes a repo in which the supplied command made commits of its own, which were pushed
+	CommandCreatedCommits types.Event = "command-created-commits"
+	// CommandChangedBranch denotes a repo in which the supplied command checked out a different branch, so nothing was pushed
+	CommandChangedBranch types.Event = "command-changed-branch"
+	// CommandRewroteHistory denotes a repo in which the supplied command reset or rebased the branch past the commit it started from, so nothing was pushed
+	CommandRewroteHistory types.Event = "command-rewrote-history"
+	// FilesLeftUnstaged denotes a repo in which the supplied command changed files that --stage-include or --stage-exclude left out of the commit
+	FilesLeftUnstaged types.Event = "files-left-unstaged"
+	// ChangeTooLarge denotes a repo in which the supplied command's changes exceeded --max-changed-files, --max-changed-lines or --max-file-size, so nothing was pushed
+	ChangeTooLarge types.Event = "change-too-large"
+	// SecretsFound denotes a repo in which the supplied command's changes contained possible secrets, so nothing was pushed
+	SecretsFound types.Event = "secrets-found"
+	// SecretScanFailed denotes a repo whose changes could not be scanned for secrets, so nothing was pushed
+	SecretScanFailed types.Event = "secret-scan-failed"
+	// ValidationFailed denotes a repo in which the --validate-cmd command failed after the supplied command ran, so nothing was committed or pushed
+	ValidationFailed types.Event = "validation-failed"
+	// ReviewSkipped denotes a repo whose changes were not approved during an --interactive review, so nothing was pushed
+	ReviewSkipped types.Event = "review-skipped"
+	// PatchWriteFailed denotes a repo whose changes could not be written to --dry-run-output-dir as a patch
+	PatchWriteFailed types.Event = "patch-write-failed"
+	// PatchDidNotApply denotes a repo to which the patch passed to apply-patch, or its patch from the bundle being published, doesn't apply, so nothing was pushed
+	PatchDidNotApply types.Event = "patch-did-not-apply"
+	// BundleBaseMoved denotes a repo whose branch moved on since the bundle being published was generated, but whose patch still applied
+	BundleBaseMoved types.Event = "bundle-base-moved"
+	// CherryPickConflict denotes a repo into which the commit passed via --cherry-pick conflicted, so nothing was pushed
+	CherryPickConflict types.Event = "cherry-pick-conflict"
+	// CherryPickFailed denotes a repo into which the commit passed via --cherry-pick could not be fetched or cherry-picked for a reason other than a conflict
+	CherryPickFailed types.Event = "cherry-pick-failed"
 )
 
 var allEvents = []types.AnnotatedEvent{
@@ -108,10 +164,37 @@ var allEvents = []types.AnnotatedEvent{
 	{Event: BranchRemoteDidntExistYet, Description: "Repos whose specified branches did not exist on the remote, and so were first created locally"},
 	{Event: RepoFlagSuppliedRepoMalformed, Description: "Repos passed via the --repo flag that were malformed (missing their Github org prefix?) and therefore unprocessable"},
 	{Event: RepoDoesntSupportDraftPullRequestsErr, Description: "Repos that do not support Draft PRs (--draft flag was passed)"},
-	{Event: BaseBranchTargetInvalidErr, Description: "Repos that did not have the branch specified by --base-branch-name"},
+	{Event: BaseBranchTargetInvalidErr, Description: "Repos that did not have the base branch specified by --base-branch-name or org/repo@branch"},
 	{Event: PRFailedDueToRateLimitsErr, Description: "Repos whose initial Pull Request failed to be created due to GitHub rate limits"},
 	{Event: PRFailedAfterMaximumRetriesErr, Description: "Repos whose Pull Request failed to be created after the maximum number of retries"},
 	{Event: RequestReviewersErr, Description: "Repos whose request to add reviewers to the opened pull request failed"},
+	{Event: PreflightCheckFailed, Description: "Repos that were skipped because the preflight check found git-xargs lacks the permissions to update them"},
+	{Event: WorkflowScopeMissing, Description: "Repos whose changes touch GitHub Actions workflows, which were not pushed because the token lacks the workflow scope"},
+	{Event: BranchRebased, Description: "Repos whose existing branch was rebased onto the latest base branch"},
+	{Event: BranchRecreated, Description: "Repos whose existing branch was started over from the latest base branch"},
+	{Event: BranchResetToBase, Description: "Repos whose existing branch was reset to the latest base branch because the command made no changes, so no pull request was opened"},
+	{Event: BranchRebaseConflict, Description: "Repos whose existing branch could not be rebased because it conflicts with the latest base branch"},
+	{Event: SubmoduleChangesNotCommitted, Description: "Repos in which the command changed files inside submodules, which were not committed"},
+	{Event: LFSObjectsFetched, Description: "Repos that use Git LFS, whose LFS objects were downloaded before running the command"},
+	{Event: LFSRepoSkipped, Description: "Repos that were skipped because they use Git LFS, which requires --git-backend cli and git-lfs"},
+	{Event: LFSFetchFailed, Description: "Repos that use Git LFS, whose LFS objects could not be downloaded"},
+	{Event: LFSPushFailed, Description: "Repos that use Git LFS, whose LFS objects could not be uploaded"},
+	{Event: CommandCreatedCommits, Description: "Repos in which the command made commits of its own, which were pushed"},
+	{Event: CommandChangedBranch, Description: "Repos that were not updated because the command checked out a different branch"},
+	{Event: CommandRewroteHistory, Description: "Repos that were not updated because the command rewrote the branch's existing history"},
+	{Event: FilesLeftUnstaged, Description: "Repos in which the command changed files that --stage-include or --stage-exclude left uncommitted"},
+	{Event: ChangeTooLarge, Description: "Repos that were not updated because the command's changes exceeded the change size limits"},
+	{Event: SecretsFound, Description: "Repos that were not updated because the command's changes contained possible secrets"},
+	{Event: SecretScanFailed, Description: "Repos that were not updated because the command's changes could not be scanned for secrets"},
+	{Event: ValidationFailed, Description: "Repos that were not updated because the --validate-cmd command failed after the command ran"},
+	{Event: ReviewSkipped, Description: "Repos that were skipped during --interactive review"},
+	{Event: PatchWriteFailed, Description: "Repos whose changes could not be written to --dry-run-output-dir as a patch"},
+	{Event: PatchDidNotApply, Description: "Repos that were not updated because the patch, or their patch from the bundle, doesn't apply"},
+	{Event: BundleBaseMoved, Description: "Repos whose branch moved on since the bundle was generated, but whose patch still applied"},
+	{Event: CherryPickConflict, Description: "Repos that were not updated because the commit passed via --cherry-pick conflicted"},
+	{Event: CherryPickFailed, Description: "Repos into which the commit passed via --cherry-pick could not be fetched or cherry-picked"},
+	{Event: SigningRequiredNotConfigured, Description: "Repos whose base branch requires signed commits, but --sign-commits was not passed"},
+	{Event: SigningRequirementUnknown, Description: "Repos whose base branch protection the token can't read, so whether it requires signed commits is unknown"},
 }
 
 // RunStats will be a stats-tracker class that keeps score of which repos were touched, which were considered for update, which had branches made, PRs made, which were missing workflows or contexts, or had out of date workflows syntax values, etc
@@ -122,10 +205,17 @@ type RunStats struct {
 	pulls                 map[string]string
 	draftpulls            map[string]string
 	command               []string
+	cherryPick            string
+	patchFile             string
+	commitAuthor          string
+	commitTrailers        []string
 	fileProvidedRepos     []*types.AllowedRepo
 	repoFlagProvidedRepos []*types.AllowedRepo
 	startTime             time.Time
 	skipPullRequests      bool
+	tokenUsage            []types.TokenUsage
+	patches               []types.PatchSummary
+	details               map[types.Event]map[string]string
 	mutex                 *sync.Mutex
 }
 
@@ -144,6 +234,7 @@ func NewStatsTracker() *RunStats {
 		repoFlagProvidedRepos: repoFlagProvidedRepos,
 		startTime:             time.Now(),
 		skipPullRequests:      false,
+		details:               make(map[types.Event]map[string]string),
 		mutex:                 &sync.Mutex{},
 	}
 	return t
@@ -209,11 +300,33 @@ func (r *RunStats) SetSkipPullRequests(skipPullRequests bool) {
 	r.skipPullRequests = skipPullRequests
 }
 
+// SetTokenUsage records how many API requests were made with each token in the token pool, if one was used
+func (r *RunStats) SetTokenUsage(tokenUsage []types.TokenUsage) {
+	r.tokenUsage = tokenUsage
+}
+
 // SetCommand sets the user-supplied command to be run against the targeted repos
 func (r *RunStats) SetCommand(c []string) {
 	r.command = c
 }
 
+// SetCherryPick sets the commit cherry-picked into the targeted repos in place of a command, if any
+func (r *RunStats) SetCherryPick(cherryPick string) {
+	r.cherryPick = cherryPick
+}
+
+// SetPatchFile sets the patch applied to the targeted repos in place of a command, if any
+func (r *RunStats) SetPatchFile(patchFile string) {
+	r.patchFile = patchFile
+}
+
+// SetCommitIdentity records who commits are authored by, if the user chose an identity, and the trailers added to
+// each commit message
+func (r *RunStats) SetCommitIdentity(author string, trailers []string) {
+	r.commitAuthor = author
+	r.commitTrailers = trailers
+}
+
 // GetMultiple returns the slice of pointers to GitHub repositories filed under the provided event's key
 func (r *RunStats) GetMultiple(event types.Event) []*github.Repository {
 	return r.repos[event]
@@ -227,6 +340,27 @@ func (r *RunStats) TrackSingle(event types.Event, repo *github.Repository) {
 	r.repos[event] = TrackEventIfMissing(r.repos[event], repo)
 }
 
+// TrackSingleWithDetail tracks the supplied repo under the given event in the same way as TrackSingle, but also records
+// a short explanation of what happened to the repo, which is printed alongside it in the final report. Explanations are
+// keyed by the repo's full name, so that repos with the same name in different organizations don't overwrite each other
+func (r *RunStats) TrackSingleWithDetail(event types.Event, repo *github.Repository, detail string) {
+	r.TrackSingle(event, repo)
+
+	defer r.mutex.Unlock()
+	r.mutex.Lock()
+	if r.details[event] == nil {
+		r.details[event] = make(map[string]string)
+	}
+	r.details[event][repo.GetFullName()] = detail
+}
+
+// GetDetail returns the explanation recorded for the supplied repo under the given event, if any
+func (r *RunStats) GetDetail(event types.Event, repo *github.Repository) string {
+	defer r.mutex.Unlock()
+	r.mutex.Lock()
+	return r.details[event][repo.GetFullName()]
+}
+
 // TrackEventIfMissing prevents the addition of duplicates to the tracking slices. Repos may end up with file changes
 // for example, from multiple command runs, so we don't need the same repo repeated multiple times in the final report
 func TrackEventIfMissing(slice []*github.Repository, repo *github.Repository) []*github.Repository {
@@ -256,6 +390,23 @@ func (r *RunStats) TrackDraftPullRequest(repoName, prURL string) {
 	r.draftpulls[repoName] = prURL
 }
 
+// TrackPatch records the patch written for a repo instead of pushing its changes
+// This function is safe to call from concurrent goroutines
+func (r *RunStats) TrackPatch(patch types.PatchSummary) {
+	defer r.mutex.Unlock()
+	r.mutex.Lock()
+	r.patches = append(r.patches, patch)
+}
+
+// GetPatches returns the patches written during the run, ordered by repo
+func (r *RunStats) GetPatches() []types.PatchSummary {
+	defer r.mutex.Unlock()
+	r.mutex.Lock()
+	patches := append([]types.PatchSummary{}, r.patches...)
+	sort.Slice(patches, func(i, j int) bool { return patches[i].Repo < patches[j].Repo })
+	return patches
+}
+
 // TrackMultiple accepts a types.Event and a slice of pointers to GitHub repos that will all be associated with that event
 func (r *RunStats) TrackMultiple(event types.Event, repos []*github.Repository) {
 	for _, repo := range repos {
@@ -269,10 +420,17 @@ func (r *RunStats) GenerateRunReport() *types.RunReport {
 		Repos:          r.GetRepos(),
 		SkippedRepos:   r.GetSkippedArchivedRepos(),
 		Command:        r.command,
+		CherryPick:     r.cherryPick,
+		PatchFile:      r.patchFile,
+		CommitAuthor:   r.commitAuthor,
+		CommitTrailers: r.commitTrailers,
 		SelectionMode:  r.selectionMode,
 		RuntimeSeconds: r.GetTotalRunSeconds(), FileProvidedRepos: r.GetFileProvidedRepos(),
 		PullRequests:      r.GetPullRequests(),
 		DraftPullRequests: r.GetDraftPullRequests(),
+		TokenUsage:        r.tokenUsage,
+		Patches:           r.GetPatches(),
+		Details:           r.details,
 	}
 }
 
@@ -2,6 +2,7 @@ package types
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/go-github/v43/github"
@@ -20,11 +21,18 @@ type RunReport struct {
 	Repos             map[Event][]*github.Repository
 	SkippedRepos      map[Event][]*github.Repository
 	Command           []string
+	CherryPick        string
+	PatchFile         string
+	CommitAuthor      string
+	CommitTrailers    []string
 	SelectionMode     string
 	RuntimeSeconds    int
 	FileProvidedRepos []*AllowedRepo
 	PullRequests      map[string]string
 	DraftPullRequests map[string]string
+	TokenUsage        []TokenUsage
+	Patches           []PatchSummary
+	Details           map[Event]map[string]string
 }
 
 // AnnotatedEvent is used in printing the final report. It contains the info to print a section's table - both its Event for looking up the tagged repos, and the human-legible description for printing above the table
@@ -37,6 +45,14 @@ type AnnotatedEvent struct {
 type AllowedRepo struct {
 	Organization string `header:"Organization name"`
 	Name         string `header:"URL"`
+	BaseBranch   string `header:"Base branch"`
+}
+
+// CherryPickSource is the commit passed via --cherry-pick, along with the repo it's fetched from
+type CherryPickSource struct {
+	Organization string
+	Name         string
+	Commit       string
 }
 
 type OpenPrRequest struct {
@@ -46,6 +62,27 @@ type OpenPrRequest struct {
 	Retries int
 }
 
+// TokenUsage describes how much a single token in a token pool was used during a run, identifying the token without
+// revealing it
+type TokenUsage struct {
+	Token     string `header:"Token"`
+	Requests  int    `header:"API requests"`
+	Remaining string `header:"Rate limit remaining"`
+}
+
+// PatchSummary describes a patch of the changes made to a single repo, written instead of pushing them
+type PatchSummary struct {
+	Repo string `header:"Repo name"`
+	Path string `header:"Patch"`
+	// BaseBranch and BaseCommit are the branch and commit the patch was made on top of
+	BaseBranch string `header:"Base branch"`
+	BaseCommit string `header:"Base commit"`
+	Commits    int    `header:"Commits"`
+	Files      int    `header:"Files changed"`
+	Additions  int    `header:"Lines added"`
+	Deletions  int    `header:"Lines removed"`
+}
+
 // PullRequest is a simple two column representation of the repo name and its PR url
 type PullRequest struct {
 	Repo string `header:"Repo name"`
@@ -58,6 +95,18 @@ func (NoArgumentsPassedErr) Error() string {
 	return fmt.Sprint("You must pass a valid command or script path to git-xargs")
 }
 
+type MultipleChangeSourcesErr struct{}
+
+func (MultipleChangeSourcesErr) Error() string {
+	return fmt.Sprint("Pass only one of a command, a commit to cherry-pick via --cherry-pick, or a patch to git-xargs apply-patch")
+}
+
+type NoCacheDirPassedErr struct{}
+
+func (NoCacheDirPassedErr) Error() string {
+	return fmt.Sprint("You must pass the --cache-dir to prune")
+}
+
 type NoGithubOrgSuppliedErr struct{}
 
 func (NoGithubOrgSuppliedErr) Error() string {
@@ -105,5 +154,391 @@ func (NoCommandSuppliedErr) Error() string {
 type NoGithubOauthTokenProvidedErr struct{}
 
 func (NoGithubOauthTokenProvidedErr) Error() string {
-	return fmt.Sprintf("You must export a valid Github personal access token as GITHUB_OAUTH_TOKEN")
+	return fmt.Sprintf("You must supply a valid Github personal access token by exporting GITHUB_OAUTH_TOKEN, GITHUB_TOKEN or GH_TOKEN, or via --token-file or --token-command")
+}
+
+type GithubAppPrivateKeyMissingErr struct{}
+
+func (GithubAppPrivateKeyMissingErr) Error() string {
+	return fmt.Sprint("You must pass the path to your GitHub App's private key via --github-app-private-key-path when using --github-app-id")
+}
+
+type GithubAppPrivateKeyInvalidErr struct{}
+
+func (GithubAppPrivateKeyInvalidErr) Error() string {
+	return fmt.Sprint("The GitHub App private key is not a valid PEM-encoded RSA private key")
+}
+
+type GithubAppInstallationNotFoundErr struct {
+	Owner      string
+	Underlying error
+}
+
+func (err GithubAppInstallationNotFoundErr) Error() string {
+	return fmt.Sprintf("Could not find an installation of the GitHub App for %s: %s", err.Owner, err.Underlying)
+}
+
+type GithubAppOwnerUnknownErr struct {
+	Path string
+}
+
+func (err GithubAppOwnerUnknownErr) Error() string {
+	return fmt.Sprintf("Could not determine which GitHub App installation to use for API path %s. Pass --github-app-installation-id to use a single installation", err.Path)
+}
+
+type InvalidGitTransportErr struct {
+	Transport string
+}
+
+func (err InvalidGitTransportErr) Error() string {
+	return fmt.Sprintf("Unsupported --git-transport %s. Valid values are https and ssh", err.Transport)
+}
+
+type TokenCommandFailedErr struct {
+	Command    string
+	Stderr     string
+	Underlying error
+}
+
+func (err TokenCommandFailedErr) Error() string {
+	return fmt.Sprintf("The command passed via --token-command (%s) failed: %s: %s", err.Command, err.Underlying, err.Stderr)
+}
+
+type GitCredentialHelperFailedErr struct {
+	Host       string
+	Stderr     string
+	Underlying error
+}
+
+func (err GitCredentialHelperFailedErr) Error() string {
+	if err.Underlying == nil {
+		return fmt.Sprintf("The configured git credential helper did not supply credentials for %s: %s", err.Host, err.Stderr)
+	}
+	return fmt.Sprintf("The configured git credential helper did not supply credentials for %s: %s: %s", err.Host, err.Underlying, err.Stderr)
+}
+
+type InvalidTokenRotationStrategyErr struct {
+	Strategy string
+}
+
+func (err InvalidTokenRotationStrategyErr) Error() string {
+	return fmt.Sprintf("Unsupported --token-rotation-strategy %s. Valid values are round-robin and most-remaining", err.Strategy)
+}
+
+type InvalidPreflightCheckErr struct {
+	Mode string
+}
+
+func (err InvalidPreflightCheckErr) Error() string {
+	return fmt.Sprintf("Unsupported --preflight-check %s. Valid values are skip and abort", err.Mode)
+}
+
+type PreflightCheckFailedErr struct {
+	Failures []string
+}
+
+func (err PreflightCheckFailedErr) Error() string {
+	return fmt.Sprintf("Aborting because %d repos failed the preflight permission check: %s", len(err.Failures), strings.Join(err.Failures, ", "))
+}
+
+type WorkflowScopeMissingErr struct {
+	Files []string
+}
+
+func (err WorkflowScopeMissingErr) Error() string {
+	return fmt.Sprintf("GitHub rejects pushes that change GitHub Actions workflows unless the token has the workflow scope, and the changes touch %s", strings.Join(err.Files, ", "))
+}
+
+type InvalidGithubEnterpriseURLErr struct {
+	URL    string
+	Reason string
+}
+
+func (err InvalidGithubEnterpriseURLErr) Error() string {
+	return fmt.Sprintf("Invalid GitHub Enterprise URL %q: %s. Pass the URL of your server, such as https://ghe.example.com/ or https://ghe.example.com/api/v3/", err.URL, err.Reason)
+}
+
+type InvalidProxyURLErr struct {
+	URL string
+}
+
+func (err InvalidProxyURLErr) Error() string {
+	return fmt.Sprintf("Invalid proxy URL %q. Pass an absolute URL, such as http://proxy.example.com:3128", err.URL)
+}
+
+type CABundleInvalidErr struct{}
+
+func (CABundleInvalidErr) Error() string {
+	return fmt.Sprint("The CA bundle does not contain any PEM-encoded certificates")
+}
+
+type InvalidGitBackendErr struct {
+	Backend string
+}
+
+func (err InvalidGitBackendErr) Error() string {
+	return fmt.Sprintf("Unsupported --git-backend %s. Valid values are go-git and cli", err.Backend)
+}
+
+type InvalidBranchStrategyErr struct {
+	Strategy string
+}
+
+func (err InvalidBranchStrategyErr) Error() string {
+	return fmt.Sprintf("Unsupported --branch-strategy %s. Valid values are append, rebase and recreate", err.Strategy)
+}
+
+type IncompleteCommitAuthorErr struct{}
+
+func (IncompleteCommitAuthorErr) Error() string {
+	return fmt.Sprint("You must pass both --commit-author-name and --commit-author-email, or neither")
+}
+
+type InvalidCommitTrailerErr struct {
+	Trailer string
+}
+
+func (err InvalidCommitTrailerErr) Error() string {
+	return fmt.Sprintf("Invalid --commit-trailer %q. Trailers must be in the format of <token>: <value>, such as \"Co-authored-by: Jane Doe <jane@example.com>\"", err.Trailer)
+}
+
+type CommitIdentityMissingErr struct{}
+
+func (CommitIdentityMissingErr) Error() string {
+	return fmt.Sprint("--signoff needs to know who commits are authored by. Pass --commit-author-name and --commit-author-email, or set user.name and user.email in your git config")
+}
+
+type CommandChangedBranchErr struct {
+	Branch string
+	Head   string
+}
+
+func (err CommandChangedBranchErr) Error() string {
+	return fmt.Sprintf("The command checked out %s, but its changes must be made on %s", err.Head, err.Branch)
+}
+
+type CommandRewroteHistoryErr struct {
+	Branch string
+	Commit string
+}
+
+func (err CommandRewroteHistoryErr) Error() string {
+	return fmt.Sprintf("The command rewrote %s so that it no longer contains %s, the commit it was at before the command ran", err.Branch, err.Commit)
+}
+
+type SigningKeyMissingErr struct{}
+
+func (SigningKeyMissingErr) Error() string {
+	return fmt.Sprint("You must pass the path to the key to sign commits with via --signing-key when using --sign-commits")
+}
+
+type UnsupportedSigningKeyErr struct{}
+
+func (UnsupportedSigningKeyErr) Error() string {
+	return fmt.Sprint("The key passed via --signing-key is neither an OpenPGP nor an SSH private key")
+}
+
+type BranchRebaseConflictErr struct {
+	Branch string
+	Stderr string
+}
+
+func (err BranchRebaseConflictErr) Error() string {
+	return fmt.Sprintf("The commits on branch %s conflict with the latest default branch, so they could not be rebased onto it: %s", err.Branch, err.Stderr)
+}
+
+type InvalidCloneDepthErr struct {
+	Depth int
+}
+
+func (err InvalidCloneDepthErr) Error() string {
+	return fmt.Sprintf("Invalid --clone-depth %d. Pass a positive number of commits, or leave it unset to clone the full history", err.Depth)
+}
+
+type InvalidChangeSizeLimitErr struct {
+	Flag  string
+	Limit int64
+}
+
+func (err InvalidChangeSizeLimitErr) Error() string {
+	return fmt.Sprintf("Invalid --%s %d. Pass a positive number, or leave it unset for no limit", err.Flag, err.Limit)
+}
+
+type InvalidSecretPatternErr struct {
+	Pattern    string
+	Underlying error
+}
+
+func (err InvalidSecretPatternErr) Error() string {
+	return fmt.Sprintf("Invalid --secret-pattern %q: %s", err.Pattern, err.Underlying)
+}
+
+type InvalidSparsePathErr struct {
+	Path string
+}
+
+func (err InvalidSparsePathErr) Error() string {
+	return fmt.Sprintf("Invalid --sparse-paths entry %q. Pass a directory relative to the root of each repo", err.Path)
+}
+
+type GitCommandFailedErr struct {
+	Args       []string
+	Stderr     string
+	Underlying error
+}
+
+func (err GitCommandFailedErr) Error() string {
+	return fmt.Sprintf("git %s failed: %s: %s", strings.Join(err.Args, " "), err.Underlying, err.Stderr)
+}
+
+type LFSNotSupportedErr struct {
+	Reason string
+}
+
+func (err LFSNotSupportedErr) Error() string {
+	return fmt.Sprintf("Refusing to update a repo that uses Git LFS: %s", err.Reason)
+}
+
+type BaseBranchNotFoundErr struct {
+	Branch string
+}
+
+func (err BaseBranchNotFoundErr) Error() string {
+	return fmt.Sprintf("The base branch %s does not exist in the repo", err.Branch)
+}
+
+type ChangeTooLargeErr struct {
+	Detail string
+}
+
+func (err ChangeTooLargeErr) Error() string {
+	return fmt.Sprintf("The command's changes exceed the change size limits: %s", err.Detail)
+}
+
+type SecretsFoundErr struct {
+	Findings int
+}
+
+func (err SecretsFoundErr) Error() string {
+	return fmt.Sprintf("Found %d possible secrets in the command's changes. Refusing to push them", err.Findings)
+}
+
+type ValidationFailedErr struct {
+	Command    string
+	Underlying error
+}
+
+func (err ValidationFailedErr) Error() string {
+	return fmt.Sprintf("The validation command %q failed: %s", err.Command, err.Underlying)
+}
+
+type ValidationTimedOutErr struct {
+	Timeout time.Duration
+}
+
+func (err ValidationTimedOutErr) Error() string {
+	return fmt.Sprintf("timed out after %s", err.Timeout)
+}
+
+type InvalidValidateTimeoutErr struct {
+	Timeout time.Duration
+}
+
+func (err InvalidValidateTimeoutErr) Error() string {
+	return fmt.Sprintf("--validate-timeout must be positive, but %s was passed", err.Timeout)
+}
+
+type InteractiveRequiresTerminalErr struct{}
+
+func (InteractiveRequiresTerminalErr) Error() string {
+	return fmt.Sprint("--interactive asks whether to push each repo's changes, so stdin must be a terminal. Repos can't be piped in via stdin either")
+}
+
+type DryRunOutputDirRequiresDryRunErr struct{}
+
+func (DryRunOutputDirRequiresDryRunErr) Error() string {
+	return fmt.Sprint("--dry-run-output-dir only applies to dry runs. Pass --dry-run as well")
+}
+
+type NoBundleDirPassedErr struct{}
+
+func (NoBundleDirPassedErr) Error() string {
+	return fmt.Sprint("You must pass the directory of the bundle to write via --bundle-dir, or to publish as an argument")
+}
+
+type InvalidBundleErr struct {
+	Dir        string
+	Underlying error
+}
+
+func (err InvalidBundleErr) Error() string {
+	return fmt.Sprintf("%s is not a valid git-xargs bundle: %s", err.Dir, err.Underlying)
+}
+
+type UnsupportedBundleVersionErr struct {
+	Dir     string
+	Version int
+}
+
+func (err UnsupportedBundleVersionErr) Error() string {
+	return fmt.Sprintf("The bundle in %s has version %d, which this version of git-xargs can't publish", err.Dir, err.Version)
+}
+
+type PatchDidNotApplyErr struct {
+	Patch      string
+	Underlying error
+}
+
+func (err PatchDidNotApplyErr) Error() string {
+	return fmt.Sprintf("The patch %s does not apply: %s", err.Patch, err.Underlying)
+}
+
+type RepoNotInBundleErr struct {
+	Repo string
+}
+
+func (err RepoNotInBundleErr) Error() string {
+	return fmt.Sprintf("The repo %s is not in the bundle being published", err.Repo)
+}
+
+type InvalidFuzzErr struct {
+	Fuzz int
+}
+
+func (err InvalidFuzzErr) Error() string {
+	return fmt.Sprintf("--fuzz must not be negative, but %d was passed", err.Fuzz)
+}
+
+type InvalidCherryPickErr struct {
+	CherryPick string
+}
+
+func (err InvalidCherryPickErr) Error() string {
+	return fmt.Sprintf("--cherry-pick must be given as <github-org>/<repo-name>@<full-commit-sha>, but %q was passed", err.CherryPick)
+}
+
+type CherryPickSourceNotFoundErr struct {
+	Repo string
+}
+
+func (err CherryPickSourceNotFoundErr) Error() string {
+	return fmt.Sprintf("The repo %s to cherry-pick from does not exist, or can't be accessed", err.Repo)
+}
+
+type CherryPickConflictErr struct {
+	Commit string
+	Files  []string
+}
+
+func (err CherryPickConflictErr) Error() string {
+	return fmt.Sprintf("Cherry-picking %s conflicted in %s", err.Commit, strings.Join(err.Files, ", "))
+}
+
+type CherryPickFailedErr struct {
+	Commit     string
+	Underlying error
+}
+
+func (err CherryPickFailedErr) Error() string {
+	return fmt.Sprintf("Could not cherry-pick %s: %s", err.Commit, err.Underlying)
 }
@@ -13,7 +13,8 @@ import (
 
 const letterBytes = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
 
-// ConvertStringToAllowedRepo accepts a user-supplied repo in the format of <github-organization>/<repo-name>.
+// ConvertStringToAllowedRepo accepts a user-supplied repo in the format of <github-organization>/<repo-name>, optionally
+// followed by @<base-branch> to base the repo's changes on a branch other than its default branch.
 // It trims out stray characters that we might expect in a repos file that was copy-pasted from json or an array,
 // and it only returns an AllowedRepo if the user-supplied input looks valid. Note this does not actually look
 // up the repo via the GitHub API because that's slow, and we do it later when converting repo names to GitHub response structs.
@@ -26,6 +27,7 @@ func ConvertStringToAllowedRepo(repoInput string) *types.AllowedRepo {
 
 	trimmedLine := strings.TrimSpace(repoInput)
 	cleanedLine := charRegex.ReplaceAllString(trimmedLine, "")
+	cleanedLine, baseBranch, _ := strings.Cut(cleanedLine, "@")
 	orgAndRepoSlice := strings.Split(cleanedLine, "/")
 	// Guard against stray lines, extra dangling single quotes, etc
 	if len(orgAndRepoSlice) < 2 {
@@ -46,6 +48,7 @@ func ConvertStringToAllowedRepo(repoInput string) *types.AllowedRepo {
 		repo := &types.AllowedRepo{
 			Organization: parsedOrg,
 			Name:         parsedName,
+			BaseBranch:   baseBranch,
 		}
 		return repo
 	}
@@ -57,6 +60,24 @@ func ConvertStringToAllowedRepo(repoInput string) *types.AllowedRepo {
 	return nil
 }
 
+// cherryPickRegex matches a commit passed via --cherry-pick, such as gruntwork-io/cloud-nuke@<full-commit-sha>
+var cherryPickRegex = regexp.MustCompile(`^([^/@\s]+)/([^/@\s]+)@([0-9a-fA-F]{40})$`)
+
+// ConvertStringToCherryPickSource parses a commit passed via --cherry-pick in the format of
+// <github-organization>/<repo-name>@<commit-sha>. The full SHA is required, because git can only fetch a single commit
+// by its full SHA. It returns nil if the input doesn't look valid
+func ConvertStringToCherryPickSource(input string) *types.CherryPickSource {
+	match := cherryPickRegex.FindStringSubmatch(strings.TrimSpace(input))
+	if match == nil {
+		return nil
+	}
+	return &types.CherryPickSource{
+		Organization: match[1],
+		Name:         match[2],
+		Commit:       strings.ToLower(match[3]),
+	}
+}
+
 func RandStringBytes(n int) string {
 	b := make([]byte, n)
 	for i := range b {
@@ -68,3 +89,12 @@ func RandStringBytes(n int) string {
 func NewTestFileName() string {
 	return fmt.Sprintf("test-file-%s", RandStringBytes(9))
 }
+
+// commitTrailerRegex matches a git trailer, such as "Co-authored-by: Jane Doe <jane@example.com>"
+var commitTrailerRegex = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9-]*: *\S[^\n]*$`)
+
+// IsValidCommitTrailer returns true if the given string is a single git trailer that git interpret-trailers would
+// recognize as one
+func IsValidCommitTrailer(trailer string) bool {
+	return commitTrailerRegex.MatchString(trailer)
+}
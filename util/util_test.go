@@ -0,0 +1,29 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/gruntwork-io/git-xargs/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertStringToAllowedRepo(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		input    string
+		expected *types.AllowedRepo
+	}{
+		{"gruntwork-io/cloud-nuke", &types.AllowedRepo{Organization: "gruntwork-io", Name: "cloud-nuke"}},
+		{`"gruntwork-io/fetch",`, &types.AllowedRepo{Organization: "gruntwork-io", Name: "fetch"}},
+		{"gruntwork-io/terragrunt@release", &types.AllowedRepo{Organization: "gruntwork-io", Name: "terragrunt", BaseBranch: "release"}},
+		{"gruntwork-io/terragrunt@release/v1/hotfix", &types.AllowedRepo{Organization: "gruntwork-io", Name: "terragrunt", BaseBranch: "release/v1/hotfix"}},
+		{"cloud-nuke", nil},
+		{"gruntwork-io/", nil},
+		{"/cloud-nuke@main", nil},
+	}
+
+	for _, testCase := range testCases {
+		assert.Equal(t, testCase.expected, ConvertStringToAllowedRepo(testCase.input), testCase.input)
+	}
+}